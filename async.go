@@ -0,0 +1,244 @@
+package logger
+
+import (
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// DropPolicy defines what happens if the queue of the asynchronous mode is full
+type DropPolicy uint8
+
+const (
+	// Wait until there is space in the queue
+	DropPolicyBlock DropPolicy = iota
+
+	// Drop the oldest queued message to make room for the new one
+	DropPolicyDropOldest
+
+	// Drop the new message
+	DropPolicyDropNewest
+)
+
+// AsyncConfig contains configuration options to write the messages to the targets
+// in a background goroutine. The logging calls only put the messages into a queue, so a slow
+// disk or network target does not stall the caller.
+//
+// Fatal messages are always written synchronously after all queued messages were written.
+// Messages that are logged by a target or hook within the background goroutine while the queue
+// is full are also written synchronously, because the goroutine can't wait for itself.
+// Call Close() or Flush() before the program exits so that no queued messages are lost
+type AsyncConfig struct {
+
+	// Maximum number of queued messages. Defaults to 1024
+	QueueSize int
+
+	// Behavior if the queue is full. Defaults to DropPolicyBlock
+	Policy DropPolicy
+
+	// Interval in which the number of dropped messages is logged as a warning.
+	// Defaults to 10 seconds
+	DropReportInterval time.Duration
+}
+
+// asyncWriter writes the queued entries in a background goroutine
+type asyncWriter struct {
+	config AsyncConfig
+	queue  chan asyncItem
+
+	// Number of messages dropped since the last report
+	dropped atomic.Uint64
+
+	// Goroutine ID of the background goroutine (see isWorker())
+	worker atomic.Uint64
+
+	// Number of items that are currently queued by enqueue(). The background goroutine
+	// waits for them after the writer was closed, so that no item is lost
+	pending atomic.Int64
+
+	closed atomic.Bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// asyncItem is either an entry to write or a marker that is closed after
+// all previous entries were written
+type asyncItem struct {
+	entry  Entry
+	marker chan struct{}
+}
+
+// newAsyncWriter creates the writer and starts the background goroutine.
+// The number of dropped messages is reported with the given logger
+func newAsyncWriter(config AsyncConfig, l *Logger) *asyncWriter {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1024
+	}
+	if config.DropReportInterval <= 0 {
+		config.DropReportInterval = 10 * time.Second
+	}
+
+	w := &asyncWriter{
+		config: config,
+		queue:  make(chan asyncItem, config.QueueSize),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go w.run(l)
+
+	return w
+}
+
+// run writes the queued entries until the writer is closed
+func (w *asyncWriter) run(l *Logger) {
+	defer close(w.done)
+	w.worker.Store(getGoroutineID())
+
+	ticker := time.NewTicker(w.config.DropReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case item := <-w.queue:
+			w.handle(item)
+		case <-ticker.C:
+			w.reportDropped(l)
+		case <-w.stop:
+			// Write the remaining entries including the ones that are queued right now
+			for w.pending.Load() > 0 || len(w.queue) > 0 {
+				select {
+				case item := <-w.queue:
+					w.handle(item)
+				default:
+					runtime.Gosched()
+				}
+			}
+			w.reportDropped(l)
+			return
+		}
+	}
+}
+
+// handle writes the entry of the item or closes its marker
+func (w *asyncWriter) handle(item asyncItem) {
+	if item.marker != nil {
+		close(item.marker)
+	} else {
+		item.entry.logger.dispatch(item.entry)
+	}
+}
+
+// reportDropped logs the number of dropped messages since the last report
+func (w *asyncWriter) reportDropped(l *Logger) {
+	if dropped := w.dropped.Swap(0); dropped > 0 {
+		message := "Dropped " + strconv.FormatUint(dropped, 10) + " log messages because the queue was full"
+		l.dispatch(Entry{Time: time.Now(), Level: LevelWarning, Message: message, File: "#unknown", logger: l, template: message})
+	}
+}
+
+// write queues the entry. If the writer was closed or the entry is logged by a target
+// within the background goroutine while the queue is full, the entry is written directly
+func (w *asyncWriter) write(e Entry) {
+	if !w.enqueue(asyncItem{entry: e}) {
+		e.logger.dispatch(e)
+	}
+}
+
+// enqueue puts the item into the queue according to the drop policy. It returns false if the
+// writer was closed or if the queue is full and this is called by the background goroutine,
+// which would wait for itself
+func (w *asyncWriter) enqueue(item asyncItem) bool {
+	w.pending.Add(1)
+	defer w.pending.Add(-1)
+	if w.closed.Load() {
+		return false
+	}
+
+	select {
+	case w.queue <- item:
+		return true
+	default:
+	}
+	if w.isWorker() {
+		return false
+	}
+
+	// Markers are never dropped
+	policy := w.config.Policy
+	if item.marker != nil {
+		policy = DropPolicyBlock
+	}
+
+	switch policy {
+	case DropPolicyDropNewest:
+		w.dropped.Add(1)
+	case DropPolicyDropOldest:
+		for {
+			select {
+			case w.queue <- item:
+				return true
+			default:
+			}
+
+			select {
+			case oldest := <-w.queue:
+				if oldest.marker != nil {
+					// Markers are kept → queue it again and drop the new entry instead
+					w.dropped.Add(1)
+					if !w.send(oldest) {
+						close(oldest.marker)
+					}
+					return true
+				}
+				w.dropped.Add(1)
+			default:
+			}
+		}
+	default:
+		return w.send(item)
+	}
+
+	return true
+}
+
+// send puts the item into the queue and waits until there is space.
+// It returns false if the writer was closed while waiting
+func (w *asyncWriter) send(item asyncItem) bool {
+	select {
+	case w.queue <- item:
+		return true
+	case <-w.stop:
+		return false
+	}
+}
+
+// isWorker returns whether it's called by the background goroutine
+func (w *asyncWriter) isWorker() bool {
+	return w.worker.Load() == getGoroutineID()
+}
+
+// flush waits until all entries queued before were written.
+// Within the background goroutine it returns immediately
+func (w *asyncWriter) flush() {
+	if w.isWorker() {
+		return
+	}
+
+	marker := make(chan struct{})
+	if w.enqueue(asyncItem{marker: marker}) {
+		<-marker
+	}
+}
+
+// close writes all queued entries and stops the background goroutine. Within the
+// background goroutine it doesn't wait for the remaining entries
+func (w *asyncWriter) close() {
+	if !w.closed.Swap(true) {
+		close(w.stop)
+	}
+
+	if !w.isWorker() {
+		<-w.done
+	}
+}
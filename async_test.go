@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// reentrantTarget logs two further messages with the logger while writing the first one
+type reentrantTarget struct {
+	logger   *Logger
+	mux      sync.Mutex
+	messages []string
+}
+
+func (t *reentrantTarget) Enabled(level Level) bool { return true }
+func (t *reentrantTarget) Close() error             { return nil }
+func (t *reentrantTarget) Write(e Entry) error {
+	t.mux.Lock()
+	t.messages = append(t.messages, e.Message)
+	t.mux.Unlock()
+
+	if e.Message == "outer" {
+		t.logger.Info("inner 1")
+		t.logger.Info("inner 2")
+		t.logger.Flush()
+	}
+	return nil
+}
+
+func TestAsyncReentrantWrite(t *testing.T) {
+	target := &reentrantTarget{}
+	l := NewLogger(&Logger{
+		Level:   LevelOff,
+		Targets: []Target{target},
+		Async:   &AsyncConfig{QueueSize: 1},
+	})
+	target.logger = l
+
+	done := make(chan struct{})
+	go func() {
+		l.Info("outer")
+		l.Flush()
+		l.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writing from within a target deadlocked")
+	}
+
+	if messages := strings.Join(target.messages, ","); len(target.messages) != 3 || !strings.HasPrefix(messages, "outer,") {
+		t.Errorf("expected all three messages, got %q", messages)
+	}
+}
@@ -0,0 +1,194 @@
+// auditlog provides a target that writes tamper-evident audit logs into a file.
+//
+// Every line starts with a hash that is calculated over the hash of the previous line and
+// the formatted entry ("<hash> <entry>"). Modifying, inserting or removing a line breaks
+// the chain, which is detected by Verify(). To detect that lines were removed at the end
+// of the file, store the hash of the last line (see Target.LastHash()) at another place.
+//
+// If a key is configured, the hashes are HMAC-SHA256 values. Otherwise SHA-256 is used and
+// an attacker with write access could recalculate the whole chain
+package auditlog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+)
+
+// Hash of the "previous line" of the first line in a file
+var genesisHash = strings.Repeat("0", sha256.Size*2)
+
+// Config contains the configuration options of the audit target
+type Config struct {
+
+	// Minimum log level of the entries to write
+	Level logger.Level
+
+	// Path of the audit log file. Entries are appended to an existing file
+	Path string
+
+	// Formatter used for the entries. Defaults to JSON.
+	// Line breaks in the formatted entries are escaped as "\n"
+	Formatter logger.Formatter
+
+	// Secret key for HMAC-SHA256 hashes. If empty, SHA-256 hashes are used
+	Key []byte
+}
+
+// Target writes the entries with a hash chain into a file
+type Target struct {
+	config Config
+
+	lock     sync.Mutex
+	file     *os.File
+	lastHash string
+}
+
+// VerifyError is returned by Verify() if the hash of a line is invalid
+type VerifyError struct {
+
+	// Number of the first invalid line starting with 1
+	Line int
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("auditlog: hash chain is broken at line %d", e.Line)
+}
+
+// New opens the audit log file. If it already contains entries, the chain is verified
+// and continued with the hash of the last line. Add the target to the field "Targets"
+// of a logger
+func New(config Config) (*Target, error) {
+	if config.Formatter == nil {
+		config.Formatter = logger.JSONFormatter{}
+	}
+
+	file, err := os.OpenFile(config.Path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	lastHash, err := Verify(file, config.Key)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &Target{config: config, file: file, lastHash: lastHash}, nil
+}
+
+// Enabled returns whether entries with the level are written
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.config.Level <= level
+}
+
+// Write appends the entry with its hash to the file. The file is synced after every entry
+func (t *Target) Write(e logger.Entry) error {
+	message, err := t.config.Formatter.Format(e)
+	if err != nil {
+		return err
+	}
+	message = bytes.ReplaceAll(message, []byte("\n"), []byte(`\n`))
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.file == nil {
+		return errors.New("auditlog: target is closed")
+	}
+
+	hash := calculateHash(t.config.Key, t.lastHash, message)
+	line := make([]byte, 0, len(hash)+len(message)+2)
+	line = append(line, hash...)
+	line = append(line, ' ')
+	line = append(append(line, message...), '\n')
+
+	if _, err := t.file.Write(line); err != nil {
+		return err
+	}
+	t.lastHash = hash
+
+	return t.file.Sync()
+}
+
+// LastHash returns the hash of the last written line. Store it outside of the file
+// to detect that lines were removed from the end
+func (t *Target) LastHash() string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.lastHash
+}
+
+// Close closes the file
+func (t *Target) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.file == nil {
+		return nil
+	}
+	err := t.file.Close()
+	t.file = nil
+
+	return err
+}
+
+// Verify checks the hash chain of an audit log that was written with the given key.
+// It returns the hash of the last line or a *VerifyError for the first invalid line
+func Verify(r io.Reader, key []byte) (lastHash string, err error) {
+	lastHash = genesisHash
+
+	reader := bufio.NewReader(r)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if len(line) == 0 && err == io.EOF {
+			return lastHash, nil
+		} else if err != nil && err != io.EOF {
+			return "", err
+		}
+
+		// Every line has to be completed by a line break
+		hash, message, ok := bytes.Cut(bytes.TrimSuffix(line, []byte("\n")), []byte(" "))
+		if !ok || err == io.EOF || !hmac.Equal(hash, []byte(calculateHash(key, lastHash, message))) {
+			return "", &VerifyError{Line: lineNumber}
+		}
+		lastHash = string(hash)
+	}
+}
+
+// VerifyFile checks the hash chain of the audit log file (see Verify())
+func VerifyFile(path string, key []byte) (lastHash string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	return Verify(file, key)
+}
+
+// calculateHash returns the hex encoded hash of the previous hash and the message
+func calculateHash(key []byte, previous string, message []byte) string {
+	var h hash.Hash
+	if len(key) > 0 {
+		h = hmac.New(sha256.New, key)
+	} else {
+		h = sha256.New()
+	}
+
+	h.Write([]byte(previous))
+	h.Write(message)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,272 @@
+// batch collects items in the background and passes them in batches to a
+// flush function. It's used by the network targets and can be used for custom targets:
+//
+//	batcher := batch.New(batch.Options[[]byte]{MaxItems: 100, MaxLatency: time.Second}, func(lines [][]byte) {
+//		// Send the lines
+//	})
+//
+// A batch is flushed when it contains "MaxItems" items, when it would exceed "MaxBytes"
+// or when the oldest item was queued "MaxLatency" ago
+package batch
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Options configures the batching behavior
+type Options[T any] struct {
+
+	// Maximum number of items per batch. Defaults to 100
+	MaxItems int
+
+	// Maximum size of a batch in bytes determined by "Size". An item that is larger
+	// than this is flushed as a single batch. No limit is applied if this is <= 0
+	MaxBytes int
+
+	// Function that returns the size of an item in bytes. It's required for "MaxBytes"
+	Size func(item T) int
+
+	// Maximum time an item is kept before the batch is flushed. Defaults to 5 seconds
+	MaxLatency time.Duration
+
+	// Maximum number of items that are queued. If the queue is full,
+	// new items are dropped. Defaults to ten times "MaxItems"
+	QueueSize int
+
+	// Block instead of dropping new items when the queue is full
+	Block bool
+}
+
+// Stats contains metrics about the flushed batches
+type Stats struct {
+
+	// Number of flushed batches
+	Batches uint64
+
+	// Number of flushed items
+	Items uint64
+
+	// Size of the flushed items in bytes. It's only counted if "Size" is set
+	Bytes uint64
+
+	// Number of items that were dropped because the queue was full
+	Dropped uint64
+
+	// Number of items that are queued or collected for the next batch
+	Queued int
+
+	// Number of items and size of the last batch
+	LastBatchItems int
+	LastBatchBytes int
+
+	// Duration of the last call and the longest call of the flush function
+	LastFlushLatency time.Duration
+	MaxFlushLatency  time.Duration
+
+	// Total duration of all calls of the flush function
+	TotalFlushLatency time.Duration
+}
+
+// AverageBatchItems returns the average number of items per batch
+func (s Stats) AverageBatchItems() float64 {
+	if s.Batches == 0 {
+		return 0
+	}
+	return float64(s.Items) / float64(s.Batches)
+}
+
+// AverageFlushLatency returns the average duration of the flush function
+func (s Stats) AverageFlushLatency() time.Duration {
+	if s.Batches == 0 {
+		return 0
+	}
+	return s.TotalFlushLatency / time.Duration(s.Batches)
+}
+
+// Batcher collects items and flushes them in batches
+type Batcher[T any] struct {
+	options Options[T]
+	flush   func(items []T)
+
+	queue   chan T
+	flushCh chan chan struct{}
+	done    chan struct{}
+	once    sync.Once
+	wg      sync.WaitGroup
+
+	// Number of items collected for the next batch
+	pending atomic.Int64
+	dropped atomic.Uint64
+
+	statsMu sync.Mutex
+	stats   Stats
+}
+
+// New creates a new batcher and starts the background worker
+func New[T any](options Options[T], flush func(items []T)) *Batcher[T] {
+	if options.MaxItems <= 0 {
+		options.MaxItems = 100
+	}
+	if options.MaxLatency <= 0 {
+		options.MaxLatency = 5 * time.Second
+	}
+	if options.QueueSize <= 0 {
+		options.QueueSize = options.MaxItems * 10
+	}
+	if options.Size == nil {
+		options.MaxBytes = 0
+	}
+
+	b := &Batcher[T]{
+		options: options,
+		flush:   flush,
+		queue:   make(chan T, options.QueueSize),
+		flushCh: make(chan chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+	go b.run()
+
+	return b
+}
+
+// Add queues the item. It returns false if the queue is full and the item was dropped.
+// With the option "Block" it waits until there is space in the queue
+func (b *Batcher[T]) Add(item T) bool {
+	select {
+	case <-b.done:
+		return false
+	default:
+	}
+
+	if b.options.Block {
+		select {
+		case b.queue <- item:
+			return true
+		case <-b.done:
+			return false
+		}
+	}
+
+	select {
+	case b.queue <- item:
+		return true
+	default:
+		b.dropped.Add(1)
+		return false
+	}
+}
+
+// Flush flushes all queued items and waits until they were passed to the flush function
+func (b *Batcher[T]) Flush() {
+	ch := make(chan struct{})
+	select {
+	case b.flushCh <- ch:
+		<-ch
+	case <-b.done:
+	}
+}
+
+// Close flushes all queued items and stops the background worker
+func (b *Batcher[T]) Close() {
+	b.once.Do(func() {
+		close(b.done)
+	})
+	b.wg.Wait()
+}
+
+// Stats returns the metrics of the flushed batches
+func (b *Batcher[T]) Stats() Stats {
+	b.statsMu.Lock()
+	stats := b.stats
+	b.statsMu.Unlock()
+
+	stats.Dropped = b.dropped.Load()
+	stats.Queued = len(b.queue) + int(b.pending.Load())
+	return stats
+}
+
+// run collects the items and flushes them if the batch is full or the latency was reached
+func (b *Batcher[T]) run() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.options.MaxLatency)
+	defer ticker.Stop()
+
+	items := make([]T, 0, b.options.MaxItems)
+	size := 0
+	flush := func() {
+		if len(items) > 0 {
+			b.flushBatch(items, size)
+			items = make([]T, 0, b.options.MaxItems)
+			size = 0
+			b.pending.Store(0)
+		}
+	}
+	add := func(item T) {
+		itemSize := 0
+		if b.options.Size != nil {
+			itemSize = b.options.Size(item)
+		}
+		if b.options.MaxBytes > 0 && size+itemSize > b.options.MaxBytes {
+			flush()
+		}
+
+		items = append(items, item)
+		size += itemSize
+		b.pending.Store(int64(len(items)))
+		if len(items) >= b.options.MaxItems || (b.options.MaxBytes > 0 && size >= b.options.MaxBytes) {
+			flush()
+		}
+	}
+	drain := func() {
+		for {
+			select {
+			case item := <-b.queue:
+				add(item)
+			default:
+				flush()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case item := <-b.queue:
+			add(item)
+		case <-ticker.C:
+			flush()
+		case ch := <-b.flushCh:
+			drain()
+			close(ch)
+		case <-b.done:
+			drain()
+			return
+		}
+	}
+}
+
+// flushBatch passes the items to the flush function and updates the metrics
+func (b *Batcher[T]) flushBatch(items []T, size int) {
+	start := time.Now()
+	b.flush(items)
+	latency := time.Since(start)
+
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+
+	b.stats.Batches++
+	b.stats.Items += uint64(len(items))
+	b.stats.Bytes += uint64(size)
+	b.stats.LastBatchItems = len(items)
+	b.stats.LastBatchBytes = size
+	b.stats.LastFlushLatency = latency
+	b.stats.TotalFlushLatency += latency
+	if latency > b.stats.MaxFlushLatency {
+		b.stats.MaxFlushLatency = latency
+	}
+}
@@ -0,0 +1,30 @@
+package logger
+
+import "sync"
+
+// Maximum capacity of a buffer that is put back into the pool.
+// Larger buffers are released so that a single huge message doesn't bloat the pool
+const maxPooledBufferSize = 64 << 10
+
+// bufferPool contains byte slices used to format the entries without allocations
+var bufferPool = sync.Pool{
+	New: func() any {
+		b := make([]byte, 0, 512)
+		return &b
+	},
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *[]byte {
+	b := bufferPool.Get().(*[]byte)
+	*b = (*b)[:0]
+	return b
+}
+
+// putBuffer puts the buffer back into the pool
+func putBuffer(b *[]byte) {
+	if cap(*b) > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(b)
+}
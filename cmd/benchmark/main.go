@@ -0,0 +1,84 @@
+// benchmark measures the performance and the allocations of the hot logging path.
+// Run it with "go run ./cmd/benchmark". The console output is discarded
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+)
+
+func main() {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	stdout := os.Stdout
+	os.Stdout, os.Stderr = devNull, devNull
+
+	dir, err := os.MkdirTemp("", "logger-benchmark")
+	if err != nil {
+		fmt.Fprintln(stdout, err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(dir)
+
+	fields := map[string]any{"user": "mario", "attempt": 3, "ok": true}
+	benchmarks := []struct {
+		name   string
+		logger *logger.Logger
+		fields bool
+	}{
+		{"Disabled", newLogger(logger.LevelError, logger.FormatText, false, ""), false},
+		{"Text", newLogger(logger.LevelTrace, logger.FormatText, false, ""), false},
+		{"TextNoSource", newLogger(logger.LevelTrace, logger.FormatText, false, ""), false},
+		{"TextColored", newLogger(logger.LevelTrace, logger.FormatText, true, ""), false},
+		{"TextFields", newLogger(logger.LevelTrace, logger.FormatText, false, ""), true},
+		{"JSON", newLogger(logger.LevelTrace, logger.FormatJSON, false, ""), false},
+		{"JSONFields", newLogger(logger.LevelTrace, logger.FormatJSON, false, ""), true},
+		{"Logfmt", newLogger(logger.LevelTrace, logger.FormatLogfmt, false, ""), false},
+		{"LogfmtFields", newLogger(logger.LevelTrace, logger.FormatLogfmt, false, ""), true},
+		{"File", newLogger(logger.LevelError, logger.FormatText, false, filepath.Join(dir, "bench.log")), false},
+	}
+
+	for _, bench := range benchmarks {
+		l := bench.logger
+		l.PrintSource = bench.name != "TextNoSource"
+		if bench.fields {
+			l = l.WithFields(fields)
+		}
+
+		result := testing.Benchmark(func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				l.Info("Player %s joined the game", "mario")
+			}
+		})
+		fmt.Fprintf(stdout, "%-14s %s\t%s\n", bench.name, result.String(), result.MemString())
+		l.Close()
+	}
+}
+
+// newLogger creates a logger for the benchmark. The file is only used if a path is given
+func newLogger(level logger.Level, format logger.Format, colored bool, path string) *logger.Logger {
+	os.Setenv("TERMINAL_ENABLE_COLORS", "1")
+	if !colored {
+		os.Unsetenv("TERMINAL_ENABLE_COLORS")
+	}
+
+	return logger.NewLogger(&logger.Logger{
+		Level:         level,
+		Format:        format,
+		ColoredOutput: colored,
+		PrintSource:   true,
+		File: &logger.FileLogger{
+			Level:  logger.LevelTrace,
+			Path:   path,
+			Format: format,
+		},
+	})
+}
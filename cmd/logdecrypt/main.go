@@ -0,0 +1,51 @@
+// logdecrypt decrypts log files that were written with FileLogger.EncryptionKey and
+// prints the messages to stdout. The key is read hex or base64 encoded from an environment variable:
+//
+//	LOGGER_KEY=... go run ./cmd/logdecrypt ./logs/app.log
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+)
+
+func main() {
+	keyEnv := flag.String("key-env", "LOGGER_KEY", "Name of the environment variable that contains the key")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [-key-env NAME] FILE...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	key, err := logger.KeyFromEnv(*keyEnv)()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for _, path := range flag.Args() {
+		if err := decryptFile(path, key); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+			os.Exit(1)
+		}
+	}
+}
+
+// decryptFile prints the decrypted messages of the file
+func decryptFile(path string, key []byte) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return logger.DecryptLog(file, os.Stdout, key)
+}
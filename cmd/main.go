@@ -29,6 +29,9 @@ func main() {
 	logger.Warning("But it would not be safe to use it")
 	logger.Error("Now it happend")
 
+	// Attach structured fields to the messages
+	logger.WithField("player", "mario").Info("Entered level %d", 1)
+
 	// New logger with the same file to log in
 	lOther := &logger.Logger{
 		ColoredOutput: false,
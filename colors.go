@@ -1,60 +1,147 @@
 package logger
 
 import (
+	"io"
 	"os"
 )
 
 // ColorConfig contains configuration options to write
 // colored text to the console.
 type colorConfig struct {
-	enableColors bool
+	// Whether colors are enabled for stdout and stderr
+	enableColors    bool
+	enableColorsErr bool
 }
 
-// Default ANSI color code definitions.
-// The variable contains a function that will be padded by the
-// matching color. You can also specify replace values after the string
-// using printf.
-var (
-	colPurple      = color("\033[1;35m", "\033[0m")
-	colPurpleLight = color("\033[0;35m", "\033[0m")
-	colRed         = color("\033[1;31m", "\033[0m")
-	colYellow      = color("\033[1;33m", "\033[0m")
-	colBlue        = color("\033[1;34m", "\033[0m")
-	colBlueLight   = color("\033[0;34m", "\033[0m")
-	colCyan        = color("\033[1;36m", "\033[0m")
-	colGreen       = color("\033[0;32m", "\033[0m")
+// colorCode is an ANSI escape sequence that colors the following text.
+// The text has to be terminated with colReset
+type colorCode string
+
+// Default ANSI color code definitions
+const (
+	colPurple      colorCode = "\033[1;35m"
+	colPurpleLight colorCode = "\033[0;35m"
+	colRed         colorCode = "\033[1;31m"
+	colYellow      colorCode = "\033[1;33m"
+	colBlue        colorCode = "\033[1;34m"
+	colBlueLight   colorCode = "\033[0;34m"
+	colCyan        colorCode = "\033[1;36m"
+	colGreen       colorCode = "\033[0;32m"
+	colDim         colorCode = "\033[2m"
+
+	colReset = "\033[0m"
 )
 
-// Color returns a function that pads the string with the given color code
-func color(code, termination string) func(str string) string {
-	return func(str string) string {
-		return code + str + termination
+// ColorStyle defines which parts of the text messages are colored
+type ColorStyle uint8
+
+const (
+	// The level and the message are colored in the color of the level.
+	// The time, source, name and prefix are colored in own colors
+	ColorStyleDefault ColorStyle = iota
+
+	// Only the level tag like "[INFO ]" is colored
+	ColorStyleLevel
+
+	// The level tag is colored and the time, source, name and prefix are dimmed
+	ColorStyleDimMetadata
+
+	// The whole line is colored in the color of the level
+	ColorStyleFullLine
+)
+
+// textColors contains the colors of the parts of a text message.
+// Parts with an empty color are not colored
+type textColors struct {
+	line, level, time, source, name, prefix, message colorCode
+}
+
+// getColors returns the colors of the parts of a message with the given level
+func (style ColorStyle) getColors(level Level) textColors {
+	levelColor := level.getColor()
+
+	switch style {
+	case ColorStyleLevel:
+		return textColors{level: levelColor}
+	case ColorStyleDimMetadata:
+		return textColors{level: levelColor, time: colDim, source: colDim, name: colDim, prefix: colDim}
+	case ColorStyleFullLine:
+		return textColors{line: levelColor}
+	default:
+		return textColors{
+			level: levelColor, time: colCyan, source: colPurple,
+			name: colPurpleLight, prefix: colBlueLight, message: levelColor,
+		}
 	}
 }
 
 // NewColorConfig prepares and creates a new color config.
+// If coloring is enabled, the environment variables are checked in the following order:
+//   - "NO_COLOR" or "TERMINAL_DISABLE_COLORS" set to any value disables colors
+//   - "CLICOLOR_FORCE" or "FORCE_COLOR" set to a value other than "0" or
+//     "TERMINAL_ENABLE_COLORS" set to any value forces colors
+//   - "CLICOLOR" set to "0" disables colors
+//
+// Otherwise colors are enabled for each output independently if it's a terminal
+// that supports colors. Outputs that are redirected to a file or piped to another
+// program are not colored. Writers other than *os.File are never treated as a terminal.
 // This function could panic because of low level system access
-func newColorConfig(enable bool) (conf *colorConfig) {
+func newColorConfig(enable bool, out io.Writer, errOut io.Writer) (conf *colorConfig) {
 	conf = &colorConfig{}
 
 	// Validate if ANSI codes are supported by the terminal
 	if enable {
-		if _, exist := os.LookupEnv("TERMINAL_DISABLE_COLORS"); exist {
+		if os.Getenv("NO_COLOR") != "" || isEnvSet("TERMINAL_DISABLE_COLORS") {
 			return
-		} else if _, exist := os.LookupEnv("TERMINAL_ENABLE_COLORS"); exist {
+		} else if isEnvEnabled("CLICOLOR_FORCE") || isEnvEnabled("FORCE_COLOR") || isEnvSet("TERMINAL_ENABLE_COLORS") {
 			conf.enableColors = true
+			conf.enableColorsErr = true
+			return
+		} else if os.Getenv("CLICOLOR") == "0" {
 			return
 		}
 
-		conf.enableColors = conf.isColoringSupported()
+		conf.enableColors = isColoringSupported(out)
+		conf.enableColorsErr = isColoringSupported(errOut)
 	}
 
 	return
 }
 
+// enabled returns whether colors are enabled for stderr or stdout
+func (c colorConfig) enabled(stderr bool) bool {
+	if stderr {
+		return c.enableColorsErr
+	}
+	return c.enableColors
+}
+
+// isColoringSupported returns whether the writer is a terminal that supports colors
+func isColoringSupported(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	return ok && isTerminal(file)
+}
+
+// isEnvSet returns whether the environment variable is set (even if it's empty)
+func isEnvSet(name string) bool {
+	_, exist := os.LookupEnv(name)
+	return exist
+}
+
+// isEnvEnabled returns whether the environment variable is set to a
+// value other than "0" or "false"
+func isEnvEnabled(name string) bool {
+	value := os.Getenv(name)
+	return value != "" && value != "0" && value != "false"
+}
+
 // getColor returns the matching color for the level
-func (l Level) getColor() func(str string) string {
-	switch l {
+func (l Level) getColor() colorCode {
+	if definition, ok := getCustomLevel(l); ok && definition.Color != "" {
+		return colorCode(definition.Color)
+	}
+
+	switch l.predefined() {
 	case LevelTrace:
 		return colPurpleLight
 	case LevelDebug:
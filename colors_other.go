@@ -2,6 +2,16 @@
 
 package logger
 
-func (c colorConfig) isColoringSupported() bool {
+import (
+	"io"
+	"os"
+)
+
+func isTerminal(file *os.File) bool {
 	return false
 }
+
+// wrapConsoleWriter returns the writer unchanged. The ANSI codes are written as they are
+func wrapConsoleWriter(w io.Writer) io.Writer {
+	return w
+}
@@ -2,9 +2,24 @@
 
 package logger
 
-import "os"
+import (
+	"io"
+	"os"
+)
 
-func (c colorConfig) isColoringSupported() bool {
-	// Check if $TERM variable is set. Almost every terminal does support coloring in linux
-	return os.Getenv("TERM") != ""
+// isTerminal returns whether the file is a terminal that supports colors.
+// Almost every terminal does support coloring in linux if the $TERM variable is set
+func isTerminal(file *os.File) bool {
+	if os.Getenv("TERM") == "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	// Files and pipes are no character devices
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// wrapConsoleWriter returns the writer unchanged. The ANSI codes are written as they are
+func wrapConsoleWriter(w io.Writer) io.Writer {
+	return w
 }
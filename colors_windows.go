@@ -1,23 +1,129 @@
 package logger
 
 import (
+	"bytes"
+	"io"
 	"os"
+	"strings"
 
 	"golang.org/x/sys/windows"
 )
 
-func (c colorConfig) isColoringSupported() bool {
+// isTerminal returns whether the file is a console that supports colors
+func isTerminal(file *os.File) bool {
 
 	// In cmd ANSI colors are not supported by default from the beggining on (>16257) → enable explicit support via
-	// the flag ENABLE_VIRTUAL_TERMINAL_PROCESSING
-	stdout := windows.Handle(os.Stdout.Fd())
+	// the flag ENABLE_VIRTUAL_TERMINAL_PROCESSING.
+	// Getting the console mode fails if the output is redirected to a file or a pipe
+	handle := windows.Handle(file.Fd())
 	var originalMode uint32
 
-	if windows.GetConsoleMode(stdout, &originalMode) == nil {
-		if windows.SetConsoleMode(stdout, originalMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING) == nil {
-			return true
-		}
+	if windows.GetConsoleMode(handle, &originalMode) == nil {
+		// Older consoles don't support ANSI codes. They are colored by a legacyConsoleWriter
+		windows.SetConsoleMode(handle, originalMode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING)
+		return true
 	}
 
 	return false
 }
+
+// wrapConsoleWriter returns a legacyConsoleWriter for consoles that don't support ANSI codes
+// (older than Windows 10 like Windows Server 2012 and 2016). Otherwise the writer is returned
+func wrapConsoleWriter(w io.Writer) io.Writer {
+	file, ok := w.(*os.File)
+	if !ok {
+		return w
+	}
+
+	handle := windows.Handle(file.Fd())
+	var mode uint32
+	var info windows.ConsoleScreenBufferInfo
+	if windows.GetConsoleMode(handle, &mode) != nil || mode&windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING != 0 ||
+		windows.GetConsoleScreenBufferInfo(handle, &info) != nil {
+		return w
+	}
+
+	return &legacyConsoleWriter{file: file, handle: handle, defaultAttributes: info.Attributes, attributes: info.Attributes}
+}
+
+var procSetConsoleTextAttribute = windows.NewLazySystemDLL("kernel32.dll").NewProc("SetConsoleTextAttribute")
+
+// Text attributes of the console
+const (
+	foregroundBlue      = 0x1
+	foregroundGreen     = 0x2
+	foregroundRed       = 0x4
+	foregroundIntensity = 0x8
+	foregroundMask      = 0xf
+)
+
+// legacyConsoleWriter translates the ANSI color codes of the messages to
+// text attributes of the console
+type legacyConsoleWriter struct {
+	file              *os.File
+	handle            windows.Handle
+	defaultAttributes uint16
+	attributes        uint16
+}
+
+func (w *legacyConsoleWriter) Write(p []byte) (int, error) {
+	text := p
+	for len(text) > 0 {
+		// Write the text up to the next escape sequence "\033[...m"
+		start := bytes.IndexByte(text, 0x1b)
+		if start == -1 || start+1 >= len(text) || text[start+1] != '[' {
+			start = len(text)
+		}
+		if start > 0 {
+			if _, err := w.file.Write(text[:start]); err != nil {
+				return 0, err
+			}
+		}
+		if start == len(text) {
+			break
+		}
+
+		end := bytes.IndexByte(text[start:], 'm')
+		if end == -1 {
+			break
+		}
+		w.setAttributes(string(text[start+2 : start+end]))
+		text = text[start+end+1:]
+	}
+
+	return len(p), nil
+}
+
+// setAttributes applies the parameters of an ANSI color code like "1;34"
+func (w *legacyConsoleWriter) setAttributes(params string) {
+	attributes := w.attributes
+	for params != "" {
+		var param string
+		param, params, _ = strings.Cut(params, ";")
+
+		switch {
+		case param == "0" || param == "":
+			attributes = w.defaultAttributes
+		case param == "1":
+			attributes |= foregroundIntensity
+		case param == "2":
+			attributes &^= foregroundIntensity
+		case len(param) == 2 && param[0] == '3' && param[1] >= '0' && param[1] <= '7':
+			// ANSI uses the order red, green, blue for the bits of the color
+			color := param[1] - '0'
+			attributes &^= foregroundMask &^ foregroundIntensity
+			if color&1 != 0 {
+				attributes |= foregroundRed
+			}
+			if color&2 != 0 {
+				attributes |= foregroundGreen
+			}
+			if color&4 != 0 {
+				attributes |= foregroundBlue
+			}
+		}
+	}
+
+	w.attributes = attributes
+	procSetConsoleTextAttribute.Call(uintptr(w.handle), uintptr(attributes))
+}
@@ -0,0 +1,475 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Config describes a logger in a configuration file (see FromConfigFile()).
+// Levels are given by their name like "info" and formats by "text", "json" or "logfmt".
+// The level "off" disables the output of the console or a target
+type Config struct {
+
+	// Minimum level and format of the console
+	Level  string `json:"level" yaml:"level" toml:"level"`
+	Format string `json:"format" yaml:"format" toml:"format"`
+
+	// Options of the Logger struct with the same name
+	ColoredOutput bool           `json:"coloredOutput" yaml:"coloredOutput" toml:"coloredOutput"`
+	PrintSource   bool           `json:"printSource" yaml:"printSource" toml:"printSource"`
+	TimeFormat    string         `json:"timeFormat" yaml:"timeFormat" toml:"timeFormat"`
+	UseUTC        bool           `json:"useUTC" yaml:"useUTC" toml:"useUTC"`
+	TimePrecision string         `json:"timePrecision" yaml:"timePrecision" toml:"timePrecision"`
+	Prefix        string         `json:"prefix" yaml:"prefix" toml:"prefix"`
+	StaticFields  map[string]any `json:"staticFields" yaml:"staticFields" toml:"staticFields"`
+
+	// Write all console messages to stderr
+	Stderr bool `json:"stderr" yaml:"stderr" toml:"stderr"`
+
+	// Minimum levels of packages or named loggers (see SetModuleLevel())
+	Modules map[string]string `json:"modules" yaml:"modules" toml:"modules"`
+
+	// Rules that change the level of messages matching a pattern (see Logger.LevelRules)
+	LevelRules []LevelRuleConfig `json:"levelRules" yaml:"levelRules" toml:"levelRules"`
+
+	// Label of the level in the text format (see Logger.LevelLabels)
+	LevelLabels *LevelLabelsConfig `json:"levelLabels" yaml:"levelLabels" toml:"levelLabels"`
+
+	// Logging into a file. It's disabled if no path is given
+	File *FileConfig `json:"file" yaml:"file" toml:"file"`
+
+	// Logging to a syslog daemon. It's disabled if this is nil
+	Syslog *SyslogConfig `json:"syslog" yaml:"syslog" toml:"syslog"`
+
+	// Logging into the systemd journal. It's disabled if this is nil
+	Journald *JournaldConfig `json:"journald" yaml:"journald" toml:"journald"`
+}
+
+// FileConfig describes the options of FileLogger in a configuration file
+type FileConfig struct {
+	Level      string `json:"level" yaml:"level" toml:"level"`
+	Path       string `json:"path" yaml:"path" toml:"path"`
+	AppendDate bool   `json:"appendDate" yaml:"appendDate" toml:"appendDate"`
+	LinkLatest bool   `json:"linkLatest" yaml:"linkLatest" toml:"linkLatest"`
+	DateFormat string `json:"dateFormat" yaml:"dateFormat" toml:"dateFormat"`
+	Format     string `json:"format" yaml:"format" toml:"format"`
+	MaxSizeMB  int    `json:"maxSizeMB" yaml:"maxSizeMB" toml:"maxSizeMB"`
+	MaxBackups int    `json:"maxBackups" yaml:"maxBackups" toml:"maxBackups"`
+	CreateDirs bool   `json:"createDirs" yaml:"createDirs" toml:"createDirs"`
+
+	// Permissions in octal notation like "0600"
+	FileMode string `json:"fileMode" yaml:"fileMode" toml:"fileMode"`
+	DirMode  string `json:"dirMode" yaml:"dirMode" toml:"dirMode"`
+
+	// Rotation period like "hourly", "daily", "weekly" or "monthly"
+	Rotation string `json:"rotation" yaml:"rotation" toml:"rotation"`
+}
+
+// LevelRuleConfig describes a LevelRule in a configuration file
+type LevelRuleConfig struct {
+	Pattern string `json:"pattern" yaml:"pattern" toml:"pattern"`
+	Level   string `json:"level" yaml:"level" toml:"level"`
+}
+
+// LevelLabelsConfig describes a LevelLabelConfig in a configuration file.
+// The custom labels are given by the name of the level like "warn": "WARNING"
+type LevelLabelsConfig struct {
+	Short     bool              `json:"short" yaml:"short" toml:"short"`
+	Lowercase bool              `json:"lowercase" yaml:"lowercase" toml:"lowercase"`
+	NoPadding bool              `json:"noPadding" yaml:"noPadding" toml:"noPadding"`
+	Labels    map[string]string `json:"labels" yaml:"labels" toml:"labels"`
+}
+
+// SyslogConfig describes the options of SyslogLogger in a configuration file
+type SyslogConfig struct {
+	Level   string `json:"level" yaml:"level" toml:"level"`
+	Network string `json:"network" yaml:"network" toml:"network"`
+	Address string `json:"address" yaml:"address" toml:"address"`
+	Tag     string `json:"tag" yaml:"tag" toml:"tag"`
+
+	// Facility like "user", "daemon" or "local0"
+	Facility string `json:"facility" yaml:"facility" toml:"facility"`
+
+	// TLS options for the network "tls"
+	TLS *TLSOptions `json:"tls" yaml:"tls" toml:"tls"`
+}
+
+// JournaldConfig describes the options of JournaldLogger in a configuration file
+type JournaldConfig struct {
+	Level          string            `json:"level" yaml:"level" toml:"level"`
+	Force          bool              `json:"force" yaml:"force" toml:"force"`
+	ReplaceConsole bool              `json:"replaceConsole" yaml:"replaceConsole" toml:"replaceConsole"`
+	Identifier     string            `json:"identifier" yaml:"identifier" toml:"identifier"`
+	Fields         map[string]string `json:"fields" yaml:"fields" toml:"fields"`
+}
+
+// ConfigDecoder decodes the content of a configuration file into the value
+// like "json.Unmarshal()"
+type ConfigDecoder func(data []byte, v any) error
+
+// Registered decoders by the file extension
+var configDecoders = struct {
+	sync.RWMutex
+	decoders map[string]ConfigDecoder
+}{decoders: map[string]ConfigDecoder{".json": json.Unmarshal}}
+
+// RegisterConfigDecoder registers a decoder for configuration files with the given
+// extension like ".yaml". Only JSON is supported out of the box so that this module
+// has no dependencies. Register the decoder of your YAML or TOML library:
+//
+//	logger.RegisterConfigDecoder(".yaml", yaml.Unmarshal)
+//	logger.RegisterConfigDecoder(".yml", yaml.Unmarshal)
+//	logger.RegisterConfigDecoder(".toml", toml.Unmarshal)
+func RegisterConfigDecoder(extension string, decoder ConfigDecoder) {
+	configDecoders.Lock()
+	configDecoders.decoders[strings.ToLower(extension)] = decoder
+	configDecoders.Unlock()
+}
+
+// FromConfigFile creates a new logger from the configuration file.
+// The decoder is chosen by the extension of the file (see RegisterConfigDecoder()).
+// Environment variables within the string values like "${LOG_LEVEL}" or "$HOME" are replaced
+// with their values after decoding the file. A default value can be given like "${LOG_LEVEL:-info}".
+// Use "$$" for a literal dollar sign
+func FromConfigFile(path string) (*Logger, error) {
+	config, err := LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return config.NewLogger()
+}
+
+// LoadConfig reads and decodes the configuration file (see FromConfigFile())
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	extension := strings.ToLower(filepath.Ext(path))
+	configDecoders.RLock()
+	decoder, ok := configDecoders.decoders[extension]
+	configDecoders.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no decoder registered for configuration files with the extension %q", extension)
+	}
+
+	config := &Config{}
+	if err := decoder(data, config); err != nil {
+		return nil, fmt.Errorf("failed to decode the configuration file %q: %w", path, err)
+	}
+
+	// The values are expanded after decoding, so that values of the variables
+	// with quotes or line breaks can't change the structure of the file
+	expandEnvValues(reflect.ValueOf(config))
+
+	return config, nil
+}
+
+// NewLogger creates a new logger from the configuration. The module levels
+// are registered globally
+func (c *Config) NewLogger() (*Logger, error) {
+	l := &Logger{}
+	if err := c.configure(l); err != nil {
+		return nil, err
+	}
+
+	modules, err := c.parseModules()
+	if err != nil {
+		return nil, err
+	}
+	for module, level := range modules {
+		SetModuleLevel(module, level)
+	}
+
+	return NewLogger(l), nil
+}
+
+// configure validates the configuration and sets the options of the logger that are
+// defined by it. The file, syslog and journald targets are replaced by new ones that
+// are not opened yet
+func (c *Config) configure(l *Logger) error {
+	l.ColoredOutput = c.ColoredOutput
+	l.PrintSource = c.PrintSource
+	l.TimeFormat = c.TimeFormat
+	l.UseUTC = c.UseUTC
+	l.Prefix = c.Prefix
+	l.StaticFields = c.StaticFields
+	l.File = &FileLogger{}
+	l.Syslog = nil
+	l.Journald = nil
+	l.LevelRules = nil
+	l.LevelLabels = nil
+
+	var err error
+	if l.Level, err = parseConfigLevel(c.Level, LevelInfo); err != nil {
+		return err
+	}
+	if l.Format, err = parseFormat(c.Format); err != nil {
+		return err
+	}
+	if l.TimePrecision, err = parseTimePrecision(c.TimePrecision); err != nil {
+		return err
+	}
+	l.ConsoleRouting = RouteErrorsToStderr
+	if c.Stderr {
+		l.ConsoleRouting = RouteAllToStderr
+	}
+
+	if c.File != nil {
+		l.File = &FileLogger{
+			Path:       c.File.Path,
+			AppendDate: c.File.AppendDate,
+			LinkLatest: c.File.LinkLatest,
+			DateFormat: c.File.DateFormat,
+			MaxSizeMB:  c.File.MaxSizeMB,
+			MaxBackups: c.File.MaxBackups,
+			CreateDirs: c.File.CreateDirs,
+		}
+		if l.File.Level, err = parseConfigLevel(c.File.Level, l.Level); err != nil {
+			return err
+		}
+		if l.File.Format, err = parseFormat(c.File.Format); err != nil {
+			return err
+		}
+		if l.File.RotationPeriod, err = parseRotationPeriod(c.File.Rotation); err != nil {
+			return err
+		}
+		if l.File.FileMode, err = parseFileMode(c.File.FileMode); err != nil {
+			return err
+		}
+		if l.File.DirMode, err = parseFileMode(c.File.DirMode); err != nil {
+			return err
+		}
+	}
+
+	if c.Syslog != nil {
+		l.Syslog = &SyslogLogger{Network: c.Syslog.Network, Address: c.Syslog.Address, Tag: c.Syslog.Tag}
+		if l.Syslog.Level, err = parseConfigLevel(c.Syslog.Level, l.Level); err != nil {
+			return err
+		}
+		if l.Syslog.Facility, err = parseFacility(c.Syslog.Facility); err != nil {
+			return err
+		}
+		if c.Syslog.TLS != nil {
+			if l.Syslog.TLSConfig, err = c.Syslog.TLS.Config(); err != nil {
+				return err
+			}
+		}
+	}
+
+	if c.Journald != nil {
+		l.Journald = &JournaldLogger{
+			Force:          c.Journald.Force,
+			ReplaceConsole: c.Journald.ReplaceConsole,
+			Identifier:     c.Journald.Identifier,
+			Fields:         c.Journald.Fields,
+		}
+		if l.Journald.Level, err = parseConfigLevel(c.Journald.Level, l.Level); err != nil {
+			return err
+		}
+	}
+
+	for _, rule := range c.LevelRules {
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern of the level rule: %w", err)
+		}
+		level, err := parseConfigLevel(rule.Level, LevelDebug)
+		if err != nil {
+			return err
+		}
+		l.LevelRules = append(l.LevelRules, LevelRule{Pattern: rule.Pattern, Level: level})
+	}
+
+	if c.LevelLabels != nil {
+		l.LevelLabels = &LevelLabelConfig{
+			Short:     c.LevelLabels.Short,
+			Lowercase: c.LevelLabels.Lowercase,
+			NoPadding: c.LevelLabels.NoPadding,
+			Labels:    make(map[Level]string, len(c.LevelLabels.Labels)),
+		}
+		for levelName, label := range c.LevelLabels.Labels {
+			level, err := parseConfigLevel(levelName, LevelInfo)
+			if err != nil {
+				return err
+			}
+			l.LevelLabels.Labels[level] = label
+		}
+	}
+
+	return nil
+}
+
+// parseModules returns the module levels of the configuration
+func (c *Config) parseModules() (map[string]Level, error) {
+	modules := make(map[string]Level, len(c.Modules))
+	for module, levelName := range c.Modules {
+		level, err := parseConfigLevel(levelName, LevelInfo)
+		if err != nil {
+			return nil, err
+		}
+		modules[module] = level
+	}
+
+	return modules, nil
+}
+
+// parseConfigLevel returns the level with the given name. An empty name
+// returns the default level
+func parseConfigLevel(levelName string, defaultLevel Level) (Level, error) {
+	if levelName == "" {
+		return defaultLevel, nil
+	}
+
+	level, err := ParseLevel(levelName)
+	if err != nil {
+		return defaultLevel, err
+	}
+	return level, nil
+}
+
+// parseFormat returns the format with the given name. An empty name returns FormatText
+func parseFormat(formatName string) (Format, error) {
+	switch strings.ToLower(formatName) {
+	case "", "text":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	case "logfmt":
+		return FormatLogfmt, nil
+	}
+
+	return FormatText, fmt.Errorf("unknown format %q", formatName)
+}
+
+// parseTimePrecision returns the precision with the given name like "ms" or "milliseconds".
+// An empty name returns PrecisionSeconds
+func parseTimePrecision(name string) (TimePrecision, error) {
+	switch strings.ToLower(name) {
+	case "", "s", "seconds":
+		return PrecisionSeconds, nil
+	case "ms", "milliseconds":
+		return PrecisionMilliseconds, nil
+	case "us", "µs", "microseconds":
+		return PrecisionMicroseconds, nil
+	case "ns", "nanoseconds":
+		return PrecisionNanoseconds, nil
+	}
+
+	return PrecisionSeconds, fmt.Errorf("unknown time precision %q", name)
+}
+
+// parseRotationPeriod returns the rotation period with the given name like "hourly".
+// An empty name returns RotateDaily
+func parseRotationPeriod(name string) (RotationPeriod, error) {
+	switch strings.ToLower(name) {
+	case "", "daily":
+		return RotateDaily, nil
+	case "hourly":
+		return RotateHourly, nil
+	case "weekly":
+		return RotateWeekly, nil
+	case "monthly":
+		return RotateMonthly, nil
+	}
+
+	return RotateDaily, fmt.Errorf("unknown rotation period %q", name)
+}
+
+// parseFileMode parses permissions in octal notation like "0600".
+// An empty value returns 0 so that the default permissions are used
+func parseFileMode(mode string) (os.FileMode, error) {
+	if mode == "" {
+		return 0, nil
+	}
+
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid file mode %q", mode)
+	}
+	return os.FileMode(value), nil
+}
+
+// parseFacility returns the syslog facility with the given name like "local0".
+// An empty name returns FacilityUser
+func parseFacility(name string) (SyslogFacility, error) {
+	names := []string{"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news", "uucp", "cron", "authpriv", "ftp"}
+
+	name = strings.ToLower(name)
+	if name == "" {
+		return FacilityUser, nil
+	}
+	for i, facilityName := range names {
+		if name == facilityName {
+			return SyslogFacility(i), nil
+		}
+	}
+	if len(name) == 6 && strings.HasPrefix(name, "local") && name[5] >= '0' && name[5] <= '7' {
+		return FacilityLocal0 + SyslogFacility(name[5]-'0'), nil
+	}
+
+	return FacilityUser, fmt.Errorf("unknown syslog facility %q", name)
+}
+
+// expandEnvValues replaces the environment variables within all strings of the value (see expandEnv())
+func expandEnvValues(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			expandEnvValues(v.Elem())
+		}
+	case reflect.Interface:
+		// Values of "any" fields like "StaticFields" can't be set in place
+		if !v.IsNil() && v.Elem().Kind() == reflect.String {
+			v.Set(reflect.ValueOf(expandEnv(v.Elem().String())))
+		} else if !v.IsNil() {
+			expandEnvValues(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				expandEnvValues(v.Field(i))
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			expandEnvValues(v.Index(i))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			value := reflect.New(iter.Value().Type()).Elem()
+			value.Set(iter.Value())
+			expandEnvValues(value)
+			v.SetMapIndex(iter.Key(), value)
+		}
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(expandEnv(v.String()))
+		}
+	}
+}
+
+// expandEnv replaces the environment variables within the text like "${NAME}" or "$NAME".
+// A default value for unset or empty variables can be given like "${NAME:-default}"
+func expandEnv(text string) string {
+	return os.Expand(text, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+
+		name, defaultValue, hasDefault := strings.Cut(name, ":-")
+		if value := os.Getenv(name); value != "" || !hasDefault {
+			return value
+		}
+		return defaultValue
+	})
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadConfigExpandsEnvInValues(t *testing.T) {
+	t.Setenv("LOGGER_TEST_PREFIX", `x", "level": "trace`)
+	t.Setenv("LOGGER_TEST_FIELD", "line\nbreak")
+
+	path := filepath.Join(t.TempDir(), "logger.json")
+	config := `{"level": "${LOGGER_TEST_LEVEL:-warn}", "prefix": "${LOGGER_TEST_PREFIX}", "staticFields": {"field": "$LOGGER_TEST_FIELD", "list": ["$$"]}}`
+	if err := os.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Level != "warn" || c.Prefix != `x", "level": "trace` {
+		t.Errorf("unexpected level %q or prefix %q", c.Level, c.Prefix)
+	}
+	if c.StaticFields["field"] != "line\nbreak" {
+		t.Errorf("unexpected value of the field: %q", c.StaticFields["field"])
+	}
+	if list, _ := c.StaticFields["list"].([]any); len(list) != 1 || list[0] != "$" {
+		t.Errorf("unexpected value of the list: %v", c.StaticFields["list"])
+	}
+}
@@ -0,0 +1,345 @@
+package logger
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ConfigWatcher reloads a configuration file when it was changed and applies
+// it to a logger (see WatchConfigFile())
+type ConfigWatcher struct {
+	logger  *Logger
+	path    string
+	onError func(err error)
+
+	// Last applied configuration
+	config *Config
+
+	// Module levels that were applied by the last configuration
+	modules map[string]Level
+
+	done chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+// WatchConfigFile checks the configuration file periodically for changes (see FromConfigFile()).
+// If only levels were changed, the levels of the console, the file, syslog, journald and the
+// module levels are updated atomically at runtime.
+// If other options like the path of the log file were changed, new targets are created from the
+// configuration. They replace the file, syslog and journald targets of the logger and all copies
+// of it (including named child loggers) atomically. The replaced targets are closed after all
+// pending writes to them finished, so no entries are dropped while applying the changes.
+// Options of the logger that are not part of the configuration (like hooks and additional
+// targets) are kept.
+//
+// If the file can't be read or contains invalid values, the previous configuration is kept and
+// the error is passed to the callback. The interval defaults to 2 seconds
+func WatchConfigFile(l *Logger, path string, interval time.Duration, onError func(err error)) *ConfigWatcher {
+	if interval <= 0 {
+		interval = 2 * time.Second
+	}
+
+	w := &ConfigWatcher{
+		logger:  l,
+		path:    path,
+		onError: onError,
+		modules: map[string]Level{},
+		done:    make(chan struct{}),
+	}
+
+	// The module levels of the initial configuration are replaced by the first change
+	if config, err := LoadConfig(path); err == nil {
+		w.config = config
+		for module, levelName := range config.Modules {
+			if level, ok := parseLevel(levelName); ok {
+				w.modules[module] = level
+			}
+		}
+	}
+
+	modTime, size := getFileState(path)
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// The file is only reloaded if it wasn't changed since the last check.
+		// This prevents reading a partially written file
+		changed := false
+		for {
+			select {
+			case <-w.done:
+				return
+			case <-ticker.C:
+				if newModTime, newSize := getFileState(path); !newModTime.Equal(modTime) || newSize != size {
+					modTime, size = newModTime, newSize
+					changed = true
+				} else if changed {
+					changed = false
+					w.Reload()
+				}
+			}
+		}
+	}()
+
+	return w
+}
+
+// Reload reads the configuration file and applies it immediately
+func (w *ConfigWatcher) Reload() {
+	config, err := LoadConfig(w.path)
+	if err == nil {
+		err = w.apply(config)
+	}
+
+	if err != nil {
+		if w.onError != nil {
+			w.onError(err)
+		} else {
+			w.logger.Error("Failed to reload the configuration file %q: %s", w.path, err)
+		}
+	}
+}
+
+// Close stops watching the configuration file
+func (w *ConfigWatcher) Close() error {
+	w.once.Do(func() {
+		close(w.done)
+	})
+	w.wg.Wait()
+
+	return nil
+}
+
+// apply validates the configuration and applies it afterwards. Only the levels are updated
+// if no other options were changed since the last applied configuration
+func (w *ConfigWatcher) apply(c *Config) error {
+	modules, err := c.parseModules()
+	if err != nil {
+		return err
+	}
+
+	if w.config != nil && onlyLevelsChanged(w.config, c) {
+		err = w.applyLevels(c)
+	} else {
+		err = w.replaceTargets(c)
+	}
+	if err != nil {
+		return err
+	}
+
+	for module := range w.modules {
+		if _, ok := modules[module]; !ok {
+			RemoveModuleLevel(module)
+		}
+	}
+	for module, level := range modules {
+		SetModuleLevel(module, level)
+	}
+	w.config = c
+	w.modules = modules
+
+	return nil
+}
+
+// applyLevels validates the levels of the configuration and updates the levels of the
+// targets afterwards
+func (w *ConfigWatcher) applyLevels(c *Config) error {
+	if !w.logger.ensureSetup() {
+		return nil
+	}
+	l := w.logger.resolve()
+
+	level, err := parseConfigLevel(c.Level, LevelInfo)
+	if err != nil {
+		return err
+	}
+	levels := map[*Level]Level{&l.Level: level}
+
+	if c.File != nil && l.File != nil {
+		if levels[&l.File.Level], err = parseConfigLevel(c.File.Level, level); err != nil {
+			return err
+		}
+	}
+	if c.Syslog != nil && l.Syslog != nil {
+		if levels[&l.Syslog.Level], err = parseConfigLevel(c.Syslog.Level, level); err != nil {
+			return err
+		}
+	}
+	if c.Journald != nil && l.Journald != nil {
+		if levels[&l.Journald.Level], err = parseConfigLevel(c.Journald.Level, level); err != nil {
+			return err
+		}
+	}
+
+	// All values are valid → apply them
+	for target, level := range levels {
+		target.store(level)
+	}
+
+	return nil
+}
+
+// replaceTargets creates a copy of the watched logger with the options and targets of the
+// configuration and replaces the current logger with it. The replaced targets are closed afterwards
+func (w *ConfigWatcher) replaceTargets(c *Config) error {
+	if !w.logger.ensureSetup() {
+		return nil
+	}
+
+	// Options that are not part of the configuration are taken from the watched logger
+	replacement := *w.logger
+	if err := c.configure(&replacement); err != nil {
+		return err
+	}
+	replacement.health = nil
+	replacement.levelLabels = nil
+	replacement.setup(false)
+
+	old := w.logger.resolve()
+	w.logger.reload.replace(&replacement)
+
+	if old.File.crashOutput {
+		replacement.HandleCrashes()
+	}
+
+	var errs []error
+	if !old.File.shared {
+		errs = append(errs, old.File.Close())
+	}
+	if old.Syslog != nil {
+		errs = append(errs, old.Syslog.Close())
+	}
+	if old.Journald != nil {
+		errs = append(errs, old.Journald.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// onlyLevelsChanged returns whether the configurations only differ in their levels
+func onlyLevelsChanged(old *Config, new *Config) bool {
+	return reflect.DeepEqual(withoutLevels(old), withoutLevels(new))
+}
+
+// withoutLevels returns a copy of the configuration without the levels of the targets and modules
+func withoutLevels(c *Config) Config {
+	copy := *c
+	copy.Level = ""
+	copy.Modules = nil
+	if c.File != nil {
+		file := *c.File
+		file.Level = ""
+		copy.File = &file
+	}
+	if c.Syslog != nil {
+		syslog := *c.Syslog
+		syslog.Level = ""
+		copy.Syslog = &syslog
+	}
+	if c.Journald != nil {
+		journald := *c.Journald
+		journald.Level = ""
+		copy.Journald = &journald
+	}
+
+	return copy
+}
+
+// reloadState contains the logger that replaced a logger and all copies of it after
+// its configuration file was reloaded (see WatchConfigFile())
+type reloadState struct {
+	active atomic.Pointer[reloadTargets]
+}
+
+// reloadTargets are the targets that are currently written to. The writes are counted,
+// so that replaced targets are only closed after all pending writes finished
+type reloadTargets struct {
+	// Logger that replaced the original logger. It's nil for the targets of the logger itself
+	logger *Logger
+
+	mu       sync.Mutex
+	writes   int
+	replaced bool
+	// Closed after the targets were replaced and all writes finished
+	idle chan struct{}
+}
+
+// newReloadState returns a state that writes to the targets of the logger itself
+func newReloadState() *reloadState {
+	state := &reloadState{}
+	state.active.Store(&reloadTargets{idle: make(chan struct{})})
+	return state
+}
+
+// acquire returns the current targets and registers a write to them. Call release()
+// after writing. It never blocks, so targets may log reentrantly while they are written to
+func (s *reloadState) acquire() *reloadTargets {
+	for {
+		t := s.active.Load()
+		t.mu.Lock()
+		if !t.replaced {
+			t.writes++
+			t.mu.Unlock()
+			return t
+		}
+
+		// The targets were replaced in the meantime → use the new ones
+		t.mu.Unlock()
+	}
+}
+
+// release marks a write registered by acquire() as finished
+func (t *reloadTargets) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.writes--
+	if t.replaced && t.writes == 0 {
+		close(t.idle)
+	}
+}
+
+// replace writes all new entries to the targets of the given logger and waits
+// until all writes to the previous targets finished
+func (s *reloadState) replace(l *Logger) {
+	old := s.active.Swap(&reloadTargets{logger: l, idle: make(chan struct{})})
+
+	old.mu.Lock()
+	old.replaced = true
+	if old.writes == 0 {
+		close(old.idle)
+	}
+	old.mu.Unlock()
+
+	<-old.idle
+}
+
+// resolve returns the logger that replaced this logger after a reload of the
+// configuration file. The logger itself is returned if it was not replaced
+func (l *Logger) resolve() *Logger {
+	if l.reload != nil {
+		if t := l.reload.active.Load(); t != nil && t.logger != nil {
+			return t.logger
+		}
+	}
+
+	return l
+}
+
+// getFileState returns the modification time and the size of the file
+func getFileState(path string) (time.Time, int64) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, -1
+	}
+
+	return info.ModTime(), info.Size()
+}
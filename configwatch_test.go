@@ -0,0 +1,150 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writeFileConfig returns a function that writes a configuration with the given log file
+func writeFileConfig(t *testing.T, dir string, configPath string) func(file string) {
+	return func(file string) {
+		config := fmt.Sprintf(`{"level": "off", "file": {"level": "info", "path": %q}}`, filepath.Join(dir, file))
+		if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestConfigWatcherReplacesFile(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "logger.json")
+	writeConfig := writeFileConfig(t, dir, configPath)
+
+	writeConfig("old.log")
+	l, err := FromConfigFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := WatchConfigFile(l, configPath, time.Hour, func(err error) { t.Errorf("reloading failed: %s", err) })
+	defer w.Close()
+	child := l.WithField("key", "value")
+
+	l.Info("before")
+	writeConfig("new.log")
+	w.Reload()
+	l.Info("after")
+	child.Info("child")
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for file, expected := range map[string][]string{"old.log": {"before"}, "new.log": {"after", "child"}} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lines := strings.Count(string(data), "\n"); lines != len(expected) {
+			t.Errorf("expected %d lines in %s, got %q", len(expected), file, data)
+		}
+		for _, message := range expected {
+			if !strings.Contains(string(data), message) {
+				t.Errorf("expected %q in %s, got %q", message, file, data)
+			}
+		}
+	}
+}
+
+// blockingTarget waits while it writes the first entry and logs a message with the logger afterwards
+type blockingTarget struct {
+	logger  *Logger
+	started chan struct{}
+	proceed chan struct{}
+	writes  atomic.Int32
+}
+
+func (t *blockingTarget) Enabled(level Level) bool { return true }
+func (t *blockingTarget) Close() error             { return nil }
+
+func (t *blockingTarget) Write(e Entry) error {
+	if t.writes.Add(1) == 1 {
+		close(t.started)
+		<-t.proceed
+		t.logger.Info("reentrant")
+	}
+	return nil
+}
+
+func TestConfigWatcherReentrantWriteDuringReload(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "logger.json")
+	writeConfig := writeFileConfig(t, dir, configPath)
+
+	writeConfig("old.log")
+	l, err := FromConfigFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := &blockingTarget{logger: l, started: make(chan struct{}), proceed: make(chan struct{})}
+	l.Targets = []Target{target}
+	w := WatchConfigFile(l, configPath, time.Hour, func(err error) { t.Errorf("reloading failed: %s", err) })
+	defer w.Close()
+
+	written := make(chan struct{})
+	go func() {
+		l.Info("first")
+		close(written)
+	}()
+	<-target.started
+
+	// The reload waits for the pending write that logs reentrantly
+	reloaded := make(chan struct{})
+	writeConfig("new.log")
+	go func() {
+		w.Reload()
+		close(reloaded)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	close(target.proceed)
+
+	for _, done := range []chan struct{}{written, reloaded} {
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("the reentrant write during the reload deadlocked")
+		}
+	}
+	l.Close()
+
+	data, err := os.ReadFile(filepath.Join(dir, "new.log"))
+	if err != nil || !strings.Contains(string(data), "reentrant") {
+		t.Errorf("expected the reentrant message in the new file, got %q (%v)", data, err)
+	}
+}
+
+func TestConfigWatcherKeepsSharedFile(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "logger.json")
+	writeConfig := writeFileConfig(t, dir, configPath)
+
+	owner := NewLogger(&Logger{Level: LevelOff, File: &FileLogger{Level: LevelInfo, Path: filepath.Join(dir, "shared.log")}})
+	defer owner.Close()
+	l := NewLoggerWithFile(&Logger{Level: LevelOff}, owner)
+
+	writeConfig("old.log")
+	w := WatchConfigFile(l, configPath, time.Hour, func(err error) { t.Errorf("reloading failed: %s", err) })
+	defer w.Close()
+	writeConfig("new.log")
+	w.Reload()
+	defer l.Close()
+
+	owner.Info("owner")
+	data, err := os.ReadFile(filepath.Join(dir, "shared.log"))
+	if err != nil || !strings.Contains(string(data), "owner") {
+		t.Errorf("the shared file was closed by the reload, got %q (%v)", data, err)
+	}
+}
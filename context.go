@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"context"
+	"sync"
+)
+
+// contextKey is the key used to store a Logger within a context
+type contextKey struct{}
+
+// NewContext returns a copy of the context that carries the given logger.
+// Use FromContext() to retrieve the logger again
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger stored inside the context by NewContext().
+// If the context does not contain a logger, the global logger is returned
+func FromContext(ctx context.Context) *Logger {
+	if l, ok := ctx.Value(contextKey{}).(*Logger); ok && l != nil {
+		return l
+	}
+
+	return GetGlobalLogger()
+}
+
+// ContextExtractor returns structured fields that are read from a context.
+// They are added to every message that is logged with a context (like InfoCtx())
+type ContextExtractor func(ctx context.Context) map[string]any
+
+// Registered context extractors
+var contextExtractors struct {
+	sync.RWMutex
+	extractors []ContextExtractor
+}
+
+// AddContextExtractor registers a function that extracts structured fields from the
+// context of messages logged with the context aware methods (like InfoCtx())
+func AddContextExtractor(extractor ContextExtractor) {
+	contextExtractors.Lock()
+	contextExtractors.extractors = append(contextExtractors.extractors, extractor)
+	contextExtractors.Unlock()
+}
+
+// TraceContextExtractor returns a context extractor that adds the fields "trace_id" and
+// "span_id" for the correlation of logs and traces.
+// The given function has to read the IDs of the current span from the context. Using
+// OpenTelemetry this looks like:
+//
+//	logger.AddContextExtractor(logger.TraceContextExtractor(func(ctx context.Context) (string, string, bool) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return sc.TraceID().String(), sc.SpanID().String(), sc.IsValid()
+//	}))
+func TraceContextExtractor(getIDs func(ctx context.Context) (traceID string, spanID string, ok bool)) ContextExtractor {
+	return func(ctx context.Context) map[string]any {
+		traceID, spanID, ok := getIDs(ctx)
+		if !ok {
+			return nil
+		}
+
+		return map[string]any{"trace_id": traceID, "span_id": spanID}
+	}
+}
+
+// addContextFields returns the given fields with the fields of all context extractors added.
+// The given map is not modified
+func addContextFields(ctx context.Context, fields map[string]any) map[string]any {
+	contextExtractors.RLock()
+	defer contextExtractors.RUnlock()
+
+	var rtc map[string]any
+	for _, extractor := range contextExtractors.extractors {
+		extracted := extractor(ctx)
+		if len(extracted) == 0 {
+			continue
+		}
+
+		if rtc == nil {
+			rtc = make(map[string]any, len(fields)+len(extracted))
+			for key, value := range fields {
+				rtc[key] = value
+			}
+		}
+		for key, value := range extracted {
+			rtc[key] = value
+		}
+	}
+
+	if rtc == nil {
+		return fields
+	}
+	return rtc
+}
+
+// EnabledCtx returns whether the logger writes entries with the given level like the
+// method "Enabled(ctx, level)" of a "log/slog" handler. Use it to skip collecting
+// expensive data for messages that are logged with a context
+func (l *Logger) EnabledCtx(ctx context.Context, level Level) bool {
+	return l.Enabled(level)
+}
+
+// EnabledCtx returns whether the logger stored inside the context writes entries with
+// the given level. If the context does not contain a logger, the global logger is used
+func EnabledCtx(ctx context.Context, level Level) bool {
+	return FromContext(ctx).Enabled(level)
+}
+
+// LogCtx logs a message with the given level. Fields of the registered context
+// extractors are added to the message
+func (l *Logger) LogCtx(ctx context.Context, level Level, message string, parameters ...any) {
+	l.log(ctx, level, message, parameters...)
+}
+
+// Global available context aware methods per logging level.
+// The logger stored in the context via NewContext() is used. If the context
+// does not contain a logger, the global logger is used
+
+func TraceCtx(ctx context.Context, message string, parameters ...any) {
+	FromContext(ctx).log(ctx, LevelTrace, message, parameters...)
+}
+func DebugCtx(ctx context.Context, message string, parameters ...any) {
+	FromContext(ctx).log(ctx, LevelDebug, message, parameters...)
+}
+func InfoCtx(ctx context.Context, message string, parameters ...any) {
+	FromContext(ctx).log(ctx, LevelInfo, message, parameters...)
+}
+func WarningCtx(ctx context.Context, message string, parameters ...any) {
+	FromContext(ctx).log(ctx, LevelWarning, message, parameters...)
+}
+func ErrorCtx(ctx context.Context, message string, parameters ...any) {
+	FromContext(ctx).log(ctx, LevelError, message, parameters...)
+}
+func FatalCtx(ctx context.Context, message string, parameters ...any) {
+	FromContext(ctx).log(ctx, LevelFatal, message, parameters...)
+}
+
+// Available context aware methods for each logger per logging level
+
+func (l *Logger) TraceCtx(ctx context.Context, message string, parameters ...any) {
+	l.log(ctx, LevelTrace, message, parameters...)
+}
+func (l *Logger) DebugCtx(ctx context.Context, message string, parameters ...any) {
+	l.log(ctx, LevelDebug, message, parameters...)
+}
+func (l *Logger) InfoCtx(ctx context.Context, message string, parameters ...any) {
+	l.log(ctx, LevelInfo, message, parameters...)
+}
+func (l *Logger) WarningCtx(ctx context.Context, message string, parameters ...any) {
+	l.log(ctx, LevelWarning, message, parameters...)
+}
+func (l *Logger) ErrorCtx(ctx context.Context, message string, parameters ...any) {
+	l.log(ctx, LevelError, message, parameters...)
+}
+func (l *Logger) FatalCtx(ctx context.Context, message string, parameters ...any) {
+	l.log(ctx, LevelFatal, message, parameters...)
+}
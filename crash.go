@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"errors"
+	"strings"
+)
+
+// HandleCrashes writes the output of unhandled panics and fatal runtime errors (like
+// concurrent map writes) into the log file in addition to stderr. The runtime writes
+// this output directly, so it's not formatted like the other messages. After the log
+// file was rotated or reopened, the new file is used.
+//
+// Recovered panics are not affected. To log a panic of the main goroutine with the
+// logger itself, additionally use "defer l.RecoverAndRepanic()" in main().
+// This requires Go 1.23 or newer and a log file that is not encrypted
+func (l *Logger) HandleCrashes() error {
+	if !l.ensureSetup() {
+		return errors.New("no log file is configured")
+	}
+
+	file := l.File
+	if file == nil || strings.TrimSpace(file.Path) == "" || file.fileSync == nil {
+		return errors.New("no log file is configured")
+	}
+	if file.EncryptionKey != nil {
+		return errors.New("crashes cannot be written to an encrypted log file")
+	}
+
+	file.fileSync.Lock()
+	defer file.fileSync.Unlock()
+
+	if file.file == nil {
+		return errors.New("the log file is not opened")
+	}
+	if err := setCrashOutput(file.file); err != nil {
+		return err
+	}
+	file.crashOutput = true
+
+	return nil
+}
+
+// HandleCrashes writes the output of unhandled panics and fatal runtime errors into
+// the log file of the global logger (see Logger.HandleCrashes())
+func HandleCrashes() error {
+	return dLogger.HandleCrashes()
+}
@@ -0,0 +1,13 @@
+//go:build go1.23
+
+package logger
+
+import (
+	"os"
+	"runtime/debug"
+)
+
+// setCrashOutput sets the file to which the runtime writes the output of crashes
+func setCrashOutput(file *os.File) error {
+	return debug.SetCrashOutput(file, debug.CrashOptions{})
+}
@@ -0,0 +1,13 @@
+//go:build !go1.23
+
+package logger
+
+import (
+	"errors"
+	"os"
+)
+
+// setCrashOutput is not supported before Go 1.23
+func setCrashOutput(file *os.File) error {
+	return errors.New("writing crashes into the log file requires Go 1.23")
+}
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// deduplicator collapses identical consecutive messages within a time window
+// into a single message with a repeat count
+type deduplicator struct {
+	window time.Duration
+
+	lock      sync.Mutex
+	last      Entry
+	hasLast   bool
+	repeated  int
+	timer     *time.Timer
+	firstTime time.Time
+}
+
+func newDeduplicator(window time.Duration) *deduplicator {
+	return &deduplicator{window: window}
+}
+
+// check returns whether the entry should be written. If the entry is identical to the previous
+// one and the window did not elapse yet, it's counted and dropped
+func (d *deduplicator) check(e Entry) bool {
+	if e.Level >= LevelPanic {
+		return true
+	}
+
+	d.lock.Lock()
+	if d.hasLast && isDuplicate(d.last, e) && e.Time.Sub(d.firstTime) < d.window {
+		d.repeated++
+		if d.timer == nil {
+			d.timer = time.AfterFunc(d.window-e.Time.Sub(d.firstTime), d.flush)
+		}
+		d.lock.Unlock()
+		return false
+	}
+
+	summary, hasSummary := d.takeSummary()
+	d.last = e
+	d.hasLast = true
+	d.firstTime = e.Time
+	d.lock.Unlock()
+
+	if hasSummary {
+		e.logger.dispatch(summary)
+	}
+	return true
+}
+
+// flush writes the summary of the repeated messages after the window elapsed
+func (d *deduplicator) flush() {
+	d.lock.Lock()
+	summary, hasSummary := d.takeSummary()
+	d.hasLast = false
+	d.lock.Unlock()
+
+	if hasSummary {
+		summary.logger.dispatch(summary)
+	}
+}
+
+// takeSummary returns an entry that summarizes the repeated messages and resets the counter.
+// The caller has to hold the lock
+func (d *deduplicator) takeSummary() (Entry, bool) {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.repeated == 0 {
+		return Entry{}, false
+	}
+
+	summary := d.last
+	summary.Time = time.Now()
+	summary.Message = formatMessage("Last message repeated %d times", d.repeated)
+	d.repeated = 0
+
+	return summary, true
+}
+
+// isDuplicate returns whether both entries have the same level, message and fields
+func isDuplicate(a Entry, b Entry) bool {
+	if a.Level != b.Level || a.Message != b.Message || len(a.Fields) != len(b.Fields) {
+		return false
+	}
+
+	for key, value := range a.Fields {
+		if other, exists := b.Fields[key]; !exists || !isEqualValue(value, other) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isEqualValue compares two field values without panicking for uncomparable types
+func isEqualValue(a any, b any) (equal bool) {
+	defer func() {
+		if recover() != nil {
+			equal = false
+		}
+	}()
+
+	return a == b
+}
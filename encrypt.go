@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// KeyFromEnv returns a key function for FileLogger.EncryptionKey that reads the key from
+// the environment variable with the given name. The key has to be encoded in hex or base64
+// and has to be 16, 24 or 32 bytes long (AES-128, AES-192 or AES-256)
+func KeyFromEnv(name string) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		value := strings.TrimSpace(os.Getenv(name))
+		if value == "" {
+			return nil, fmt.Errorf("the environment variable %s is not set", name)
+		}
+
+		return DecodeKey(value)
+	}
+}
+
+// DecodeKey decodes a hex or base64 encoded encryption key
+func DecodeKey(value string) ([]byte, error) {
+	if key, err := hex.DecodeString(value); err == nil {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(value); err == nil {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("the key is neither hex nor base64 encoded")
+}
+
+// newFileCipher creates the AES-GCM cipher with the key returned by the function
+func newFileCipher(getKey func() ([]byte, error)) (cipher.AEAD, error) {
+	key, err := getKey()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptLine encrypts the message with a random nonce and returns it as a
+// base64 encoded line "base64(nonce | ciphertext)\n"
+func encryptLine(aead cipher.AEAD, message []byte) ([]byte, error) {
+	sealed := make([]byte, aead.NonceSize(), aead.NonceSize()+len(message)+aead.Overhead())
+	if _, err := rand.Read(sealed); err != nil {
+		return nil, err
+	}
+	sealed = aead.Seal(sealed, sealed, message, nil)
+
+	line := make([]byte, base64.StdEncoding.EncodedLen(len(sealed))+1)
+	base64.StdEncoding.Encode(line, sealed)
+	line[len(line)-1] = '\n'
+
+	return line, nil
+}
+
+// DecryptLog decrypts a log file that was written with FileLogger.EncryptionKey
+// and writes the plain messages to the writer
+func DecryptLog(r io.Reader, w io.Writer, key []byte) error {
+	aead, err := newFileCipher(func() ([]byte, error) { return key, nil })
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(r)
+	for lineNumber := 1; ; lineNumber++ {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			sealed := make([]byte, base64.StdEncoding.DecodedLen(len(line)))
+			n, decodeErr := base64.StdEncoding.Decode(sealed, line)
+			if decodeErr != nil || n < aead.NonceSize() {
+				return fmt.Errorf("invalid encoding of line %d", lineNumber)
+			}
+
+			message, openErr := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():n], nil)
+			if openErr != nil {
+				return fmt.Errorf("failed to decrypt line %d: %w", lineNumber, openErr)
+			}
+			if _, err := w.Write(message); err != nil {
+				return err
+			}
+		}
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package logger
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry contains all information of a single log message.
+// It's passed to the targets of a logger
+type Entry struct {
+
+	// Time at which the message was logged
+	Time time.Time
+
+	// Level of the message
+	Level Level
+
+	// The message with all replace values applied
+	Message string
+
+	// Structured key/value pairs attached to the message.
+	// The map is shared with the logger and must not be modified. Use
+	// WithField() to add a field to the entry
+	Fields map[string]any
+
+	// Hierarchical name of the logger that created the entry (see Logger.Named())
+	LoggerName string
+
+	// File, line number and program counter of the invoking (calling) line.
+	// The source is only determined if "PrintSource" is enabled for the logger or
+	// a target requires it (see SourceTarget). Otherwise the line is zero
+	File string
+	Line int
+	PC   uintptr
+
+	// ID of the goroutine that logged the entry. It's only determined
+	// if "PrintGoroutineID" is enabled for the logger
+	GoroutineID uint64
+
+	// Stack trace of the goroutine that logged the entry with one line per frame.
+	// It's captured for the levels at or above "StacktraceLevel" of the logger
+	Stack string
+
+	// Logger that created this entry
+	logger *Logger
+
+	// File target the entry is formatted for. Its options replace the ones of the logger
+	file *FileLogger
+
+	// Whether the entry was dropped by a hook
+	dropped bool
+
+	// The message before the replace values were applied
+	template string
+
+	// Level of the module set via SetModuleLevel() that replaces the levels of the targets
+	moduleLevel    Level
+	hasModuleLevel bool
+
+	// Whether the entry has to be written to the log file (see MustLog())
+	requireFile bool
+}
+
+// WithField returns a copy of the entry with the given key/value pair
+// added to the fields
+func (e Entry) WithField(key string, value any) Entry {
+	fields := make(map[string]any, len(e.Fields)+1)
+	for k, v := range e.Fields {
+		fields[k] = v
+	}
+	fields[key] = value
+
+	e.Fields = fields
+	return e
+}
+
+// Caller returns the source of the entry like "file.go:1". If the source
+// was not determined, an empty string is returned
+func (e Entry) Caller() string {
+	if e.Line <= 0 {
+		return ""
+	}
+
+	return getSourceName(e.File, e.Line)
+}
+
+// defaultOptions are used to format entries that were not created by a logger
+var defaultOptions = &Logger{}
+
+// options returns the logger that created the entry. For entries that were built
+// manually, a logger with the default options is returned
+func (e Entry) options() *Logger {
+	if e.logger == nil {
+		return defaultOptions
+	}
+	return e.logger
+}
+
+// printSource returns whether the source is printed for the entry
+func (e Entry) printSource() bool {
+	if e.file != nil && e.file.PrintSource != nil {
+		return *e.file.PrintSource
+	}
+	return e.options().PrintSource
+}
+
+// timeFormat returns the layout of the timestamp (see Logger.TimeFormat)
+func (e Entry) timeFormat() string {
+	if e.file != nil && e.file.TimeFormat != "" {
+		return e.file.TimeFormat
+	}
+	return e.options().TimeFormat
+}
+
+// timePrecision returns the fractional seconds of the timestamp (see Logger.TimePrecision)
+func (e Entry) timePrecision() TimePrecision {
+	if e.file != nil && e.file.TimePrecision != nil {
+		return *e.file.TimePrecision
+	}
+	return e.options().TimePrecision
+}
+
+// prefix returns the prefix of the logger. If no prefix is set and "ComponentPrefix"
+// is enabled, the component of the caller is returned like " db:"
+func (e Entry) prefix() string {
+	l := e.logger
+	if l == nil {
+		return ""
+	}
+	if l.PrefixFunc != nil {
+		return l.PrefixFunc(e)
+	}
+	if strings.IndexByte(l.Prefix, '{') != -1 {
+		return e.expandPrefix(l.Prefix)
+	}
+	if l.Prefix != "" || !l.ComponentPrefix {
+		return l.Prefix
+	}
+
+	if pkg := getPackagePath(e.PC); pkg != "" {
+		return " " + getComponent(pkg, l.Components) + ":"
+	}
+	return ""
+}
+
+// expandPrefix replaces the placeholders like "{request_id}" of the prefix
+func (e Entry) expandPrefix(prefix string) string {
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(prefix, '{')
+		end := strings.IndexByte(prefix[start+1:], '}')
+		if start == -1 || end == -1 {
+			b.WriteString(prefix)
+			return b.String()
+		}
+		end += start + 1
+
+		b.WriteString(prefix[:start])
+		switch name := prefix[start+1 : end]; name {
+		case "goroutine":
+			b.WriteString(strconv.FormatUint(e.GoroutineID, 10))
+		case "logger":
+			b.WriteString(e.LoggerName)
+		default:
+			value, ok := e.Fields[name]
+			if !ok {
+				value, ok = e.logger.StaticFields[name]
+			}
+			if ok {
+				b.WriteString(formatFieldValue(value))
+			} else {
+				b.WriteByte('-')
+			}
+		}
+		prefix = prefix[end+1:]
+	}
+}
+
+// needsGoroutineID returns whether the goroutine ID has to be determined for the entries
+func (l *Logger) needsGoroutineID() bool {
+	return l.PrintGoroutineID || (l.PrefixFunc == nil && strings.Contains(l.Prefix, "{goroutine}"))
+}
+
+// NewEntry creates a new entry with the given level and message for the logger.
+// The time, the fields and the name of the logger are set. Adjust the entry
+// as needed and log it with LogEntry()
+func (l *Logger) NewEntry(level Level, message string) Entry {
+	if l == nil {
+		return Entry{Time: time.Now(), Level: level, Message: message}
+	}
+
+	return Entry{
+		Time:       time.Now(),
+		Level:      level,
+		Message:    message,
+		Fields:     l.fields,
+		LoggerName: l.name,
+		logger:     l,
+	}
+}
+
+// LogEntry writes an entry that was built outside of this package (e.g. by a bridge
+// from another logging library) to the targets of the logger. Missing values are
+// completed: an empty time is set to now, the fields of the logger are merged with
+// the fields of the entry and the source is resolved from the program counter.
+// Like for all other logging calls, a fatal entry exits the program
+func (l *Logger) LogEntry(e Entry) {
+	if !l.ensureSetup() {
+		return
+	}
+	if e.Level != LevelFatal && !l.Enabled(e.Level) {
+		// A level rule may raise the level of the entry
+		rules := l.resolve().levelRules
+		if rules == nil {
+			return
+		}
+		if e = rules.apply(e); !l.Enabled(e.Level) {
+			return
+		}
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.LoggerName == "" {
+		e.LoggerName = l.name
+	}
+	if l.needsGoroutineID() && e.GoroutineID == 0 {
+		e.GoroutineID = getGoroutineID()
+	}
+	if e.PC != 0 && e.File == "" {
+		frame, _ := runtime.CallersFrames([]uintptr{e.PC}).Next()
+		e.File, e.Line = frame.File, frame.Line
+	}
+	if e.File == "" {
+		e.File = "#unknown"
+	}
+	if e.Stack == "" && l.stacktraceEnabled(e.Level) {
+		e.Stack = captureStack(1, e.PC)
+	}
+
+	switch {
+	case len(e.Fields) == 0:
+		e.Fields = l.fields
+	case len(l.fields) > 0:
+		fields := make(map[string]any, len(l.fields)+len(e.Fields))
+		for key, value := range l.fields {
+			fields[key] = value
+		}
+		for key, value := range e.Fields {
+			fields[key] = value
+		}
+		e.Fields = fields
+	}
+	e.Fields = resolveLazyFields(e.Fields)
+
+	e.logger = l
+	e.template = e.Message
+	e.dropped = false
+	l.write(e, false)
+}
+
+// LogEntry writes the entry to the targets of the global logger (see Logger.LogEntry())
+func LogEntry(e Entry) {
+	dLogger.LogEntry(e)
+}
+
+// getGoroutineID returns the ID of the current goroutine. The ID is parsed from the
+// first line of the stack trace like "goroutine 1 [running]:"
+func getGoroutineID() uint64 {
+	buf := make([]byte, 32)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+
+	if end := bytes.IndexByte(buf, ' '); end != -1 {
+		buf = buf[:end]
+	}
+
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
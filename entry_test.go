@@ -0,0 +1,30 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewEntryString(t *testing.T) {
+	l := NewLogger(&Logger{Level: LevelOff})
+	t.Cleanup(func() { l.Close() })
+
+	e := l.NewEntry(LevelInfo, "message").WithField("key", "value")
+	if rtc := e.String(); !strings.Contains(rtc, "message key=value") {
+		t.Fatalf("unexpected string of the entry: %q", rtc)
+	}
+}
+
+func TestFormatEntryWithoutLogger(t *testing.T) {
+	e := Entry{Level: LevelWarning, Message: "message", Fields: map[string]any{"key": "value"}}
+
+	if rtc := e.String(); !strings.Contains(rtc, "[WARN ]") || !strings.Contains(rtc, "message key=value") {
+		t.Fatalf("unexpected string of the entry: %q", rtc)
+	}
+	for _, formatter := range []Formatter{JSONFormatter{}, LogfmtFormatter{}, TextFormatter{Colored: true}} {
+		rtc, err := formatter.Format(e)
+		if err != nil || !strings.Contains(string(rtc), "value") {
+			t.Fatalf("unexpected output of %T: %q (%v)", formatter, rtc, err)
+		}
+	}
+}
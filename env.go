@@ -1,8 +1,13 @@
 package logger
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // getEnvBool returns a boolean value read from the environmnet variable.
@@ -27,3 +32,184 @@ func getEnvString(name string, defaultValue string) string {
 
 	return val
 }
+
+// ApplyEnv overrides the options of the logger with the values of environment variables.
+// Every exported option can be set by a variable named "LOGGER_<OPTION>" like "LOGGER_TIMEFORMAT".
+// Options of the file, syslog and journald loggers are prefixed with their name like
+// "LOGGER_FILE_MAXSIZEMB". If a variable of syslog or journald is set, they are enabled.
+//
+// Levels and formats are given by their name like "info" or "json", durations like "5s"
+// and maps like "service=api,env=prod". Options that can't be represented as a string
+// (like writers and targets) are not supported.
+// The names of the applied variables are returned. Invalid values are skipped and reported
+// by the returned error
+func ApplyEnv(l *Logger) ([]string, error) {
+	if l.File == nil {
+		l.File = &FileLogger{}
+	}
+
+	var applied []string
+	var errs []error
+	applyEnvToStruct(reflect.ValueOf(l).Elem(), "LOGGER_", &applied, &errs)
+
+	if getEnvBool("LOGGER_CONSOLE_STDERR", false) {
+		l.ConsoleRouting = RouteAllToStderr
+		applied = append(applied, "LOGGER_CONSOLE_STDERR")
+	}
+
+	return applied, errors.Join(errs...)
+}
+
+// applyEnvToStruct sets the exported fields of the struct to the values of the
+// environment variables with the given prefix
+func applyEnvToStruct(value reflect.Value, prefix string, applied *[]string, errs *[]error) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := prefix + strings.ToUpper(field.Name)
+		fieldValue := value.Field(i)
+
+		// Nested configurations like "File" are created if one of their variables is set
+		if field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct {
+			if fieldValue.IsNil() {
+				if !hasEnvWithPrefix(name + "_") {
+					continue
+				}
+				fieldValue.Set(reflect.New(field.Type.Elem()))
+			}
+			applyEnvToStruct(fieldValue.Elem(), name+"_", applied, errs)
+			continue
+		}
+
+		envValue, isSet := os.LookupEnv(name)
+		if !isSet {
+			continue
+		}
+
+		if err := setFromEnv(fieldValue, envValue); err != nil {
+			*errs = append(*errs, fmt.Errorf("invalid value %q of the environment variable %s: %w", envValue, name, err))
+		} else {
+			*applied = append(*applied, name)
+		}
+	}
+}
+
+// setFromEnv converts the value of an environment variable to the type of the field
+func setFromEnv(field reflect.Value, value string) error {
+	// Optional values like "StacktraceLevel"
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+		if err := setFromEnv(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	}
+
+	switch field.Type() {
+	case reflect.TypeOf(Level(0)):
+		level, ok := parseLevel(value)
+		if !ok {
+			return errors.New("unknown level")
+		}
+		field.Set(reflect.ValueOf(level))
+		return nil
+	case reflect.TypeOf(Format(0)):
+		format, err := parseFormat(value)
+		if err == nil {
+			field.Set(reflect.ValueOf(format))
+		}
+		return err
+	case reflect.TypeOf(TimePrecision(0)):
+		precision, err := parseTimePrecision(value)
+		if err == nil {
+			field.Set(reflect.ValueOf(precision))
+		}
+		return err
+	case reflect.TypeOf(RotationPeriod(0)):
+		period, err := parseRotationPeriod(value)
+		if err == nil {
+			field.Set(reflect.ValueOf(period))
+		}
+		return err
+	case reflect.TypeOf(os.FileMode(0)):
+		// Permissions are given in octal like "0600"
+		mode, err := strconv.ParseUint(value, 8, 32)
+		if err == nil {
+			field.SetUint(mode)
+		}
+		return err
+	case reflect.TypeOf(time.Duration(0)):
+		duration, err := time.ParseDuration(value)
+		if err == nil {
+			field.SetInt(int64(duration))
+		}
+		return err
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		strVal := strings.ToLower(value)
+		field.SetBool(strVal == "1" || strVal == "true" || strVal == "yes" || strVal == "ja")
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(number)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(number)
+	case reflect.Float32, reflect.Float64:
+		number, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(number)
+	case reflect.Map:
+		if field.Type().Key().Kind() != reflect.String {
+			return errors.New("unsupported type")
+		}
+		m := reflect.MakeMap(field.Type())
+		for _, pair := range strings.Split(value, ",") {
+			key, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value instead of %q", pair)
+			}
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := setFromEnv(elem, strings.TrimSpace(val)); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(strings.TrimSpace(key)), elem)
+		}
+		field.Set(m)
+	case reflect.Interface:
+		// Values of maps like "StaticFields"
+		if field.NumMethod() != 0 {
+			return errors.New("unsupported type")
+		}
+		field.Set(reflect.ValueOf(value))
+	default:
+		return errors.New("unsupported type")
+	}
+
+	return nil
+}
+
+// hasEnvWithPrefix returns whether an environment variable with the prefix is set
+func hasEnvWithPrefix(prefix string) bool {
+	for _, env := range os.Environ() {
+		if strings.HasPrefix(env, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -1,11 +1,16 @@
 package logger
 
 import (
+	"bufio"
+	"crypto/cipher"
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,9 +25,78 @@ type FileLogger struct {
 	Path string
 
 	// With this option the path of the log file will be appended with the current date
-	// so that an own log file for each day is used. The format of the date is 'YYYYMMDD'
+	// so that an own log file for each day is used. The format of the date is 'YYYY-MM-DD'.
+	// If the path contains the placeholder "{date}" like "logs/app.{date}.log", the
+	// date is inserted there instead. The placeholder enables this option implicitly
 	AppendDate bool
 
+	// Period after which a new dated log file is started. Defaults to RotateDaily
+	RotationPeriod RotationPeriod
+
+	// Layout of the date in the path (see time.Layout). The date is always the beginning
+	// of the current period. Defaults to a layout matching the "RotationPeriod"
+	DateFormat string
+
+	// Maintains a link at "Path" that points to the current dated log file when "AppendDate"
+	// is enabled. So "tail -f" and the configuration of logrotate can always use the same path.
+	// A symbolic link is used if possible and a hard link otherwise (e.g. on Windows without
+	// the required privileges). Only links are replaced, so an existing log file at "Path" is kept
+	LinkLatest bool
+
+	// Format of the messages written to the file. Defaults to FormatText
+	Format Format
+
+	// Formatter used to render the messages written to the file.
+	// If set, the option "Format" is ignored
+	Formatter Formatter
+
+	// Layout of the timestamp in the file (see Logger.TimeFormat).
+	// Defaults to the layout of the logger
+	TimeFormat string
+
+	// Fractional seconds of the timestamps in the file (see Logger.TimePrecision).
+	// Defaults to the precision of the logger
+	TimePrecision *TimePrecision
+
+	// Whether the source of the messages is printed into the file independent
+	// of the console. Defaults to "PrintSource" of the logger
+	PrintSource *bool
+
+	// Maximum size of the log file in megabytes before it gets rotated.
+	// The rotated files are renamed by appending an index to the path (".1" is the newest one).
+	// A value <= 0 disables the size based rotation
+	MaxSizeMB int
+
+	// Maximum number of rotated files to keep. Older files are deleted.
+	// A value <= 0 keeps all rotated files
+	MaxBackups int
+
+	// Size of the write buffer in bytes. The messages are collected in the buffer and
+	// written to the file when it's full, after "FlushInterval" or by calling Flush().
+	// This reduces the number of system calls significantly. Messages that are still in the
+	// buffer are lost if the program crashes. A value <= 0 writes every message immediately
+	BufferSize int
+
+	// Interval in which the buffered messages are written to the file. Defaults to 1 second
+	FlushInterval time.Duration
+
+	// Permissions of newly created log files like 0600 for sensitive logs.
+	// Existing files are not changed. Defaults to 0644
+	FileMode os.FileMode
+
+	// Create the directory of the log file with all parents if it doesn't exist
+	CreateDirs bool
+
+	// Permissions of the directories created by "CreateDirs". Defaults to 0755
+	DirMode os.FileMode
+
+	// Encrypts every message with AES-GCM using the returned key (see KeyFromEnv()).
+	// The function is called once when the file is opened the first time, so the key can also
+	// be fetched from a key management service. If it fails, nothing is written to the file.
+	// Each message is stored as a base64 encoded line and can be decrypted with DecryptLog()
+	// or the command "cmd/logdecrypt"
+	EncryptionKey func() ([]byte, error)
+
 	// Internal dependency used to synchronize the access to the log file
 	fileSync *sync.RWMutex
 	// Additional file sync that is used during writing to the log file
@@ -31,15 +105,55 @@ type FileLogger struct {
 	logger *log.Logger
 	file   *os.File
 
+	// Write buffer for "BufferSize" and the lock for it
+	buffer     *bufio.Writer
+	bufferSync *sync.Mutex
+	// Stops the periodic flushing of the buffer. It's shared with all copies of the file logger
+	flushStop *flushStopper
+
+	// Current size of the log file in bytes (accessed atomically)
+	fileSize *int64
+
+	// Cipher for "EncryptionKey"
+	cipher cipher.AEAD
+
+	// Whether crashes are written into the file (see Logger.HandleCrashes())
+	crashOutput bool
+
+	// Whether the file was opened by another logger (see NewLoggerWithFile()).
+	// It's not closed when the targets are replaced by a reload of the configuration
+	shared bool
+
+	// Log file that was hard linked to "Path" (see LinkLatest)
+	hardLinked string
+
 	// Upper logger struct
 	rootLogger *Logger
 }
 
+// NewFileTarget opens the log file of the configuration so that it can be added as an
+// additional target to the field "Targets" of a logger. Errors while opening or rotating
+// the file are logged with the global logger
+func NewFileTarget(file *FileLogger) *FileLogger {
+	file.rootLogger = &dLogger
+	if strings.TrimSpace(file.Path) != "" {
+		file.logLinkError(file.openFile())
+	}
+
+	return file
+}
+
 // CloseFile closes the file that is currently used for logging messages to
 // a file
 func (l *FileLogger) CloseFile() {
 	if l.file != nil {
 		l.fileSync.Lock()
+		if l.buffer != nil {
+			l.bufferSync.Lock()
+			l.buffer.Flush()
+			l.buffer = nil
+			l.bufferSync.Unlock()
+		}
 		l.file.Close()
 		l.file = nil
 		l.logger = nil
@@ -47,90 +161,487 @@ func (l *FileLogger) CloseFile() {
 	}
 }
 
+// Reopen closes and opens the log file again. Use this after the log file was moved
+// by an external program so that a new file is created
+func (l *FileLogger) Reopen() {
+	if l.fileSync == nil || strings.TrimSpace(l.Path) == "" {
+		return
+	}
+
+	l.fileSyncWrite.Lock()
+	l.CloseFile()
+	err := l.openFile()
+	l.fileSyncWrite.Unlock()
+	l.logLinkError(err)
+}
+
 // openFile tries to open the file that is configured inside the loggers fild
-// "LogFilePath" and initializes the mutex
-func (l *FileLogger) openFile() {
+// "LogFilePath" and initializes the mutex.
+// The error of linking the file (see LinkLatest) is returned, so that it can be logged
+// with logLinkError() after the locks of the file were released
+func (l *FileLogger) openFile() (linkErr error) {
 	// Initialize new mutex
 	if l.fileSync == nil {
 		l.fileSync = &sync.RWMutex{}
 		l.fileSyncWrite = &sync.RWMutex{}
+		l.fileSize = new(int64)
 	}
 
 	l.fileSync.Lock()
 
 	path := l.getFilePath()
-	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	var file *os.File
+	var err error
+	if l.EncryptionKey != nil && l.cipher == nil {
+		if l.cipher, err = newFileCipher(l.EncryptionKey); err != nil {
+			err = fmt.Errorf("failed to get the encryption key: %w", err)
+		}
+	}
+	if err == nil {
+		file, err = l.createFile(path)
+	}
 	if err == nil {
 		l.logger = log.New(file, "", 0)
 		l.file = file
+		if l.BufferSize > 0 {
+			l.startBuffer()
+		}
+
+		atomic.StoreInt64(l.fileSize, 0)
+		if info, err := file.Stat(); err == nil {
+			atomic.StoreInt64(l.fileSize, info.Size())
+		}
+
+		if l.LinkLatest {
+			linkErr = l.linkLatest(path)
+		}
+		if l.crashOutput {
+			setCrashOutput(file)
+		}
 	} else {
 		l.rootLogger.Log(LevelError, fmt.Sprintf("Cannot access the log file '%s'\n%s", path, err.Error()))
 	}
 
 	l.fileSync.Unlock()
+	return linkErr
+}
+
+// createFile opens the log file for appending. The file and its directories
+// are created with the configured permissions if they don't exist
+func (l *FileLogger) createFile(path string) (*os.File, error) {
+	fileMode := l.FileMode
+	if fileMode == 0 {
+		fileMode = 0644
+	}
+
+	if l.CreateDirs {
+		dirMode := l.DirMode
+		if dirMode == 0 {
+			dirMode = 0755
+		}
+		if err := os.MkdirAll(filepath.Dir(path), dirMode); err != nil {
+			return nil, err
+		}
+	}
+
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fileMode)
+}
+
+// linkLatest points the link at "Path" to the given log file. The link is created
+// next to the real path and moved afterwards so that it's replaced atomically
+func (l *FileLogger) linkLatest(path string) error {
+	linkPath := strings.ReplaceAll(l.Path, "\\", "/")
+	for _, placeholder := range []string{".{date}", "-{date}", "_{date}", "{date}"} {
+		linkPath = strings.Replace(linkPath, placeholder, "", 1)
+	}
+	if linkPath == path || strings.HasSuffix(linkPath, "/") {
+		return nil
+	}
+
+	// Never replace a real file. Hard links can only be recognized if they were created by this logger
+	if info, err := os.Lstat(linkPath); err == nil && info.Mode()&os.ModeSymlink == 0 && !l.isHardLinked(info) {
+		return fmt.Errorf("a file that is not a link already exists at '%s'", linkPath)
+	}
+
+	// The target is relative to the directory of the link, because the date may be part of a directory
+	target, err := filepath.Rel(filepath.Dir(linkPath), path)
+	if err != nil {
+		target, _ = filepath.Abs(path)
+	}
+
+	tmpPath := linkPath + ".tmp"
+	os.Remove(tmpPath)
+	hardLinked := ""
+	if err := os.Symlink(target, tmpPath); err != nil {
+		if err := os.Link(path, tmpPath); err != nil {
+			return fmt.Errorf("failed to link '%s' to '%s': %w", path, linkPath, err)
+		}
+		hardLinked = path
+	}
+
+	if err := os.Rename(tmpPath, linkPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to link '%s' to '%s': %w", path, linkPath, err)
+	}
+	l.hardLinked = hardLinked
+	return nil
+}
+
+// logLinkError logs the error of linkLatest(). It has to be called after the locks of the
+// file were released, because the message is written to the file as well
+func (l *FileLogger) logLinkError(err error) {
+	if err != nil {
+		l.rootLogger.Log(LevelWarning, "Failed to update the link to the latest log file\n%s", err)
+	}
+}
+
+// isHardLinked returns whether the file is the hard link to the log file created by linkLatest()
+func (l *FileLogger) isHardLinked(info os.FileInfo) bool {
+	if l.hardLinked == "" {
+		return false
+	}
+
+	linked, err := os.Stat(l.hardLinked)
+	return err == nil && os.SameFile(info, linked)
+}
+
+// SetLevel changes the minimum log level for logging into the file.
+// This method is safe to call while other goroutines are logging
+func (l *FileLogger) SetLevel(level Level) {
+	l.Level.store(level)
+}
+
+// GetLevel returns the minimum log level for logging into the file
+func (l *FileLogger) GetLevel() Level {
+	return l.Level.load()
+}
+
+// Enabled returns whether the file is opened and the level is enabled for the file
+func (l *FileLogger) Enabled(level Level) bool {
+	return l.GetLevel() <= level && l.logger != nil
+}
+
+// Write writes the entry formatted to the log file
+func (l *FileLogger) Write(e Entry) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	// The options of the file replace the ones of the logger while formatting
+	e.file = l
+
+	message, err := e.appendFormat(*buf, l.Formatter, l.Format, false)
+	*buf = append(message, '\n')
+	if err != nil {
+		return err
+	}
+
+	if l.cipher != nil {
+		encrypted, err := encryptLine(l.cipher, *buf)
+		if err != nil {
+			return err
+		}
+		return l.writeToFile(encrypted)
+	}
+	return l.writeToFile(*buf)
+}
+
+// Close closes the log file. It's the same as calling CloseFile() but also
+// stops the periodic flushing of the write buffer
+func (l *FileLogger) Close() error {
+	l.CloseFile()
+	if l.flushStop != nil {
+		l.flushStop.stop()
+		l.flushStop = nil
+	}
+	return nil
+}
+
+// flushStopper stops the periodic flushing of the write buffer. Loggers that share
+// the file (like clones) share it as well, so that it's only stopped once
+type flushStopper struct {
+	once sync.Once
+	done chan struct{}
+}
+
+// stop stops the flushing. It's safe to call it multiple times
+func (s *flushStopper) stop() {
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+// stopped returns whether the flushing was stopped
+func (s *flushStopper) stopped() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
 }
 
-// writeToFile writes the given message to the opened log file
-func (l *FileLogger) writeToFile(message string, level Level) {
+// Flush writes the buffered messages to the file (see BufferSize)
+func (l *FileLogger) Flush() error {
+	if l.fileSync == nil {
+		return nil
+	}
+
 	l.fileSync.RLock()
-	l.fileSyncWrite.RLock()
+	defer l.fileSync.RUnlock()
+
+	if l.buffer == nil {
+		return nil
+	}
+	l.bufferSync.Lock()
+	defer l.bufferSync.Unlock()
+
+	return l.buffer.Flush()
+}
 
-	// When append date is enabled we need to check if file path is still accurate
-	if l.AppendDate {
-		currentPath := l.getFilePath()
-
-		if l.file.Name() != currentPath {
-			// The file path is not up-to-date anymore → update the log file
-			l.fileSync.RUnlock()
-			l.fileSyncWrite.RUnlock()
-
-			l.fileSyncWrite.Lock()
-			// The syncWriter is now locked. So check again the file name against the current path because the file could already be changed
-			// in the time framew between locking and checking
-			if l.file.Name() != currentPath {
-				l.CloseFile()
-				l.openFile()
+// Sync writes the buffered messages to the file and commits the content of
+// the file to the storage (fsync)
+func (l *FileLogger) Sync() error {
+	if l.fileSync == nil {
+		return nil
+	}
+
+	l.fileSync.RLock()
+	defer l.fileSync.RUnlock()
+
+	if l.file == nil {
+		return nil
+	}
+	if l.buffer != nil {
+		l.bufferSync.Lock()
+		err := l.buffer.Flush()
+		l.bufferSync.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+
+	return l.file.Sync()
+}
+
+// startBuffer creates the write buffer for the opened file and starts the periodic flushing.
+// The caller has to hold the lock "fileSync"
+func (l *FileLogger) startBuffer() {
+	if l.bufferSync == nil {
+		l.bufferSync = &sync.Mutex{}
+	}
+	l.buffer = bufio.NewWriterSize(l.file, l.BufferSize)
+
+	if l.flushStop != nil && !l.flushStop.stopped() {
+		return
+	}
+	interval := l.FlushInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	stop := &flushStopper{done: make(chan struct{})}
+	l.flushStop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop.done:
+				return
+			case <-ticker.C:
+				l.Flush()
 			}
-			l.fileSyncWrite.Unlock()
+		}
+	}()
+}
+
+// writeToFile writes the given message to the opened log file.
+// The message has to end with a line break
+func (l *FileLogger) writeToFile(message []byte) (err error) {
+	l.fileSync.RLock()
+	l.fileSyncWrite.RLock()
 
-			// Lock previous locks again
-			l.fileSync.RLock()
-			l.fileSyncWrite.RLock()
+	// When append date is enabled we need to check if file path is still accurate.
+	// Also the file has to be rotated when the maximum size would be exceeded
+	if l.needsReopen(len(message)) {
+		// The file is not up-to-date anymore → update the log file
+		l.fileSync.RUnlock()
+		l.fileSyncWrite.RUnlock()
+
+		l.fileSyncWrite.Lock()
+		// The syncWriter is now locked. So check again if the file has to be changed because it could already be changed
+		// in the time framew between locking and checking
+		var linkErr error
+		if l.file == nil {
+			// The file could not be opened
+		} else if l.isDated() && l.file.Name() != l.getFilePath() {
+			l.CloseFile()
+			linkErr = l.openFile()
+		} else if l.exceedsMaxSize(len(message)) {
+			linkErr = l.rotate()
 		}
+		l.fileSyncWrite.Unlock()
+		l.logLinkError(linkErr)
+
+		// Lock previous locks again
+		l.fileSync.RLock()
+		l.fileSyncWrite.RLock()
 	}
 
-	l.logger.Println(message)
-	l.file.Sync()
+	if l.buffer != nil {
+		l.bufferSync.Lock()
+		_, err = l.buffer.Write(message)
+		l.bufferSync.Unlock()
+		atomic.AddInt64(l.fileSize, int64(len(message)))
+	} else if l.logger != nil {
+		if _, err = l.logger.Writer().Write(message); err == nil {
+			err = l.file.Sync()
+		}
+		atomic.AddInt64(l.fileSize, int64(len(message)))
+	}
 
 	l.fileSync.RUnlock()
 	l.fileSyncWrite.RUnlock()
 
-	// Close the file because for fatal log level the program is going to be exited
-	if level == LevelFatal {
-		l.CloseFile()
+	return
+}
+
+// needsReopen returns whether the log file has to be changed before
+// a message with the given size can be written
+func (l *FileLogger) needsReopen(size int) bool {
+	if l.file == nil {
+		return false
 	}
+
+	return (l.isDated() && l.file.Name() != l.getFilePath()) || l.exceedsMaxSize(size)
+}
+
+// exceedsMaxSize returns whether the log file would grow over the
+// configured maximum size when writing a message with the given size
+func (l *FileLogger) exceedsMaxSize(messageSize int) bool {
+	if l.MaxSizeMB <= 0 || l.file == nil {
+		return false
+	}
+
+	size := atomic.LoadInt64(l.fileSize)
+	return size > 0 && size+int64(messageSize) > int64(l.MaxSizeMB)*1024*1024
+}
+
+// rotate closes the current log file, renames it by appending the index ".1" and
+// opens a new file. Existing backups are shifted by one index and removed
+// if there are more than "MaxBackups".
+// The caller has to hold the lock "fileSyncWrite". The error of linking the new file is returned
+func (l *FileLogger) rotate() error {
+	path := l.file.Name()
+	l.CloseFile()
+
+	// Find the highest index of the existing backups
+	count := 0
+	for {
+		if _, err := os.Stat(getBackupPath(path, count+1)); err != nil {
+			break
+		}
+		count++
+	}
+
+	// Remove backups that exceed the maximum count
+	for ; l.MaxBackups > 0 && count >= l.MaxBackups; count-- {
+		os.Remove(getBackupPath(path, count))
+	}
+
+	for i := count; i > 0; i-- {
+		os.Rename(getBackupPath(path, i), getBackupPath(path, i+1))
+	}
+	if err := os.Rename(path, getBackupPath(path, 1)); err != nil {
+		l.rootLogger.Log(LevelError, "Failed to rotate the log file '%s'\n%s", path, err)
+	}
+
+	return l.openFile()
+}
+
+// getBackupPath returns the path of a rotated log file with the given index
+func getBackupPath(path string, index int) string {
+	return path + "." + strconv.Itoa(index)
+}
+
+// RotationPeriod defines how long a dated log file is used (see FileLogger.AppendDate)
+type RotationPeriod uint8
+
+const (
+	// A new file is started every day
+	RotateDaily RotationPeriod = iota
+
+	// A new file is started every hour
+	RotateHourly
+
+	// A new file is started every week on monday
+	RotateWeekly
+
+	// A new file is started on the first day of every month
+	RotateMonthly
+)
+
+// start returns the beginning of the period that contains the given time
+func (p RotationPeriod) start(t time.Time) time.Time {
+	year, month, day := t.Date()
+
+	switch p {
+	case RotateHourly:
+		return time.Date(year, month, day, t.Hour(), 0, 0, 0, t.Location())
+	case RotateWeekly:
+		return time.Date(year, month, day-(int(t.Weekday())+6)%7, 0, 0, 0, 0, t.Location())
+	case RotateMonthly:
+		return time.Date(year, month, 1, 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+	}
+}
+
+// layout returns the default date layout for the period
+func (p RotationPeriod) layout() string {
+	switch p {
+	case RotateHourly:
+		return "2006-01-02T15"
+	case RotateMonthly:
+		return "2006-01"
+	default:
+		return "2006-01-02"
+	}
+}
+
+// isDated returns whether the path of the log file contains the date
+func (l *FileLogger) isDated() bool {
+	return l.AppendDate || strings.Contains(l.Path, "{date}")
 }
 
 // getFilePath returns the path to use for the log file
 func (l *FileLogger) getFilePath() string {
 	path := strings.ReplaceAll(l.Path, "\\", "/")
 
-	// Append the current date to the log path when enabled
-	if l.AppendDate {
+	// Insert or append the current date to the log path when enabled
+	if strings.Contains(path, "{date}") {
+		path = strings.ReplaceAll(path, "{date}", l.getFileDate())
+	} else if l.AppendDate {
 		lastSlash := strings.LastIndex(path, "/")
 		if lastSlash != -1 && (lastSlash+1) < len(path) {
-			path = path + "." + getFileDate()
+			path = path + "." + l.getFileDate()
 		} else if lastSlash == -1 {
-			path = path + "." + getFileDate()
+			path = path + "." + l.getFileDate()
 		} else {
-			path += getFileDate()
+			path += l.getFileDate()
 		}
 	}
 
 	return path
 }
 
-// getFileDate returns the current date formatted as the log files path name
-func getFileDate() string {
-	return time.Now().Format("2006-01-02")
+// getFileDate returns the beginning of the current period formatted for the log files path name
+func (l *FileLogger) getFileDate() string {
+	layout := l.DateFormat
+	if layout == "" {
+		layout = l.RotationPeriod.layout()
+	}
+
+	return l.RotationPeriod.start(time.Now()).Format(layout)
 }
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestCloseClonedBufferedFile(t *testing.T) {
+	a := NewLogger(&Logger{
+		Level: LevelOff,
+		File: &FileLogger{
+			Level:      LevelInfo,
+			Path:       filepath.Join(t.TempDir(), "test.log"),
+			BufferSize: 4096,
+		},
+	})
+	b := CloneLogger(a)
+	c := a.Named("child")
+
+	a.Info("message")
+	for _, l := range []*Logger{a, b, c} {
+		if err := l.Close(); err != nil {
+			t.Fatalf("closing the logger failed: %s", err)
+		}
+	}
+}
+
+func TestFileOptionsReplaceLoggerOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	printSource := false
+	precision := PrecisionMilliseconds
+	l := NewLogger(&Logger{
+		Level:       LevelOff,
+		PrintSource: true,
+		File: &FileLogger{
+			Level:         LevelInfo,
+			Path:          path,
+			PrintSource:   &printSource,
+			TimePrecision: &precision,
+		},
+	})
+	l.Info("message")
+	l.Close()
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !regexp.MustCompile(`^\[INFO \] \d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}\.\d{3} - message\n$`).Match(content) {
+		t.Fatalf("unexpected content of the file: %q", content)
+	}
+}
+
+func TestLinkLatestWithDateDirectory(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLogger(&Logger{
+		Level: LevelOff,
+		File: &FileLogger{
+			Level:      LevelInfo,
+			Path:       filepath.Join(dir, "{date}", "test.log"),
+			CreateDirs: true,
+			LinkLatest: true,
+		},
+	})
+	l.Info("message")
+	l.Close()
+
+	content, err := os.ReadFile(filepath.Join(dir, "test.log"))
+	if err != nil || !regexp.MustCompile(`- message\n$`).Match(content) {
+		t.Fatalf("the link doesn't point to the log file: %q (%v)", content, err)
+	}
+}
+
+func TestLinkLatestKeepsExistingFile(t *testing.T) {
+	dir := t.TempDir()
+	linkPath := filepath.Join(dir, "test.log")
+	if err := os.WriteFile(linkPath, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewLogger(&Logger{
+		Level: LevelOff,
+		File:  &FileLogger{Level: LevelInfo, Path: filepath.Join(dir, "test-{date}.log"), LinkLatest: true},
+	})
+	l.Close()
+
+	if content, err := os.ReadFile(linkPath); err != nil || string(content) != "old\n" {
+		t.Fatalf("the existing file was replaced: %q (%v)", content, err)
+	}
+	// The warning is written to the log file itself
+	files, _ := filepath.Glob(filepath.Join(dir, "test-*.log"))
+	if len(files) != 1 {
+		t.Fatalf("expected one dated log file, got %v", files)
+	}
+	if content, _ := os.ReadFile(files[0]); !strings.Contains(string(content), "Failed to update the link") {
+		t.Fatalf("expected a warning in the log file, got %q", content)
+	}
+}
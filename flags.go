@@ -0,0 +1,103 @@
+package logger
+
+import (
+	"flag"
+	"strings"
+)
+
+// Flags contains the logging options parsed from command line flags (see RegisterFlags())
+type Flags struct {
+
+	// Minimum log level for the console and the file ("-log-level")
+	Level Level
+
+	// Path of the log file ("-log-file")
+	File string
+
+	// Format of the messages ("-log-format")
+	Format Format
+
+	// Colorize the console output ("-log-color")
+	Colored bool
+}
+
+// RegisterFlags adds the flags "-log-level", "-log-file", "-log-format" and "-log-color"
+// to the flag set. If the flag set is nil, the flags are added to "flag.CommandLine".
+// Call NewLogger() of the returned flags after parsing the flags:
+//
+//	logFlags := logger.RegisterFlags(nil)
+//	flag.Parse()
+//	logger.SetGlobalLogger(logFlags.NewLogger())
+//
+// For "github.com/spf13/pflag" register the flags to a flag set of the standard library
+// and add it to pflag with "pflag.CommandLine.AddGoFlagSet()"
+func RegisterFlags(fs *flag.FlagSet) *Flags {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+
+	f := &Flags{Level: LevelInfo, Format: FormatText, Colored: true}
+	fs.Var((*levelFlag)(&f.Level), "log-level", "minimum log `level` (trace, debug, info, warn, error or fatal)")
+	fs.StringVar(&f.File, "log-file", "", "`path` of the log file")
+	fs.Var((*formatFlag)(&f.Format), "log-format", "`format` of the log messages (text, json or logfmt)")
+	fs.BoolVar(&f.Colored, "log-color", true, "colorize the console output")
+
+	return f
+}
+
+// NewLogger creates a new logger with the options of the flags
+func (f *Flags) NewLogger() *Logger {
+	return NewLogger(&Logger{
+		Level:         f.Level,
+		Format:        f.Format,
+		ColoredOutput: f.Colored,
+		File: &FileLogger{
+			Level:  f.Level,
+			Path:   f.File,
+			Format: f.Format,
+		},
+	})
+}
+
+// levelFlag is a flag value for a level given by its name
+type levelFlag Level
+
+func (l *levelFlag) String() string {
+	return strings.ToLower(Level(*l).String())
+}
+
+func (l *levelFlag) Set(value string) error {
+	level, err := parseConfigLevel(value, LevelInfo)
+	*l = levelFlag(level)
+	return err
+}
+
+// Type returns the name of the type for the usage message of pflag
+func (l *levelFlag) Type() string {
+	return "level"
+}
+
+// formatFlag is a flag value for a format given by its name
+type formatFlag Format
+
+func (f *formatFlag) String() string {
+	switch Format(*f) {
+	case FormatJSON:
+		return "json"
+	case FormatLogfmt:
+		return "logfmt"
+	default:
+		return "text"
+	}
+}
+
+func (f *formatFlag) Set(value string) error {
+	format, err := parseFormat(value)
+	*f = formatFlag(format)
+	return err
+}
+
+// Type returns the name of the type for the usage message of pflag
+func (f *formatFlag) Type() string {
+	return "format"
+}
@@ -0,0 +1,341 @@
+// fluentlog provides a target that ships the log entries to Fluentd or Fluent Bit
+// via the "forward" protocol.
+//
+// The entries are collected in batches and sent as MessagePack encoded "Forward Mode"
+// messages over TCP. Optionally the server has to acknowledge every chunk ("RequireAck")
+package fluentlog
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/batch"
+	"git.rpjosh.de/RPJosh/go-logger/internal/spill"
+	"git.rpjosh.de/RPJosh/go-logger/retry"
+)
+
+// Config contains the configuration options of the fluent target
+type Config struct {
+
+	// Minimum log level of the entries to send
+	Level logger.Level
+
+	// Network to use. Supported values are "tcp" (default), "tls" and "unix"
+	Network string
+
+	// Address of the server. Defaults to "localhost:24224"
+	Address string
+
+	// TLS configuration used for the network "tls"
+	TLSConfig *tls.Config
+
+	// TLS options used to build "TLSConfig" if it's nil (see logger.TLSOptions)
+	TLS *logger.TLSOptions
+
+	// Tag of the entries. Defaults to the name of the executable
+	Tag string
+
+	// Append the name of the logger to the tag like "<Tag>.<LoggerName>"
+	AppendLoggerName bool
+
+	// Wait for the acknowledgment of the server for every chunk
+	RequireAck bool
+
+	// Maximum number of entries per chunk. Defaults to 100
+	BatchSize int
+
+	// Maximum duration an entry is kept before it's sent. Defaults to 1 second
+	FlushInterval time.Duration
+
+	// Maximum number of entries that are queued. If the queue is full, entries are dropped.
+	// Defaults to 1000
+	QueueSize int
+
+	// Number of attempts to send a chunk. Defaults to 3
+	MaxAttempts int
+
+	// Policy for retrying failed requests like the delays between the attempts.
+	// It replaces "MaxAttempts" if set
+	RetryPolicy *retry.Policy
+
+	// Directory of a persistent queue on disk. Chunks that could not be sent are stored
+	// in it and sent again before the next batch or every 30 seconds. Stored chunks also survive a restart
+	// of the program. The directory must not be shared with other targets.
+	// The queue is disabled if this is empty
+	SpillDir string
+
+	// Maximum size of the queue on disk in bytes. If it's exceeded, the oldest
+	// chunks are dropped. Defaults to 100 MB
+	SpillMaxSize int64
+
+	// Timeout for connecting, writing and waiting for the acknowledgment. Defaults to 10 seconds
+	Timeout time.Duration
+}
+
+// Target sends the entries to a fluent server
+type Target struct {
+	config  Config
+	batcher *batch.Batcher[logger.Entry]
+	health  logger.HealthStatus
+	sender  spill.Sender
+
+	// The connection is only used by the background worker of the batcher
+	conn     net.Conn
+	connSync sync.Mutex
+}
+
+// New creates a new fluent target with the given configuration.
+// Add it to the field "Targets" of a logger
+func New(config Config) *Target {
+	if config.Network == "" {
+		config.Network = "tcp"
+	}
+	if config.Address == "" {
+		config.Address = "localhost:24224"
+	}
+	if config.Tag == "" {
+		config.Tag = filepath.Base(os.Args[0])
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = &retry.Policy{MaxAttempts: config.MaxAttempts, InitialDelay: 500 * time.Millisecond}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.TLS != nil && config.TLSConfig == nil {
+		tlsConfig, err := config.TLS.Config()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fluentlog: invalid TLS options: %s\n", err)
+		}
+		config.TLSConfig = tlsConfig
+	}
+
+	t := &Target{config: config}
+	t.sender = spill.Sender{Name: "fluentlog", Policy: config.RetryPolicy, Send: t.writePayload}
+	if config.SpillDir != "" {
+		queue, err := spill.Open(spill.Options{Dir: config.SpillDir, MaxSize: config.SpillMaxSize})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "fluentlog: failed to open the spill queue: %s\n", err)
+		}
+		t.sender.Queue = queue
+		t.sender.Start()
+	}
+	t.batcher = batch.New(batch.Options[logger.Entry]{
+		MaxItems:   config.BatchSize,
+		MaxLatency: config.FlushInterval,
+		QueueSize:  config.QueueSize,
+	}, t.send)
+
+	return t
+}
+
+// Enabled returns whether entries with the level are sent
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.config.Level <= level
+}
+
+// Write queues the entry for sending
+func (t *Target) Write(e logger.Entry) error {
+	if !t.batcher.Add(e) {
+		return errors.New("fluentlog: queue is full, entry dropped")
+	}
+
+	return nil
+}
+
+// Flush sends all queued entries
+func (t *Target) Flush() {
+	t.batcher.Flush()
+}
+
+// Stats returns the metrics of the sent batches like their size and the flush latency
+func (t *Target) Stats() batch.Stats {
+	return t.batcher.Stats()
+}
+
+// Health returns the state of the target with the last error of sending a batch
+func (t *Target) Health() logger.SinkHealth {
+	health := t.health.Status()
+	health.QueueDepth = t.batcher.Stats().Queued + t.sender.Len()
+	return health
+}
+
+// Close sends all queued entries and closes the connection
+func (t *Target) Close() error {
+	t.batcher.Close()
+
+	t.connSync.Lock()
+	defer t.connSync.Unlock()
+	t.disconnect()
+
+	return t.sender.Close()
+}
+
+// send groups the entries by their tag and sends every group as a chunk
+func (t *Target) send(entries []logger.Entry) {
+	t.connSync.Lock()
+	defer t.connSync.Unlock()
+
+	tags := make([]string, 0, 1)
+	groups := make(map[string][]logger.Entry)
+	for _, e := range entries {
+		tag := t.getTag(e)
+		if _, ok := groups[tag]; !ok {
+			tags = append(tags, tag)
+		}
+		groups[tag] = append(groups[tag], e)
+	}
+
+	for _, tag := range tags {
+		chunk, message := t.encode(tag, groups[tag])
+		stored, err := t.sender.Deliver(append([]byte(chunk+"\n"), message...))
+		t.health.Report(err)
+		if err != nil && !stored {
+			fmt.Fprintf(os.Stderr, "fluentlog: failed to send %d entries: %s\n", len(groups[tag]), err)
+		}
+	}
+}
+
+// getTag returns the tag of the entry
+func (t *Target) getTag(e logger.Entry) string {
+	if t.config.AppendLoggerName && e.LoggerName != "" {
+		return t.config.Tag + "." + e.LoggerName
+	}
+
+	return t.config.Tag
+}
+
+// encode returns the entries encoded as a message in the "Forward Mode" and the ID of the chunk
+func (t *Target) encode(tag string, entries []logger.Entry) (chunk string, message []byte) {
+	enc := &encoder{}
+	enc.writeArrayHeader(3)
+	enc.writeString(tag)
+
+	enc.writeArrayHeader(len(entries))
+	for _, e := range entries {
+		enc.writeArrayHeader(2)
+		enc.writeEventTime(e.Time)
+		enc.writeMap(getRecord(e))
+	}
+
+	option := map[string]any{"size": len(entries)}
+	if t.config.RequireAck {
+		id := make([]byte, 16)
+		rand.Read(id)
+		chunk = base64.StdEncoding.EncodeToString(id)
+		option["chunk"] = chunk
+	}
+	enc.writeMap(option)
+
+	return chunk, enc.buf
+}
+
+// getRecord returns the record sent for the entry
+func getRecord(e logger.Entry) map[string]any {
+	record := make(map[string]any, len(e.Fields)+4)
+	for key, value := range e.Fields {
+		record[key] = value
+	}
+
+	record["level"] = e.Level.String()
+	record["message"] = e.Message
+	if e.Line > 0 {
+		record["source"] = filepath.Base(e.File) + ":" + strconv.Itoa(e.Line)
+	}
+	if e.LoggerName != "" {
+		record["logger"] = e.LoggerName
+	}
+
+	return record
+}
+
+// write sends the message and waits for the acknowledgment if required.
+// On failure the connection is closed so that the next attempt reconnects
+func (t *Target) write(chunk string, message []byte) (err error) {
+	defer func() {
+		if err != nil {
+			t.disconnect()
+		}
+	}()
+
+	if err = t.connect(); err != nil {
+		return err
+	}
+
+	t.conn.SetDeadline(time.Now().Add(t.config.Timeout))
+	if _, err = t.conn.Write(message); err != nil {
+		return err
+	}
+
+	if chunk != "" {
+		ack, err := readAckResponse(t.conn)
+		if err != nil {
+			return err
+		}
+		if ack != chunk {
+			return errors.New("acknowledgment does not match the chunk")
+		}
+	}
+
+	return nil
+}
+
+// writePayload sends a message of the spill queue. The payload starts with
+// the ID of the chunk followed by a line break
+func (t *Target) writePayload(payload []byte) error {
+	chunk, message, _ := bytes.Cut(payload, []byte("\n"))
+	return t.write(string(chunk), message)
+}
+
+// connect connects to the server if not already connected
+func (t *Target) connect() (err error) {
+	if t.conn != nil {
+		return nil
+	}
+
+	dialer := &net.Dialer{Timeout: t.config.Timeout}
+	switch t.config.Network {
+	case "tls":
+		t.conn, err = tls.DialWithDialer(dialer, "tcp", t.config.Address, t.config.TLSConfig)
+	case "tcp", "unix":
+		t.conn, err = dialer.Dial(t.config.Network, t.config.Address)
+	default:
+		err = fmt.Errorf("unsupported network %q", t.config.Network)
+	}
+	if err != nil {
+		t.conn = nil
+	}
+
+	return
+}
+
+// disconnect closes the connection
+func (t *Target) disconnect() {
+	if t.conn != nil {
+		t.conn.Close()
+		t.conn = nil
+	}
+}
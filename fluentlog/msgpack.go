@@ -0,0 +1,269 @@
+package fluentlog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// Minimal MessagePack encoder and decoder that supports the types required
+// for the forward protocol
+
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) writeNil() {
+	e.buf = append(e.buf, 0xc0)
+}
+
+func (e *encoder) writeBool(v bool) {
+	if v {
+		e.buf = append(e.buf, 0xc3)
+	} else {
+		e.buf = append(e.buf, 0xc2)
+	}
+}
+
+func (e *encoder) writeInt(v int64) {
+	switch {
+	case v >= 0:
+		e.writeUint(uint64(v))
+	case v >= -32:
+		e.buf = append(e.buf, byte(v))
+	case v >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xd2), uint32(v))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xd3), uint64(v))
+	}
+}
+
+func (e *encoder) writeUint(v uint64) {
+	switch {
+	case v <= 0x7f:
+		e.buf = append(e.buf, byte(v))
+	case v <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xce), uint32(v))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcf), v)
+	}
+}
+
+func (e *encoder) writeFloat(v float64) {
+	e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcb), math.Float64bits(v))
+}
+
+func (e *encoder) writeString(v string) {
+	l := len(v)
+	switch {
+	case l <= 31:
+		e.buf = append(e.buf, 0xa0|byte(l))
+	case l <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(l))
+	case l <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xda), uint16(l))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xdb), uint32(l))
+	}
+	e.buf = append(e.buf, v...)
+}
+
+func (e *encoder) writeBinary(v []byte) {
+	l := len(v)
+	switch {
+	case l <= math.MaxUint8:
+		e.buf = append(e.buf, 0xc4, byte(l))
+	case l <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xc5), uint16(l))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xc6), uint32(l))
+	}
+	e.buf = append(e.buf, v...)
+}
+
+func (e *encoder) writeArrayHeader(l int) {
+	switch {
+	case l <= 15:
+		e.buf = append(e.buf, 0x90|byte(l))
+	case l <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xdc), uint16(l))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xdd), uint32(l))
+	}
+}
+
+func (e *encoder) writeMapHeader(l int) {
+	switch {
+	case l <= 15:
+		e.buf = append(e.buf, 0x80|byte(l))
+	case l <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xde), uint16(l))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xdf), uint32(l))
+	}
+}
+
+// writeEventTime writes the time as the extension type "EventTime" of fluentd
+func (e *encoder) writeEventTime(t time.Time) {
+	e.buf = append(e.buf, 0xd7, 0x00)
+	e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(t.Unix()))
+	e.buf = binary.BigEndian.AppendUint32(e.buf, uint32(t.Nanosecond()))
+}
+
+// writeMap writes a map with its keys sorted
+func (e *encoder) writeMap(m map[string]any) {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	e.writeMapHeader(len(keys))
+	for _, key := range keys {
+		e.writeString(key)
+		e.writeValue(m[key])
+	}
+}
+
+// writeValue writes an arbitrary value. Unknown types are written as string
+func (e *encoder) writeValue(value any) {
+	switch v := value.(type) {
+	case nil:
+		e.writeNil()
+	case bool:
+		e.writeBool(v)
+	case int:
+		e.writeInt(int64(v))
+	case int8:
+		e.writeInt(int64(v))
+	case int16:
+		e.writeInt(int64(v))
+	case int32:
+		e.writeInt(int64(v))
+	case int64:
+		e.writeInt(v)
+	case uint:
+		e.writeUint(uint64(v))
+	case uint8:
+		e.writeUint(uint64(v))
+	case uint16:
+		e.writeUint(uint64(v))
+	case uint32:
+		e.writeUint(uint64(v))
+	case uint64:
+		e.writeUint(v)
+	case float32:
+		e.writeFloat(float64(v))
+	case float64:
+		e.writeFloat(v)
+	case string:
+		e.writeString(v)
+	case []byte:
+		e.writeBinary(v)
+	case time.Time:
+		e.writeString(v.Format(time.RFC3339Nano))
+	case error:
+		e.writeString(v.Error())
+	case map[string]any:
+		e.writeMap(v)
+	case []any:
+		e.writeArrayHeader(len(v))
+		for _, item := range v {
+			e.writeValue(item)
+		}
+	case []string:
+		e.writeArrayHeader(len(v))
+		for _, item := range v {
+			e.writeString(item)
+		}
+	default:
+		e.writeString(fmt.Sprintf("%v", v))
+	}
+}
+
+// readAckResponse reads the response of the server for a chunk and returns the
+// value of the key "ack". Only string keys and values are supported
+func readAckResponse(r io.Reader) (string, error) {
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+
+	var entries int
+	switch {
+	case b[0]&0xf0 == 0x80:
+		entries = int(b[0] & 0x0f)
+	case b[0] == 0xde:
+		l := make([]byte, 2)
+		if _, err := io.ReadFull(r, l); err != nil {
+			return "", err
+		}
+		entries = int(binary.BigEndian.Uint16(l))
+	default:
+		return "", errors.New("unexpected response from the server")
+	}
+
+	ack := ""
+	for i := 0; i < entries; i++ {
+		key, err := readString(r)
+		if err != nil {
+			return "", err
+		}
+		value, err := readString(r)
+		if err != nil {
+			return "", err
+		}
+
+		if key == "ack" {
+			ack = value
+		}
+	}
+
+	return ack, nil
+}
+
+// readString reads a string value
+func readString(r io.Reader) (string, error) {
+	b := make([]byte, 1)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return "", err
+	}
+
+	var l int
+	switch {
+	case b[0]&0xe0 == 0xa0:
+		l = int(b[0] & 0x1f)
+	case b[0] == 0xd9:
+		if _, err := io.ReadFull(r, b); err != nil {
+			return "", err
+		}
+		l = int(b[0])
+	case b[0] == 0xda:
+		lb := make([]byte, 2)
+		if _, err := io.ReadFull(r, lb); err != nil {
+			return "", err
+		}
+		l = int(binary.BigEndian.Uint16(lb))
+	default:
+		return "", errors.New("unexpected response from the server")
+	}
+
+	str := make([]byte, l)
+	if _, err := io.ReadFull(r, str); err != nil {
+		return "", err
+	}
+
+	return string(str), nil
+}
@@ -0,0 +1,686 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Format defines how a log message is rendered
+type Format uint8
+
+const (
+	// FormatText renders the messages human readable like
+	//  [INFO ] 2024-04-10 19:00:00 (file:1) [name]PREFIX - Message key=value
+	FormatText Format = iota
+
+	// FormatJSON renders each message as a single JSON object like
+	//  {"level":"INFO","time":"2024-04-10T19:00:00+02:00","source":"file:1","prefix":"PREFIX","message":"Message","key":"value"}
+	FormatJSON
+
+	// FormatLogfmt renders each message as logfmt key/value pairs like
+	//  level=info ts=2024-04-10T19:00:00+02:00 caller=file:1 prefix=PREFIX msg=Message key=value
+	FormatLogfmt
+)
+
+const (
+	// TimeFormatUnix prints the timestamp as seconds since the unix epoch
+	TimeFormatUnix = "unix"
+
+	// TimeFormatUnixMilli prints the timestamp as milliseconds since the unix epoch
+	TimeFormatUnixMilli = "unixmilli"
+)
+
+// Default layout of the timestamp for the text format
+const defaultTimeFormat = "2006-01-02 15:04:05"
+
+// TimePrecision defines the fractional seconds that are printed for the timestamps
+type TimePrecision uint8
+
+const (
+	// Timestamps are printed with full seconds
+	PrecisionSeconds TimePrecision = iota
+
+	// Timestamps are printed with three fractional digits
+	PrecisionMilliseconds
+
+	// Timestamps are printed with six fractional digits
+	PrecisionMicroseconds
+
+	// Timestamps are printed with nine fractional digits
+	PrecisionNanoseconds
+)
+
+// digits returns the number of fractional digits
+func (p TimePrecision) digits() int {
+	switch p {
+	case PrecisionMilliseconds:
+		return 3
+	case PrecisionMicroseconds:
+		return 6
+	case PrecisionNanoseconds:
+		return 9
+	default:
+		return 0
+	}
+}
+
+// layout returns the given default layout with the fractional seconds of the precision
+func (p TimePrecision) layout(layout string) string {
+	if digits := p.digits(); digits > 0 {
+		return strings.Replace(layout, "05", "05."+strings.Repeat("0", digits), 1)
+	}
+
+	return layout
+}
+
+// Formatter converts an entry to the representation that is written to a target.
+// The returned bytes must not contain a trailing line break
+type Formatter interface {
+	Format(e Entry) ([]byte, error)
+}
+
+// TextFormatter renders the entries human readable (FormatText)
+type TextFormatter struct {
+
+	// Colorize the output with ANSI color codes. Colors are only applied
+	// if coloring is enabled for the logger ("ColoredOutput")
+	Colored bool
+}
+
+// JSONFormatter renders each entry as a single JSON object (FormatJSON).
+// Fields that collide with the default keys are prefixed with "fields."
+type JSONFormatter struct{}
+
+// LogfmtFormatter renders each entry as logfmt key/value pairs (FormatLogfmt)
+type LogfmtFormatter struct{}
+
+// appendFormatter is implemented by the built-in formatters. It appends the
+// formatted entry to the buffer so that no additional allocations are required
+type appendFormatter interface {
+	appendFormat(buf []byte, e Entry) []byte
+}
+
+// getFormatter returns the formatter that implements the format
+func (format Format) getFormatter(colored bool) appendFormatter {
+	switch format {
+	case FormatJSON:
+		return JSONFormatter{}
+	case FormatLogfmt:
+		return LogfmtFormatter{}
+	default:
+		return TextFormatter{Colored: colored}
+	}
+}
+
+// String returns the entry in the text format without colors
+func (e Entry) String() string {
+	return string(TextFormatter{}.appendFormat(nil, e))
+}
+
+// appendFormat appends the entry rendered by the given formatter to the buffer.
+// If the formatter is nil, the formatter of the given format is used
+func (e Entry) appendFormat(buf []byte, formatter Formatter, format Format, colored bool) ([]byte, error) {
+	if formatter == nil {
+		return format.getFormatter(colored).appendFormat(buf, e), nil
+	}
+	if f, ok := formatter.(appendFormatter); ok {
+		return f.appendFormat(buf, e), nil
+	}
+
+	b, err := formatter.Format(e)
+	return append(buf, b...), err
+}
+
+// Format returns the human readable representation of the entry
+func (f TextFormatter) Format(e Entry) ([]byte, error) {
+	return f.appendFormat(nil, e), nil
+}
+
+func (f TextFormatter) appendFormat(buf []byte, e Entry) []byte {
+	l := e.options()
+	colors := textColors{}
+	if f.Colored && (l.colorConf.enableColors || l.colorConf.enableColorsErr) {
+		colors = l.ColorStyle.getColors(e.Level)
+	}
+	buf = appendColorStart(buf, colors.line)
+
+	// The message and the fields are colored together
+	appendMessage := func(buf []byte) []byte {
+		msgColor := colors.message
+		if e.Message == "" && len(e.Fields) == 0 {
+			msgColor = ""
+		}
+		buf = appendColorStart(buf, msgColor)
+		buf = append(buf, e.Message...)
+		buf = e.appendFields(buf)
+		if e.Stack != "" {
+			buf = append(buf, '\n')
+			buf = append(buf, e.Stack...)
+		}
+		buf = appendColorEnd(buf, msgColor)
+		return appendColorEnd(buf, colors.line)
+	}
+
+	if l.OnlyPrintMessage {
+		return appendMessage(buf)
+	}
+
+	buf = appendColorStart(buf, colors.level)
+	buf = append(buf, '[')
+	if label, ok := l.levelLabels[e.Level]; ok {
+		buf = append(buf, label...)
+	} else {
+		buf = appendLevelPadded(buf, e.Level)
+	}
+	buf = append(buf, "] "...)
+	buf = appendColorEnd(buf, colors.level)
+
+	buf = appendColorStart(buf, colors.time)
+	buf = e.appendTime(buf, defaultTimeFormat)
+	buf = appendColorEnd(buf, colors.time)
+
+	if e.printSource() {
+		buf = appendColorStart(buf, colors.source)
+		buf = append(buf, " ("...)
+		buf = e.appendSource(buf)
+		buf = append(buf, ')')
+		buf = appendColorEnd(buf, colors.source)
+	}
+
+	if e.LoggerName != "" {
+		buf = appendColorStart(buf, colors.name)
+		buf = append(buf, " ["...)
+		buf = append(buf, e.LoggerName...)
+		buf = append(buf, ']')
+		buf = appendColorEnd(buf, colors.name)
+	}
+
+	if prefix := e.prefix(); prefix != "" {
+		buf = appendColorStart(buf, colors.prefix)
+		buf = append(buf, prefix...)
+		buf = appendColorEnd(buf, colors.prefix)
+	}
+
+	buf = append(buf, " - "...)
+	return appendMessage(buf)
+}
+
+// Format returns the entry as a single JSON object
+func (f JSONFormatter) Format(e Entry) ([]byte, error) {
+	return f.appendFormat(nil, e), nil
+}
+
+func (JSONFormatter) appendFormat(buf []byte, e Entry) []byte {
+	l := e.options()
+	start := len(buf)
+
+	buf = append(buf, '{')
+	if !l.OnlyPrintMessage {
+		buf = appendJSONKey(buf, start, "level")
+		buf = appendJSONString(buf, e.Level.String())
+		if timeFormat := e.timeFormat(); timeFormat == TimeFormatUnix || timeFormat == TimeFormatUnixMilli {
+			// Epoch timestamps are written as a number
+			buf = appendJSONKey(buf, start, "time")
+			buf = e.appendTime(buf, "")
+		} else {
+			buf = appendJSONKey(buf, start, "time")
+			buf = append(buf, '"')
+			buf = e.appendTime(buf, time.RFC3339)
+			buf = append(buf, '"')
+		}
+		if e.printSource() {
+			buf = appendJSONKey(buf, start, "source")
+			buf = append(buf, '"')
+			buf = e.appendSource(buf)
+			buf = append(buf, '"')
+		}
+		if e.LoggerName != "" {
+			buf = appendJSONKey(buf, start, "logger")
+			buf = appendJSONString(buf, e.LoggerName)
+		}
+		if prefix := e.prefix(); prefix != "" {
+			buf = appendJSONKey(buf, start, "prefix")
+			buf = appendJSONString(buf, strings.TrimSpace(prefix))
+		}
+	}
+	buf = appendJSONKey(buf, start, "message")
+	buf = appendJSONString(buf, e.Message)
+
+	e.forEachField(func(key string, value any) {
+		switch key {
+		case "message", "level", "time", "source", "logger", "prefix":
+			buf = appendJSONKey(buf, start, "fields."+key)
+			buf = appendJSONValue(buf, value)
+		default:
+			buf = appendJSONField(buf, start, key, value)
+		}
+	})
+
+	return append(buf, '}')
+}
+
+// Format returns the entry as logfmt key/value pairs
+func (f LogfmtFormatter) Format(e Entry) ([]byte, error) {
+	return f.appendFormat(nil, e), nil
+}
+
+func (LogfmtFormatter) appendFormat(buf []byte, e Entry) []byte {
+	l := e.options()
+	start := len(buf)
+
+	if !l.OnlyPrintMessage {
+		buf = appendLogfmtKey(buf, start, "level")
+		levelStart := len(buf)
+		buf = append(buf, strings.ToLower(e.Level.String())...)
+		if needsQuoting(buf[levelStart:]) {
+			buf = strconv.AppendQuote(buf[:levelStart], string(buf[levelStart:]))
+		}
+		buf = appendLogfmtKey(buf, start, "ts")
+		timeStart := len(buf)
+		buf = e.appendTime(buf, time.RFC3339)
+		if needsQuoting(buf[timeStart:]) {
+			buf = strconv.AppendQuote(buf[:timeStart], string(buf[timeStart:]))
+		}
+		if e.printSource() {
+			buf = appendLogfmtKey(buf, start, "caller")
+			callerStart := len(buf)
+			buf = e.appendSource(buf)
+			if needsQuoting(buf[callerStart:]) {
+				buf = strconv.AppendQuote(buf[:callerStart], string(buf[callerStart:]))
+			}
+		}
+		if e.LoggerName != "" {
+			buf = appendLogfmtKey(buf, start, "logger")
+			buf = appendFieldString(buf, e.LoggerName)
+		}
+		if prefix := e.prefix(); prefix != "" {
+			buf = appendLogfmtKey(buf, start, "prefix")
+			buf = appendFieldString(buf, strings.TrimSpace(prefix))
+		}
+	}
+	buf = appendLogfmtKey(buf, start, "msg")
+	buf = appendFieldString(buf, e.Message)
+
+	e.forEachField(func(key string, value any) {
+		buf = appendLogfmtField(buf, start, key, value)
+	})
+
+	return buf
+}
+
+// appendLogfmtKey appends the key in the logfmt format followed by the "=" sign.
+// Invalid characters of the key are replaced with an underscore.
+// The start is the position of the first field within the buffer
+func appendLogfmtKey(buf []byte, start int, key string) []byte {
+	if len(buf) > start {
+		buf = append(buf, ' ')
+	}
+
+	for i := 0; i < len(key); i++ {
+		if c := key[i]; c <= ' ' || c == '=' || c == '"' {
+			buf = append(buf, '_')
+		} else {
+			buf = append(buf, c)
+		}
+	}
+	return append(buf, '=')
+}
+
+// appendLogfmtField appends the key and value in the logfmt format to the buffer
+func appendLogfmtField(buf []byte, start int, key string, value any) []byte {
+	buf = appendLogfmtKey(buf, start, key)
+	return appendFieldValue(buf, value)
+}
+
+// formatTime returns the time of the entry formatted with the configured layout
+// of the logger. If no layout is configured, the given default layout is used
+func (e Entry) formatTime(defaultLayout string) string {
+	return string(e.appendTime(nil, defaultLayout))
+}
+
+// appendTime appends the time of the entry formatted with the configured layout
+// of the logger to the buffer. If no layout is configured, the given default layout is used
+func (e Entry) appendTime(buf []byte, defaultLayout string) []byte {
+	l := e.options()
+	t := e.Time.Local()
+	if l.UseUTC {
+		t = e.Time.UTC()
+	}
+
+	switch timeFormat := e.timeFormat(); timeFormat {
+	case "":
+		return t.AppendFormat(buf, e.timePrecision().layout(defaultLayout))
+	case TimeFormatUnix:
+		buf = strconv.AppendInt(buf, t.Unix(), 10)
+		if digits := e.timePrecision().digits(); digits > 0 {
+			// Fractional seconds padded with leading zeros
+			fraction := strconv.Itoa(t.Nanosecond()/pow10(9-digits) + pow10(digits))
+			buf = append(buf, '.')
+			buf = append(buf, fraction[1:]...)
+		}
+		return buf
+	case TimeFormatUnixMilli:
+		return strconv.AppendInt(buf, t.UnixMilli(), 10)
+	default:
+		return t.AppendFormat(buf, timeFormat)
+	}
+}
+
+// pow10 returns 10 to the power of n
+func pow10(n int) int {
+	rtc := 1
+	for i := 0; i < n; i++ {
+		rtc *= 10
+	}
+
+	return rtc
+}
+
+// appendLevelPadded appends the name of the level padded with spaces to five characters
+func appendLevelPadded(buf []byte, level Level) []byte {
+	name := level.String()
+	buf = append(buf, name...)
+	for i := len(name); i < 5; i++ {
+		buf = append(buf, ' ')
+	}
+
+	return buf
+}
+
+// appendSourceName appends the file name and line number of the source like "file.go:1"
+func appendSourceName(buf []byte, file string, line int) []byte {
+	buf = append(buf, file[strings.LastIndex(file, "/")+1:]...)
+	buf = append(buf, ':')
+	return strconv.AppendInt(buf, int64(line), 10)
+}
+
+// appendColorStart appends the color code. An empty code is not applied
+func appendColorStart(buf []byte, code colorCode) []byte {
+	return append(buf, code...)
+}
+
+// appendColorEnd appends the code that resets the color if a color was applied
+func appendColorEnd(buf []byte, code colorCode) []byte {
+	if code != "" {
+		buf = append(buf, colReset...)
+	}
+	return buf
+}
+
+// appendJSONKey appends the key as JSON followed by a colon.
+// The start is the position of the opening brace within the buffer
+func appendJSONKey(buf []byte, start int, key string) []byte {
+	if len(buf) > start+1 {
+		buf = append(buf, ',')
+	}
+
+	buf = appendJSONString(buf, key)
+	return append(buf, ':')
+}
+
+// appendJSONField appends the key and value as JSON to the buffer
+func appendJSONField(buf []byte, start int, key string, value any) []byte {
+	buf = appendJSONKey(buf, start, key)
+	return appendJSONValue(buf, value)
+}
+
+// appendJSONValue appends the value as JSON. Common types are encoded directly,
+// all other values are encoded with "json.Marshal()". If the value can't be encoded,
+// the value formatted with "%v" is written as string
+func appendJSONValue(buf []byte, value any) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, "null"...)
+	case string:
+		return appendJSONString(buf, v)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int8:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int16:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int32:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case uint:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint8:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint16:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint32:
+		return strconv.AppendUint(buf, uint64(v), 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case float32:
+		if !math.IsNaN(float64(v)) && !math.IsInf(float64(v), 0) {
+			return appendJSONFloat(buf, float64(v), 32)
+		}
+	case float64:
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			return appendJSONFloat(buf, v, 64)
+		}
+	}
+
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return appendJSONString(buf, fmt.Sprintf("%v", value))
+	}
+	return append(buf, valueJSON...)
+}
+
+// appendJSONFloat appends the float in the same representation as "json.Marshal()"
+func appendJSONFloat(buf []byte, f float64, bits int) []byte {
+	format := byte('f')
+	if abs := math.Abs(f); abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	buf = strconv.AppendFloat(buf, f, format, -1, bits)
+	if format == 'e' {
+		// Clean up e-09 to e-9
+		if n := len(buf); n >= 4 && buf[n-4] == 'e' && buf[n-3] == '-' && buf[n-2] == '0' {
+			buf[n-2] = buf[n-1]
+			buf = buf[:n-1]
+		}
+	}
+
+	return buf
+}
+
+// appendJSONString appends the string quoted and escaped as JSON.
+// Like "json.Marshal()" the characters <, > and & are escaped
+func appendJSONString(buf []byte, s string) []byte {
+	const hex = "0123456789abcdef"
+
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= ' ' && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, `\ufffd`...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hex[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+
+	return append(buf, '"')
+}
+
+// getFieldKeys returns the keys of the structured fields sorted ascending
+func (e Entry) getFieldKeys() []string {
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// forEachField calls the function for every structured field sorted by the key.
+// The static fields of the logger are passed first. Use this for structured formats
+func (e Entry) forEachField(fn func(key string, value any)) {
+	if l := e.logger; l != nil {
+		for _, key := range l.staticKeys {
+			// The fields of the entry replace the static fields
+			if _, exists := e.Fields[key]; !exists {
+				fn(key, l.StaticFields[key])
+			}
+		}
+	}
+
+	e.forEachEntryField(fn)
+	if e.Stack != "" {
+		fn("stacktrace", e.Stack)
+	}
+}
+
+// forEachEntryField calls the function for every structured field of the entry sorted
+// by the key. The goroutine ID, the process ID, the hostname and the uptime are passed first if enabled
+func (e Entry) forEachEntryField(fn func(key string, value any)) {
+	if l := e.logger; l != nil {
+		if l.PrintGoroutineID && e.GoroutineID != 0 {
+			fn("goroutine", e.GoroutineID)
+		}
+		if l.PrintPID {
+			fn("pid", os.Getpid())
+		}
+		if l.PrintHostname && l.hostname != "" {
+			fn("host", l.hostname)
+		}
+		if l.PrintUptime && l.uptimeStart != nil {
+			fn("uptime", e.Time.Sub(*l.uptimeStart.Load()))
+		}
+	}
+
+	switch len(e.Fields) {
+	case 0:
+		return
+	case 1:
+		// No need to sort
+		for key, value := range e.Fields {
+			fn(key, value)
+		}
+		return
+	}
+
+	for _, key := range e.getFieldKeys() {
+		fn(key, e.Fields[key])
+	}
+}
+
+// getFieldsMessage returns the structured fields of the entry formatted as
+// " key=value" pairs sorted by their key
+func (e Entry) getFieldsMessage() string {
+	return string(e.appendFields(nil))
+}
+
+// appendFields appends the structured fields of the entry formatted as
+// " key=value" pairs sorted by their key
+func (e Entry) appendFields(buf []byte) []byte {
+	e.forEachEntryField(func(key string, value any) {
+		buf = append(buf, ' ')
+		buf = append(buf, key...)
+		buf = append(buf, '=')
+		buf = appendFieldValue(buf, value)
+	})
+
+	return buf
+}
+
+// formatFieldValue converts the value of a field to a string. Values containing
+// spaces or quotes are quoted
+func formatFieldValue(value any) string {
+	return string(appendFieldValue(nil, value))
+}
+
+// appendFieldValue appends the value of a field to the buffer. Values containing
+// spaces or quotes are quoted
+func appendFieldValue(buf []byte, value any) []byte {
+	switch v := value.(type) {
+	case string:
+		return appendFieldString(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case bool:
+		return strconv.AppendBool(buf, v)
+	default:
+		return appendFieldString(buf, fmt.Sprintf("%v", value))
+	}
+}
+
+// appendFieldString appends the string to the buffer. It's quoted if it contains
+// spaces or quotes
+func appendFieldString(buf []byte, str string) []byte {
+	if needsQuoting([]byte(str)) {
+		return strconv.AppendQuote(buf, str)
+	}
+	return append(buf, str...)
+}
+
+// needsQuoting returns whether the value is empty or contains spaces, quotes
+// or equal signs
+func needsQuoting(value []byte) bool {
+	if len(value) == 0 {
+		return true
+	}
+	for _, c := range value {
+		if c <= ' ' || c == '=' || c == '"' {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,77 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// GELFFormatter renders each entry as a GELF 1.1 message for Graylog.
+// The structured fields are added as additional fields prefixed with an underscore.
+// GELF over TCP requires a null byte as delimiter between the messages
+type GELFFormatter struct {
+
+	// Name of the host that sent the message. Defaults to the hostname
+	Host string
+}
+
+// Format returns the entry as a GELF message
+func (f GELFFormatter) Format(e Entry) ([]byte, error) {
+	return f.appendFormat(nil, e), nil
+}
+
+func (f GELFFormatter) appendFormat(buf []byte, e Entry) []byte {
+	start := len(buf)
+
+	host := f.Host
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+
+	// The short message only contains the first line
+	shortMessage, _, multiline := strings.Cut(e.Message, "\n")
+	if prefix := e.prefix(); prefix != "" {
+		shortMessage = strings.TrimSpace(prefix) + " " + shortMessage
+	}
+
+	buf = append(buf, '{')
+	buf = appendJSONField(buf, start, "version", "1.1")
+	buf = appendJSONField(buf, start, "host", host)
+	buf = appendJSONField(buf, start, "short_message", shortMessage)
+	if multiline {
+		buf = appendJSONField(buf, start, "full_message", e.Message)
+	}
+	buf = appendJSONKey(buf, start, "timestamp")
+	buf = strconv.AppendFloat(buf, float64(e.Time.UnixMilli())/1000, 'f', -1, 64)
+	buf = appendJSONKey(buf, start, "level")
+	buf = strconv.AppendInt(buf, int64(e.Level.getSyslogSeverity()), 10)
+
+	if e.LoggerName != "" {
+		buf = appendJSONField(buf, start, "_logger", e.LoggerName)
+	}
+	if e.Line > 0 {
+		buf = appendJSONField(buf, start, "_file", e.File)
+		buf = appendJSONField(buf, start, "_line", e.Line)
+	}
+
+	e.forEachField(func(key string, value any) {
+		// The field "_id" is reserved by Graylog
+		if key == "id" {
+			key = "id_"
+		}
+		buf = appendJSONKey(buf, start, "_"+key)
+
+		// Additional fields only support strings and numbers
+		switch v := value.(type) {
+		case string:
+			buf = appendJSONString(buf, v)
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+			buf = appendJSONValue(buf, v)
+		default:
+			buf = appendJSONString(buf, fmt.Sprintf("%v", value))
+		}
+	})
+
+	return append(buf, '}')
+}
@@ -1,3 +1,3 @@
 module git.rpjosh.de/RPJosh/go-logger
 
-go 1.20
+go 1.21
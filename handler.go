@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// levelHandlerBody is the JSON body used by the LevelHandler
+type levelHandlerBody struct {
+	Level     string `json:"level,omitempty"`
+	FileLevel string `json:"fileLevel,omitempty"`
+}
+
+// LevelHandler returns a http handler to read and change the log levels of the logger
+// at runtime.
+// A GET request returns the current levels for the console and the file as JSON:
+//
+//	{"level":"INFO","fileLevel":"WARN"}
+//
+// With a PUT (or POST) request the levels can be changed by sending the same JSON structure.
+// Omitted levels are not changed
+func (l *Logger) LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			var body levelHandlerBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			level, ok := parseLevel(body.Level)
+			fileLevel, fileOk := parseLevel(body.FileLevel)
+			if (body.Level != "" && !ok) || (body.FileLevel != "" && !fileOk) {
+				http.Error(w, "Unknown level name", http.StatusBadRequest)
+				return
+			}
+
+			if body.Level != "" {
+				l.SetLevel(level)
+			}
+			if body.FileLevel != "" {
+				l.SetFileLevel(fileLevel)
+			}
+			l.Info("Changed log levels to console=%s file=%s", l.GetLevel(), l.GetFileLevel())
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(levelHandlerBody{
+			Level:     l.GetLevel().String(),
+			FileLevel: l.GetFileLevel().String(),
+		})
+	})
+}
+
+// LevelHandler returns a http handler to read and change the log levels
+// of the global logger at runtime
+func LevelHandler() http.Handler {
+	return dLogger.LevelHandler()
+}
@@ -0,0 +1,206 @@
+package logger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// SinkHealth describes the state of a target
+type SinkHealth struct {
+
+	// Whether the target works as expected. It's false if the target is not
+	// connected or the last write failed
+	Healthy bool
+
+	// Whether the target is connected to its endpoint.
+	// It's always true for targets without a connection
+	Connected bool
+
+	// Last error of the target and when it occurred
+	LastError     error
+	LastErrorTime time.Time
+
+	// Number of entries that are queued and not written yet
+	QueueDepth int
+
+	// Number of entries that were lost, e.g. because a write failed or the queue was full
+	Dropped uint64
+}
+
+// HealthTarget can be implemented by targets to report their state for Logger.Health().
+// Failed calls of Write() are already tracked by the logger, so the reported errors
+// and dropped entries should only include the ones that are not returned by Write()
+type HealthTarget interface {
+	Target
+
+	// Health returns the current state of the target
+	Health() SinkHealth
+}
+
+// HealthStatus records the last error of a target and whether the last operation failed.
+// Targets can use it to implement HealthTarget:
+//
+//	err := send(batch)
+//	t.health.Report(err)
+type HealthStatus struct {
+	mux     sync.Mutex
+	err     error
+	errTime time.Time
+	failing bool
+}
+
+// Report records the result of an operation like sending a batch.
+// A nil error marks the target as healthy again but keeps the last error
+func (s *HealthStatus) Report(err error) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.failing = err != nil
+	if err != nil {
+		s.err = err
+		s.errTime = time.Now()
+	}
+}
+
+// Status returns the health of the target with the recorded error
+func (s *HealthStatus) Status() SinkHealth {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return SinkHealth{
+		Healthy:       !s.failing,
+		Connected:     true,
+		LastError:     s.err,
+		LastErrorTime: s.errTime,
+	}
+}
+
+// healthState tracks the failed writes of the targets of a logger. It's
+// shared with all copies of the logger
+type healthState struct {
+	mux     sync.Mutex
+	targets map[int]*targetHealth
+
+	// Number of targets for which the last write failed. Successful writes
+	// only have to be recorded if this is not zero
+	failing atomic.Int32
+}
+
+// targetHealth contains the failed writes of a single target
+type targetHealth struct {
+	status  HealthStatus
+	dropped uint64
+}
+
+// record records the result of a write to the target with the index (see forEachTarget())
+func (h *healthState) record(index int, err error) {
+	if err == nil && h.failing.Load() == 0 {
+		return
+	}
+
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	target := h.targets[index]
+	if target == nil {
+		if err == nil {
+			return
+		}
+		target = &targetHealth{}
+		h.targets[index] = target
+	}
+
+	if wasFailing := !target.status.Status().Healthy; wasFailing != (err != nil) {
+		if err != nil {
+			h.failing.Add(1)
+		} else {
+			h.failing.Add(-1)
+		}
+	}
+	if err != nil {
+		target.dropped++
+	}
+	target.status.Report(err)
+}
+
+// get returns the recorded health of the target with the index
+func (h *healthState) get(index int) (SinkHealth, uint64, bool) {
+	h.mux.Lock()
+	defer h.mux.Unlock()
+
+	target := h.targets[index]
+	if target == nil {
+		return SinkHealth{}, 0, false
+	}
+
+	return target.status.Status(), target.dropped, true
+}
+
+// Health returns the state of all targets of the logger by their name like "file",
+// "console" or "netlog.Target". Targets of the same type are numbered like "netlog.Target#2".
+// Use it to reflect problems of the logging pipeline in the health check of a service
+func (l *Logger) Health() map[string]SinkHealth {
+	health := make(map[string]SinkHealth)
+	if !l.ensureSetup() {
+		return health
+	}
+	l = l.resolve()
+
+	index := 0
+	l.forEachTarget(func(target Target) bool {
+		status := SinkHealth{Healthy: true, Connected: true}
+		if t, ok := target.(HealthTarget); ok {
+			status = t.Health()
+		}
+
+		if l.health != nil {
+			if recorded, dropped, ok := l.health.get(index); ok {
+				if recorded.LastErrorTime.After(status.LastErrorTime) {
+					status.LastError = recorded.LastError
+					status.LastErrorTime = recorded.LastErrorTime
+				}
+				status.Healthy = status.Healthy && recorded.Healthy
+				status.Dropped += dropped
+			}
+		}
+
+		name := getTargetName(target)
+		for i := 2; ; i++ {
+			if _, exists := health[name]; !exists {
+				break
+			}
+			name = strings.TrimSuffix(name, "#"+strconv.Itoa(i-1)) + "#" + strconv.Itoa(i)
+		}
+		health[name] = status
+
+		index++
+		return true
+	})
+
+	return health
+}
+
+// Health returns the state of all targets of the global logger (see Logger.Health())
+func Health() map[string]SinkHealth {
+	return dLogger.Health()
+}
+
+// getTargetName returns the name of the target for Health()
+func getTargetName(target Target) string {
+	switch target.(type) {
+	case consoleTarget:
+		return "console"
+	case *FileLogger:
+		return "file"
+	case *SyslogLogger:
+		return "syslog"
+	case *JournaldLogger:
+		return "journald"
+	}
+
+	return strings.TrimPrefix(fmt.Sprintf("%T", target), "*")
+}
@@ -0,0 +1,212 @@
+package logger
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Hook is invoked for every entry before it's formatted and written to the targets.
+// The returned entry is used for the further processing, so hooks are able to
+// modify or enrich an entry. To drop an entry return "e.Drop()"
+type Hook func(e Entry) Entry
+
+// Filter decides whether an entry is logged. Entries for which a filter returns false
+// are dropped before they are counted by the sampling and deduplication or formatted
+type Filter func(e Entry) bool
+
+// ErrorHook is invoked after an entry with the level error or higher was written
+// to the targets
+type ErrorHook func(e Entry)
+
+// hooks contains the registered hooks of a logger.
+// It's shared between a logger and all copies of it
+type hooks struct {
+	sync.RWMutex
+
+	hooks      []Hook
+	filters    []Filter
+	errorHooks []ErrorHook
+	fatalHooks []func()
+}
+
+// AddHook registers a hook that is invoked for every entry before it's written.
+// Hooks are called in the order they were added
+func (l *Logger) AddHook(hook Hook) {
+	h := l.getHooks()
+	h.Lock()
+	h.hooks = append(h.hooks, hook)
+	h.Unlock()
+}
+
+// AddFilter registers a filter that is invoked for every entry before it's processed.
+// Use it to suppress known noisy messages without raising the level
+func (l *Logger) AddFilter(filter Filter) {
+	h := l.getHooks()
+	h.Lock()
+	h.filters = append(h.filters, filter)
+	h.Unlock()
+}
+
+// AddErrorHook registers a hook that is invoked after an entry with the
+// level error or higher was written to the targets
+func (l *Logger) AddErrorHook(hook ErrorHook) {
+	h := l.getHooks()
+	h.Lock()
+	h.errorHooks = append(h.errorHooks, hook)
+	h.Unlock()
+}
+
+// OnFatal registers a callback that is invoked after a fatal message was logged and
+// before the program exits. Use this to flush buffers or release resources.
+// The callbacks are invoked in the order they were added
+func (l *Logger) OnFatal(callback func()) {
+	h := l.getHooks()
+	h.Lock()
+	h.fatalHooks = append(h.fatalHooks, callback)
+	h.Unlock()
+}
+
+// AddHook registers a hook for the global logger
+func AddHook(hook Hook) {
+	dLogger.AddHook(hook)
+}
+
+// AddFilter registers a filter for the global logger
+func AddFilter(filter Filter) {
+	dLogger.AddFilter(filter)
+}
+
+// FilterMessageContains returns a filter that drops all entries whose message
+// contains one of the given texts like "GET /health"
+func FilterMessageContains(texts ...string) Filter {
+	return func(e Entry) bool {
+		for _, text := range texts {
+			if strings.Contains(e.Message, text) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AddErrorHook registers an error hook for the global logger
+func AddErrorHook(hook ErrorHook) {
+	dLogger.AddErrorHook(hook)
+}
+
+// OnFatal registers a callback for the global logger that is invoked before the
+// program exits because of a fatal message
+func OnFatal(callback func()) {
+	dLogger.OnFatal(callback)
+}
+
+// Drop marks the entry as dropped. If a hook returns a dropped entry,
+// it's not written to any target
+func (e Entry) Drop() Entry {
+	e.dropped = true
+	return e
+}
+
+// getHooks returns the hooks of the logger and initializes them if required
+func (l *Logger) getHooks() *hooks {
+	// Hooks of a nil logger are never invoked
+	if l == nil {
+		return &hooks{}
+	}
+
+	if l.hooks == nil {
+		l.hooks = &hooks{}
+	}
+
+	return l.hooks
+}
+
+// applyHooks invokes all hooks for the entry and returns the modified entry
+func (l *Logger) applyHooks(e Entry) Entry {
+	if l.hooks == nil {
+		return e
+	}
+
+	l.hooks.RLock()
+	defer l.hooks.RUnlock()
+
+	for _, hook := range l.hooks.hooks {
+		if e = hook(e); e.dropped {
+			break
+		}
+	}
+
+	return e
+}
+
+// applyFilters returns whether all filters accept the entry
+func (l *Logger) applyFilters(e Entry) bool {
+	if l.hooks == nil {
+		return true
+	}
+
+	l.hooks.RLock()
+	defer l.hooks.RUnlock()
+
+	for _, filter := range l.hooks.filters {
+		if !filter(e) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// applyErrorHooks invokes all error hooks for the entry
+func (l *Logger) applyErrorHooks(e Entry) {
+	if l.hooks == nil || e.Level < LevelError {
+		return
+	}
+
+	l.hooks.RLock()
+	defer l.hooks.RUnlock()
+
+	for _, hook := range l.hooks.errorHooks {
+		hook(e)
+	}
+}
+
+// hasErrorHooks returns whether error hooks are registered
+func (l *Logger) hasErrorHooks() bool {
+	if l.hooks == nil {
+		return false
+	}
+
+	l.hooks.RLock()
+	defer l.hooks.RUnlock()
+	return len(l.hooks.errorHooks) > 0
+}
+
+// handleFatal invokes the OnFatal callbacks, closes all targets and exits the program
+// with the configured exit code
+func (l *Logger) handleFatal() {
+	if l.hooks != nil {
+		l.hooks.RLock()
+		callbacks := l.hooks.fatalHooks
+		l.hooks.RUnlock()
+
+		for _, callback := range callbacks {
+			callback()
+		}
+	}
+
+	if l.FatalNoExit {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), fatalShutdownTimeout)
+	l.Shutdown(ctx)
+	cancel()
+
+	if l.FatalExitCode != 0 {
+		os.Exit(l.FatalExitCode)
+	}
+	os.Exit(1)
+}
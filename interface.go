@@ -0,0 +1,52 @@
+package logger
+
+// Interface contains the logging methods of Logger that are needed by most libraries.
+// Libraries can accept it instead of *Logger, so that the application decides
+// where the messages are written to:
+//
+//	type Client struct {
+//		Log logger.Interface
+//	}
+//
+//	client := &Client{Log: logger.Discard}
+//	client.Log = logger.GetGlobalLogger().Named("client")
+//
+// The methods with the suffix "w" add the alternating keys and values as fields (see Logger.Logw())
+type Interface interface {
+	Trace(message string, parameters ...any)
+	Debug(message string, parameters ...any)
+	Info(message string, parameters ...any)
+	Warning(message string, parameters ...any)
+	Error(message string, parameters ...any)
+
+	Tracew(message string, keysAndValues ...any)
+	Debugw(message string, keysAndValues ...any)
+	Infow(message string, keysAndValues ...any)
+	Warningw(message string, keysAndValues ...any)
+	Errorw(message string, keysAndValues ...any)
+
+	// Enabled returns whether messages with the level are written
+	Enabled(level Level) bool
+}
+
+var _ Interface = (*Logger)(nil)
+
+// Discard is an Interface that drops all messages. Use it to silence a library
+var Discard Interface = discard{}
+
+// discard implements Interface without writing any message
+type discard struct{}
+
+func (discard) Trace(message string, parameters ...any)   {}
+func (discard) Debug(message string, parameters ...any)   {}
+func (discard) Info(message string, parameters ...any)    {}
+func (discard) Warning(message string, parameters ...any) {}
+func (discard) Error(message string, parameters ...any)   {}
+
+func (discard) Tracew(message string, keysAndValues ...any)   {}
+func (discard) Debugw(message string, keysAndValues ...any)   {}
+func (discard) Infow(message string, keysAndValues ...any)    {}
+func (discard) Warningw(message string, keysAndValues ...any) {}
+func (discard) Errorw(message string, keysAndValues ...any)   {}
+
+func (discard) Enabled(level Level) bool { return false }
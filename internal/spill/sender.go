@@ -0,0 +1,161 @@
+package spill
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger/retry"
+)
+
+// Sender sends the payloads of a target like the bodies of the requests. Payloads that
+// could not be sent are stored in the queue and sent again before the next payload.
+// After Start() was called, they are also sent periodically in the background, so that
+// the queue is emptied even if no new payloads are delivered
+type Sender struct {
+
+	// Name of the target used for the messages written to stderr like "lokilog"
+	Name string
+
+	// Queue the payloads are stored in. Nothing is stored if it's nil
+	Queue *Queue
+
+	// Policy for retrying a new payload. A single attempt is made if it's nil.
+	// Stored payloads are only tried once per delivery
+	Policy *retry.Policy
+
+	// Sends a single payload. Payloads that fail with a permanent error
+	// (see retry.Permanent()) are not stored.
+	// It's never called concurrently
+	Send func(payload []byte) error
+
+	// Interval in which the stored payloads are sent in the background. Defaults to 30 seconds
+	ReplayInterval time.Duration
+
+	// Serializes the deliveries and the replays in the background
+	mu sync.Mutex
+
+	// Stops the replays in the background
+	stop chan struct{}
+	done chan struct{}
+}
+
+// Start sends the stored payloads periodically in the background until
+// the sender is closed. It does nothing if no queue is set
+func (s *Sender) Start() {
+	if s.Queue == nil || s.stop != nil {
+		return
+	}
+	if s.ReplayInterval <= 0 {
+		s.ReplayInterval = 30 * time.Second
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.replayLoop()
+}
+
+// replayLoop sends the stored payloads in the interval until the sender is closed
+func (s *Sender) replayLoop() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.ReplayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			if s.Queue.Len() > 0 {
+				// Errors are expected while the endpoint is not reachable. They are retried in the next interval
+				s.replay()
+			}
+			s.mu.Unlock()
+		}
+	}
+}
+
+// Deliver sends the stored payloads and the payload afterwards to keep the order.
+// If that fails, the payload is stored in the queue. The error of sending is returned
+// and whether the payload was stored instead
+func (s *Sender) Deliver(payload []byte) (stored bool, err error) {
+	return s.DeliverFunc(payload, func() error {
+		return s.Send(payload)
+	})
+}
+
+// DeliverFunc is like Deliver() but sends the new batch with the given function. The payload
+// is only used for storing the batch. Use it if the decoded payload differs from the batch
+func (s *Sender) DeliverFunc(payload []byte, send func() error) (stored bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Queue != nil && s.Queue.Len() > 0 {
+		if err := s.replay(); err != nil {
+			return s.store(payload), err
+		}
+	}
+
+	if s.Policy != nil {
+		err = s.Policy.Do(context.Background(), send)
+	} else {
+		err = send()
+	}
+	if err != nil && s.Queue != nil && !retry.IsPermanent(err) {
+		stored = s.store(payload)
+	}
+
+	return stored, err
+}
+
+// Len returns the number of stored payloads
+func (s *Sender) Len() int {
+	if s.Queue == nil {
+		return 0
+	}
+	return s.Queue.Len()
+}
+
+// Close stops the replays in the background and closes the queue.
+// The stored payloads are sent after it was opened again
+func (s *Sender) Close() error {
+	if s.Queue == nil {
+		return nil
+	}
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+
+	return s.Queue.Close()
+}
+
+// replay sends the stored payloads. Payloads that are rejected permanently are dropped
+func (s *Sender) replay() error {
+	return s.Queue.Replay(func(payload []byte) error {
+		err := s.Send(payload)
+		if retry.IsPermanent(err) {
+			fmt.Fprintf(os.Stderr, "%s: dropped stored batch: %s\n", s.Name, err)
+			return nil
+		}
+		return err
+	})
+}
+
+// store stores the payload in the queue. It returns whether it was stored
+func (s *Sender) store(payload []byte) bool {
+	dropped, err := s.Queue.Push(payload)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: failed to store batch in the spill queue: %s\n", s.Name, err)
+		return false
+	}
+	if dropped > 0 {
+		fmt.Fprintf(os.Stderr, "%s: spill queue is full, dropped %d batches\n", s.Name, dropped)
+	}
+
+	return true
+}
@@ -0,0 +1,355 @@
+// spill provides a persistent queue on disk. It's used by the network targets to keep
+// the entries while the endpoint is not reachable, so that they survive longer outages
+// and restarts of the program.
+//
+// The records are appended to segment files within a directory. Segments that were
+// replayed completely are removed. The records are delivered at least once: if the
+// program crashes during a replay, already delivered records may be replayed again
+package spill
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// File extension of the segments
+	segmentSuffix = ".seg"
+
+	// Name of the file that contains the read offset of the oldest segment
+	offsetFile = "offset"
+
+	// Records larger than this are treated as corrupted
+	maxRecordSize = 64 * 1024 * 1024
+)
+
+// Options configures the queue
+type Options struct {
+
+	// Directory in which the segments are stored. It's created if it doesn't exist.
+	// The entries may contain sensitive data, so only the owner can access the
+	// directory (0700) and the segments (0600)
+	Dir string
+
+	// Maximum size of all segments in bytes. If it's exceeded, the oldest
+	// segments are removed. Defaults to 100 MB
+	MaxSize int64
+
+	// Size in bytes after which a new segment is started. Defaults to 4 MB
+	SegmentSize int64
+}
+
+// segment is a single file of the queue
+type segment struct {
+	id   uint64
+	size int64
+
+	// Number of records that were not replayed yet
+	records int
+}
+
+// Queue is a persistent queue of records that is stored in append-only segments
+type Queue struct {
+	options Options
+	mu      sync.Mutex
+
+	// Segments ordered from the oldest to the newest
+	segments []*segment
+
+	// Opened newest segment to which the records are appended
+	file *os.File
+
+	// Read offset within the oldest segment
+	offset int64
+
+	// Total size of all segments and number of records not replayed yet
+	size    int64
+	records int
+
+	// ID of the next segment
+	nextID uint64
+}
+
+// Open opens the queue within the directory. Records of a previous run are kept
+// and can be replayed with Replay()
+func Open(options Options) (*Queue, error) {
+	if options.Dir == "" {
+		return nil, errors.New("no directory for the queue given")
+	}
+	if options.MaxSize <= 0 {
+		options.MaxSize = 100 * 1024 * 1024
+	}
+	if options.SegmentSize <= 0 {
+		options.SegmentSize = 4 * 1024 * 1024
+	}
+	if options.SegmentSize > options.MaxSize {
+		options.SegmentSize = options.MaxSize
+	}
+
+	if err := os.MkdirAll(options.Dir, 0700); err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(options.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &Queue{options: options}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		id, err := strconv.ParseUint(strings.TrimSuffix(name, segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		q.segments = append(q.segments, &segment{id: id})
+	}
+	sort.Slice(q.segments, func(i, j int) bool { return q.segments[i].id < q.segments[j].id })
+
+	if len(q.segments) == 0 {
+		return q, nil
+	}
+	q.nextID = q.segments[len(q.segments)-1].id + 1
+
+	// The offset is ignored if it doesn't fit to the oldest segment
+	if content, err := os.ReadFile(filepath.Join(options.Dir, offsetFile)); err == nil {
+		q.offset, _ = strconv.ParseInt(strings.TrimSpace(string(content)), 10, 64)
+		if info, err := os.Stat(q.path(q.segments[0])); err != nil || q.offset < 0 || q.offset > info.Size() {
+			q.offset = 0
+		}
+	}
+
+	for i, seg := range q.segments {
+		offset := int64(0)
+		if i == 0 {
+			offset = q.offset
+		}
+
+		// Count the records. An incomplete record at the end (e.g. after a crash) is removed
+		end, err := scan(q.path(seg), offset, func([]byte) error {
+			seg.records++
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Truncate(q.path(seg), end); err != nil {
+			return nil, err
+		}
+
+		seg.size = end
+		q.size += end
+		q.records += seg.records
+	}
+
+	return q, nil
+}
+
+// Push appends the record to the queue. If the maximum size of the queue is exceeded,
+// the oldest segments are removed. The number of records that were removed is returned
+func (q *Queue) Push(data []byte) (dropped int, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.file == nil || q.segments[len(q.segments)-1].size >= q.options.SegmentSize {
+		if err := q.openSegment(); err != nil {
+			return 0, err
+		}
+	}
+
+	record := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(record, uint32(len(data)))
+	copy(record[4:], data)
+	if _, err := q.file.Write(record); err != nil {
+		return 0, err
+	}
+
+	seg := q.segments[len(q.segments)-1]
+	seg.size += int64(len(record))
+	seg.records++
+	q.size += int64(len(record))
+	q.records++
+
+	for q.size > q.options.MaxSize && len(q.segments) > 1 {
+		dropped += q.segments[0].records
+		if err := q.removeOldest(); err != nil {
+			return dropped, err
+		}
+	}
+
+	return dropped, nil
+}
+
+// Replay passes the records to the function starting with the oldest one. Records
+// for which the function returns nil are removed from the queue. If it returns an error,
+// the replay stops and the error is returned. The record is then replayed again on the next call.
+// Segments that were removed from the directory (e.g. by a cleanup job) are skipped
+func (q *Queue) Replay(fn func(data []byte) error) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.segments) > 0 {
+		seg := q.segments[0]
+		if _, err := os.Stat(q.path(seg)); errors.Is(err, fs.ErrNotExist) {
+			if err := q.removeOldest(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		offset, err := scan(q.path(seg), q.offset, func(data []byte) error {
+			if err := fn(data); err != nil {
+				return err
+			}
+			seg.records--
+			q.records--
+			return nil
+		})
+		q.offset = offset
+
+		if err != nil {
+			q.saveOffset()
+			return err
+		}
+		if err := q.removeOldest(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of records that were not replayed yet
+func (q *Queue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.records
+}
+
+// Size returns the size of all segments in bytes
+func (q *Queue) Size() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.size
+}
+
+// Close closes the opened segment and stores the read offset. The remaining
+// records are replayed after the queue was opened again
+func (q *Queue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var err error
+	if q.file != nil {
+		err = q.file.Close()
+		q.file = nil
+	}
+
+	return errors.Join(err, q.saveOffset())
+}
+
+// openSegment starts a new segment for appending records
+func (q *Queue) openSegment() error {
+	if q.file != nil {
+		q.file.Close()
+		q.file = nil
+	}
+
+	seg := &segment{id: q.nextID}
+	q.nextID++
+
+	file, err := os.OpenFile(q.path(seg), os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	q.file = file
+	q.segments = append(q.segments, seg)
+	return nil
+}
+
+// removeOldest removes the oldest segment from the queue
+func (q *Queue) removeOldest() error {
+	seg := q.segments[0]
+	if len(q.segments) == 1 && q.file != nil {
+		q.file.Close()
+		q.file = nil
+	}
+
+	q.segments = q.segments[1:]
+	q.size -= seg.size
+	q.records -= seg.records
+	q.offset = 0
+
+	err := os.Remove(q.path(seg))
+	if errors.Is(err, fs.ErrNotExist) {
+		err = nil
+	}
+	return errors.Join(err, q.saveOffset())
+}
+
+// saveOffset stores the read offset of the oldest segment
+func (q *Queue) saveOffset() error {
+	path := filepath.Join(q.options.Dir, offsetFile)
+	if q.offset == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	return os.WriteFile(path, []byte(strconv.FormatInt(q.offset, 10)), 0600)
+}
+
+// path returns the path of the segment file
+func (q *Queue) path(seg *segment) string {
+	return filepath.Join(q.options.Dir, fmt.Sprintf("%020d%s", seg.id, segmentSuffix))
+}
+
+// scan reads the records of the file starting at the offset and passes them to the function.
+// It stops at an incomplete record or when the function returns an error. The offset
+// after the last processed record is returned
+func scan(path string, offset int64, fn func(data []byte) error) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return offset, err
+	}
+	defer file.Close()
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return offset, err
+	}
+
+	header := make([]byte, 4)
+	for {
+		if _, err := io.ReadFull(file, header); err != nil {
+			return offset, nil
+		}
+		size := binary.BigEndian.Uint32(header)
+		if size > maxRecordSize {
+			return offset, nil
+		}
+
+		data := make([]byte, size)
+		if _, err := io.ReadFull(file, data); err != nil {
+			return offset, nil
+		}
+		if err := fn(data); err != nil {
+			return offset, err
+		}
+		offset += int64(4 + len(data))
+	}
+}
@@ -0,0 +1,182 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Path of the socket of systemd-journald for the native protocol
+const journaldSocket = "/run/systemd/journal/socket"
+
+// JournaldLogger contains configuration options specific to logging into the
+// systemd journal. The messages are sent via the native journal protocol so
+// that the level, the source and the structured fields are indexed by journald.
+//
+// The journald logger is only active when the program is running under systemd
+// (the environment variable "JOURNAL_STREAM" is set by systemd) and the socket
+// of journald exists. Use "Force" to always enable it
+type JournaldLogger struct {
+
+	// Minimum log level for logging into the journal
+	Level Level
+
+	// Always send the messages to journald even if the program was not started by systemd
+	Force bool
+
+	// Disables the output to the console while logging to journald is active.
+	// Because systemd writes stdout and stderr also into the journal, messages
+	// would otherwise be stored twice
+	ReplaceConsole bool
+
+	// Value of the field "SYSLOG_IDENTIFIER". Defaults to the name of the executable
+	Identifier string
+
+	// Additional fields that are attached to every message. The names have to consist of
+	// upper case letters, digits and underscores
+	Fields map[string]string
+
+	conn     *net.UnixConn
+	addr     *net.UnixAddr
+	connSync *sync.Mutex
+}
+
+// IsJournaldAvailable returns whether the program was started by systemd
+// with its output connected to the journal and the journal socket exists
+func IsJournaldAvailable() bool {
+	if _, isSet := os.LookupEnv("JOURNAL_STREAM"); !isSet {
+		return false
+	}
+
+	_, err := os.Stat(journaldSocket)
+	return err == nil
+}
+
+// isActive returns whether messages are sent to journald
+func (l *JournaldLogger) isActive() bool {
+	return l.conn != nil
+}
+
+// Enabled returns whether logging to journald is active and the level is enabled
+func (l *JournaldLogger) Enabled(level Level) bool {
+	return l.Level.load() <= level && l.isActive()
+}
+
+// NeedsSource returns true while journald is active. The source is sent
+// within the fields CODE_FILE, CODE_LINE and CODE_FUNC
+func (l *JournaldLogger) NeedsSource() bool {
+	return l.isActive()
+}
+
+// Write sends the entry to journald
+func (l *JournaldLogger) Write(e Entry) error {
+	return l.writeToJournald(e)
+}
+
+// Close closes the connection to journald
+func (l *JournaldLogger) Close() (err error) {
+	if l.connSync == nil {
+		return nil
+	}
+
+	l.connSync.Lock()
+	if l.conn != nil {
+		err = l.conn.Close()
+		l.conn = nil
+	}
+	l.connSync.Unlock()
+
+	return
+}
+
+// setup opens the socket to journald if it's available
+func (l *JournaldLogger) setup() {
+	if l.connSync != nil {
+		return
+	}
+	l.connSync = &sync.Mutex{}
+
+	if !l.Force && !IsJournaldAvailable() {
+		return
+	}
+
+	if l.Identifier == "" {
+		l.Identifier = filepath.Base(os.Args[0])
+	}
+
+	l.addr = &net.UnixAddr{Name: journaldSocket, Net: "unixgram"}
+	if conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Net: "unixgram"}); err == nil {
+		l.conn = conn
+	}
+}
+
+// writeToJournald sends the entry to journald
+func (l *JournaldLogger) writeToJournald(e Entry) (err error) {
+	var b bytes.Buffer
+
+	writeJournaldField(&b, "MESSAGE", strings.TrimSpace(e.prefix()+" "+e.Message))
+	writeJournaldField(&b, "PRIORITY", strconv.Itoa(e.Level.getSyslogSeverity()))
+	writeJournaldField(&b, "SYSLOG_IDENTIFIER", l.Identifier)
+	if e.Line > 0 {
+		writeJournaldField(&b, "CODE_FILE", e.File)
+		writeJournaldField(&b, "CODE_LINE", strconv.Itoa(e.Line))
+		if fn := runtime.FuncForPC(e.PC); fn != nil {
+			writeJournaldField(&b, "CODE_FUNC", fn.Name())
+		}
+	}
+
+	for key, value := range l.Fields {
+		writeJournaldField(&b, getJournaldFieldName(key), value)
+	}
+	for _, key := range e.getFieldKeys() {
+		writeJournaldField(&b, getJournaldFieldName(key), fmt.Sprintf("%v", e.Fields[key]))
+	}
+
+	l.connSync.Lock()
+	if l.conn != nil {
+		_, err = l.conn.WriteToUnix(b.Bytes(), l.addr)
+	}
+	l.connSync.Unlock()
+
+	return
+}
+
+// writeJournaldField appends the field in the format of the native journal protocol.
+// Values with line breaks are written in the binary format
+func writeJournaldField(b *bytes.Buffer, name string, value string) {
+	b.WriteString(name)
+	if strings.ContainsRune(value, '\n') {
+		b.WriteByte('\n')
+		binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	} else {
+		b.WriteByte('=')
+	}
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
+
+// getJournaldFieldName converts the key of a structured field to a valid
+// journal field name. It's converted to upper case and invalid characters are
+// replaced with an underscore
+func getJournaldFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+
+	// Field names must not start with an underscore or a digit
+	if len(name) == 0 || name[0] == '_' || (name[0] >= '0' && name[0] <= '9') {
+		return "F_" + string(name)
+	}
+
+	return string(name)
+}
@@ -0,0 +1,61 @@
+package logger
+
+// Lazy is a field value that is only evaluated if the entry is written to a target.
+// Use it for values that are expensive to compute:
+//
+//	l.WithField("state", logger.Lazy(func() any { return dump(state) })).Debug("Current state")
+type Lazy func() any
+
+// LogFunc logs the message returned by the function with the given level.
+// The function is only called if the level is enabled, so expensive
+// message construction is skipped for filtered levels
+func (l *Logger) LogFunc(level Level, fn func() string) {
+	l.logFunc(level, fn)
+}
+
+// LogFunc logs the message returned by the function with the global logger.
+// The function is only called if the level is enabled
+func LogFunc(level Level, fn func() string) {
+	dLogger.logFunc(level, fn)
+}
+
+// logFunc evaluates the message function if the level is enabled and logs the result.
+// Like log(), it has to be called directly by the exported function
+func (l *Logger) logFunc(level Level, fn func() string) {
+	if level != LevelFatal && !l.Enabled(level) {
+		return
+	}
+
+	if e, ok := l.buildEntry(nil, 3, level, fn(), nil); ok {
+		l.write(e, false)
+	}
+}
+
+// hasLazyValue returns whether one of the values is a Lazy value
+func hasLazyValue(fields map[string]any) bool {
+	for _, value := range fields {
+		if _, ok := value.(Lazy); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolveLazyFields returns the fields with all Lazy values evaluated.
+// If no value is lazy, the fields are returned unchanged
+func resolveLazyFields(fields map[string]any) map[string]any {
+	if !hasLazyValue(fields) {
+		return fields
+	}
+
+	rtc := make(map[string]any, len(fields))
+	for key, value := range fields {
+		if lazy, ok := value.(Lazy); ok {
+			value = lazy()
+		}
+		rtc[key] = value
+	}
+
+	return rtc
+}
@@ -1,21 +1,141 @@
 package logger
 
-import "strings"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode/utf8"
+)
 
-// Level of the log message
-type Level uint8
+// Level of the log message.
+// The underlying type is an uint32 so that the level can be accessed atomically.
+// The predefined levels are spaced by ten so that custom levels can be
+// registered between them with RegisterLevel()
+type Level uint32
 
 const (
-	LevelTrace Level = iota
+	LevelTrace Level = iota * 10
 	LevelDebug
 	LevelInfo
 	LevelWarning
 	LevelError
+	LevelPanic
 	LevelFatal
 )
 
+// LevelOff disables all messages when it's used as the level of the logger
+// or a target, e.g. to disable the console through the configuration.
+// It's not meant to be used for messages
+const LevelOff Level = math.MaxUint32
+
+// LevelDefinition describes a custom level that is registered with RegisterLevel()
+type LevelDefinition struct {
+
+	// Name of the level like "NOTICE". It's printed in the messages and
+	// used to parse the level from the configuration (case-insensitive)
+	Name string
+
+	// Name with three letters that is used for the option "Short" of
+	// LevelLabelConfig. Defaults to the first three letters of the name
+	ShortName string
+
+	// ANSI escape sequence of the color on the console like "\033[1;36m".
+	// Defaults to the color of the next lower predefined level
+	Color string
+}
+
+// customLevels contains the levels registered with RegisterLevel()
+var (
+	customLevels    = make(map[Level]LevelDefinition)
+	customLevelsMux sync.RWMutex
+)
+
+// predefinedLevels contains the predefined levels in ascending order
+var predefinedLevels = []Level{LevelTrace, LevelDebug, LevelInfo, LevelWarning, LevelError, LevelPanic, LevelFatal}
+
+// RegisterLevel registers a custom level like "NOTICE" between LevelInfo and LevelWarning:
+//
+//	const LevelNotice = logger.LevelInfo + 5
+//	logger.RegisterLevel(LevelNotice, logger.LevelDefinition{Name: "NOTICE"})
+//	logger.Log(LevelNotice, "User %s signed in", user)
+//
+// The level is ordered by its value, so it's filtered like the predefined levels.
+// Targets that map the levels to their own severities (like syslog) use the
+// severity of the next lower predefined level. Custom levels never panic or exit the program.
+// The levels should be registered before the loggers are created
+func RegisterLevel(level Level, definition LevelDefinition) error {
+	if definition.Name == "" {
+		return errors.New("no name for the level given")
+	}
+	for _, predefined := range append(predefinedLevels, LevelOff) {
+		if level == predefined {
+			return fmt.Errorf("the level %d is already used by %s", level, predefined)
+		}
+	}
+	if known, ok := parseLevel(definition.Name); ok && known != level {
+		return fmt.Errorf("the level name %q is already used", definition.Name)
+	}
+
+	if definition.ShortName == "" {
+		definition.ShortName = strings.ToUpper(definition.Name)
+		if runes := []rune(definition.ShortName); len(runes) > 3 {
+			definition.ShortName = string(runes[:3])
+		}
+	}
+
+	customLevelsMux.Lock()
+	customLevels[level] = definition
+	customLevelsMux.Unlock()
+
+	return nil
+}
+
+// Levels returns the predefined and the registered custom levels in ascending order
+func Levels() []Level {
+	levels := append([]Level{}, predefinedLevels...)
+
+	customLevelsMux.RLock()
+	for level := range customLevels {
+		levels = append(levels, level)
+	}
+	customLevelsMux.RUnlock()
+
+	sort.Slice(levels, func(i, j int) bool { return levels[i] < levels[j] })
+	return levels
+}
+
+// getCustomLevel returns the definition of the custom level
+func getCustomLevel(lvl Level) (LevelDefinition, bool) {
+	customLevelsMux.RLock()
+	defer customLevelsMux.RUnlock()
+
+	definition, ok := customLevels[lvl]
+	return definition, ok
+}
+
+// predefined returns the next lower predefined level. It's used to map
+// custom levels to the properties of the predefined levels
+func (lvl Level) predefined() Level {
+	for i := len(predefinedLevels) - 1; i > 0; i-- {
+		if lvl >= predefinedLevels[i] {
+			return predefinedLevels[i]
+		}
+	}
+
+	return LevelTrace
+}
+
 // String returns a string expression of the level
 func (lvl Level) String() string {
+	if definition, ok := getCustomLevel(lvl); ok {
+		return definition.Name
+	}
+
 	switch lvl {
 	case LevelTrace:
 		return "TRACE"
@@ -27,36 +147,200 @@ func (lvl Level) String() string {
 		return "WARN"
 	case LevelError:
 		return "ERROR"
+	case LevelPanic:
+		return "PANIC"
 	case LevelFatal:
 		return "FATAL"
+	case LevelOff:
+		return "OFF"
+	}
+
+	// Unknown levels are printed with their value, so that they can be parsed again
+	return fmt.Sprintf("LEVEL(%d)", uint32(lvl))
+}
+
+// shortName returns the name of the level with three letters like "WRN".
+// For unknown levels the full name like "LEVEL(25)" is returned
+func (lvl Level) shortName() string {
+	if definition, ok := getCustomLevel(lvl); ok {
+		return definition.ShortName
+	}
+
+	switch lvl {
+	case LevelTrace:
+		return "TRC"
+	case LevelDebug:
+		return "DBG"
+	case LevelInfo:
+		return "INF"
+	case LevelWarning:
+		return "WRN"
+	case LevelError:
+		return "ERR"
+	case LevelPanic:
+		return "PNC"
+	case LevelFatal:
+		return "FTL"
+	case LevelOff:
+		return "OFF"
+	}
+
+	return lvl.String()
+}
+
+// LevelLabelConfig customizes the label of the level that is printed
+// at the start of the messages in the text format like "[INFO ]"
+type LevelLabelConfig struct {
+
+	// Use the short names with three letters like "WRN" and "ERR"
+	Short bool
+
+	// Print the names in lower case like "warn"
+	Lowercase bool
+
+	// Don't pad the labels with spaces to the width of the longest label
+	NoPadding bool
+
+	// Labels that replace the names of the levels like {LevelWarning: "WARNING"}.
+	// They are not changed by "Short" and "Lowercase"
+	Labels map[Level]string
+}
+
+// getLabels returns the labels for all levels including the registered custom levels
+func (c *LevelLabelConfig) getLabels() map[Level]string {
+	labels := make(map[Level]string)
+	width := 0
+	for _, level := range Levels() {
+		if label, ok := c.Labels[level]; ok {
+			labels[level] = label
+		} else {
+			labels[level] = level.String()
+			if c.Short {
+				labels[level] = level.shortName()
+			}
+			if c.Lowercase {
+				labels[level] = strings.ToLower(labels[level])
+			}
+		}
+
+		width = max(width, utf8.RuneCountInString(labels[level]))
+	}
+
+	if !c.NoPadding {
+		for level, label := range labels {
+			labels[level] = label + strings.Repeat(" ", width-utf8.RuneCountInString(label))
+		}
 	}
 
-	return "DEBUG"
+	return labels
 }
 
 // GetLevelByName tries to convert the given level name to the represented level code.
-// Allowed values are: 'trace', 'debug', 'info', 'warn', 'warning', 'error', 'panic' and 'fatal'
-// If an incorrect level name was given a warning is logged and info will be returned
+// Allowed values are: 'trace', 'debug', 'info', 'warn', 'warning', 'error', 'panic', 'fatal',
+// 'off' or 'none' (see LevelOff) and the names of the custom levels registered with RegisterLevel().
+// If an incorrect level name was given a warning is logged and info will be returned.
+// Use ParseLevel() to handle invalid names yourself
 func GetLevelByName(levelName string) Level {
-	levelName = strings.ToLower(levelName)
-	switch levelName {
+	level, err := ParseLevel(levelName)
+	if err != nil {
+		Warning("%s", err)
+		return LevelInfo
+	}
+
+	return level
+}
+
+// ParseLevel converts the given level name to the represented level code like GetLevelByName().
+// An error is returned if the name is unknown
+func ParseLevel(levelName string) (Level, error) {
+	level, ok := parseLevel(levelName)
+	if !ok {
+		names := make([]string, 0, len(predefinedLevels)+1)
+		for _, level := range append(Levels(), LevelOff) {
+			names = append(names, "'"+strings.ToLower(level.String())+"'")
+		}
+		return LevelInfo, fmt.Errorf("unknown level %q. Expected one of %s", levelName, strings.Join(names, ", "))
+	}
+
+	return level, nil
+}
+
+// parseLevel converts the given level name to the represented level code.
+// The returned boolean is false if the name is unknown
+func parseLevel(levelName string) (Level, bool) {
+	name := strings.ToLower(levelName)
+	switch name {
 	case "trace":
-		return LevelTrace
+		return LevelTrace, true
 	case "debug":
-		return LevelDebug
+		return LevelDebug, true
 	case "info":
-		return LevelInfo
+		return LevelInfo, true
 	case "warn", "warning":
-		return LevelWarning
+		return LevelWarning, true
 	case "error":
-		return LevelError
-	case "panic", "fatal":
-		return LevelFatal
+		return LevelError, true
+	case "panic":
+		return LevelPanic, true
+	case "fatal":
+		return LevelFatal, true
+	case "off", "none":
+		return LevelOff, true
+	}
+
+	// Levels without a name like "level(25)" (see Level.String())
+	if value, ok := strings.CutPrefix(name, "level("); ok && strings.HasSuffix(value, ")") {
+		if level, err := strconv.ParseUint(strings.TrimSuffix(value, ")"), 10, 32); err == nil {
+			return Level(level), true
+		}
+	}
 
-	default:
-		{
-			Warning("Unable to parse the level name '%s'. Expected 'debug', 'info', 'warn', 'error' or 'fatal'", levelName)
-			return LevelWarning
+	customLevelsMux.RLock()
+	defer customLevelsMux.RUnlock()
+	for level, definition := range customLevels {
+		if strings.EqualFold(definition.Name, levelName) {
+			return level, true
 		}
 	}
+
+	return LevelWarning, false
+}
+
+// MarshalText returns the name of the level in lower case like "warn".
+// It's used by encoding/json and most other encoders, so that the level can be
+// used directly in configuration structs and with flag.TextVar()
+func (lvl Level) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(lvl.String())), nil
+}
+
+// UnmarshalText parses the name of the level like ParseLevel()
+func (lvl *Level) UnmarshalText(text []byte) error {
+	level, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+
+	*lvl = level
+	return nil
+}
+
+// UnmarshalYAML parses the name of the level for YAML decoders that
+// don't use UnmarshalText() like gopkg.in/yaml.v2
+func (lvl *Level) UnmarshalYAML(unmarshal func(any) error) error {
+	var name string
+	if err := unmarshal(&name); err != nil {
+		return err
+	}
+
+	return lvl.UnmarshalText([]byte(name))
+}
+
+// load reads the level atomically
+func (lvl *Level) load() Level {
+	return Level(atomic.LoadUint32((*uint32)(lvl)))
+}
+
+// store sets the level atomically
+func (lvl *Level) store(level Level) {
+	atomic.StoreUint32((*uint32)(lvl), uint32(level))
 }
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogfmtCustomLevel(t *testing.T) {
+	const levelNotice = LevelInfo + 3
+	if err := RegisterLevel(levelNotice, LevelDefinition{Name: "Notice_1"}); err != nil {
+		t.Fatalf("failed to register the level: %s", err)
+	}
+
+	var out bytes.Buffer
+	l := NewLogger(&Logger{
+		Level:         LevelTrace,
+		Format:        FormatLogfmt,
+		ConsoleOutput: &out,
+		File:          &FileLogger{},
+	})
+	l.Log(levelNotice, "custom level")
+
+	if !strings.HasPrefix(out.String(), "level=notice_1 ") {
+		t.Fatalf("unexpected level in %q", out.String())
+	}
+}
+
+func TestUnknownLevelRoundTrip(t *testing.T) {
+	const level = LevelInfo + 5
+	text, err := level.MarshalText()
+	if err != nil || string(text) != "level(25)" {
+		t.Fatalf("unexpected text of the level: %q (%v)", text, err)
+	}
+
+	var parsed Level
+	if err := parsed.UnmarshalText(text); err != nil || parsed != level {
+		t.Fatalf("expected %d after parsing %q, got %d (%v)", level, text, parsed, err)
+	}
+}
@@ -0,0 +1,94 @@
+package logger
+
+import "regexp"
+
+// LevelRule changes the level of entries that match a pattern. Use it to reclassify
+// noise of third party libraries like "context canceled" errors as debug messages.
+//
+// The rules are applied before the level of the logger is checked, so they can also raise
+// the level of messages that are disabled otherwise (like debug messages of a library that
+// indicate a real error). In this case the messages of the disabled levels are formatted to
+// match them against the patterns. Fatal messages are never changed
+type LevelRule struct {
+
+	// Regular expression that is matched against the message and the values
+	// of fields that contain an error
+	Pattern string
+
+	// New level of the matching entries
+	Level Level
+}
+
+// levelRules contains the compiled rules of the option "LevelRules"
+type levelRules struct {
+
+	// Rules the compiled rules were created from
+	rules []LevelRule
+
+	patterns []*regexp.Regexp
+	levels   []Level
+}
+
+// newLevelRules compiles the rules. Invalid patterns are logged with the given logger
+func newLevelRules(rules []LevelRule, l *Logger) *levelRules {
+	r := &levelRules{rules: rules}
+	for _, rule := range rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			l.Log(LevelError, "Invalid pattern '%s' of the level rule\n%s", rule.Pattern, err)
+			continue
+		}
+		r.patterns = append(r.patterns, pattern)
+		r.levels = append(r.levels, rule.Level)
+	}
+
+	return r
+}
+
+// canEnable returns whether a rule changes the level of entries to a level
+// that is enabled for the logger
+func (r *levelRules) canEnable(l *Logger) bool {
+	for _, level := range r.levels {
+		if l.Enabled(level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isFor returns whether the compiled rules were created from the given rules
+func (r *levelRules) isFor(rules []LevelRule) bool {
+	return len(r.rules) == len(rules) && (len(rules) == 0 || &r.rules[0] == &rules[0])
+}
+
+// apply returns the entry with the level of the first matching rule
+func (r *levelRules) apply(e Entry) Entry {
+	if e.Level == LevelFatal {
+		return e
+	}
+
+	for i, pattern := range r.patterns {
+		if r.matches(pattern, e) {
+			e.Level = r.levels[i]
+			return e
+		}
+	}
+
+	return e
+}
+
+// matches returns whether the message or an error of the fields matches the pattern
+func (r *levelRules) matches(pattern *regexp.Regexp, e Entry) bool {
+	if pattern.MatchString(e.Message) {
+		return true
+	}
+
+	for _, value := range e.Fields {
+		if err, ok := value.(error); ok && err != nil && pattern.MatchString(err.Error()) {
+			return true
+		}
+	}
+
+	return false
+}
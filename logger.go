@@ -1,31 +1,84 @@
 // logger provides basic logging support for your application.
-// Supported log destinations are the console and a log file
+// Supported log destinations are the console, a log file, syslog and the systemd journal.
+// Additional destinations can be added by implementing the interface Target
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Logger writes the messages to the console, the log file and further targets.
+// Create it with NewLogger(). A logger that was not created with it (like a zero
+// value "Logger{}") is set up on the first use. All methods of a nil logger are
+// no-ops, so that libraries can accept an optional logger without checking for nil
 type Logger struct {
 
 	// Minimum log level for printing to the console (stdout and stderr)
 	Level Level
 
+	// Defines which messages are written to stderr and which to stdout.
+	// Defaults to RouteErrorsToStderr
+	ConsoleRouting ConsoleRouting
+
+	// Writer used instead of stdout for the console messages like a text widget of a GUI,
+	// a websocket broadcaster or "io.Discard" in tests.
+	// The writes are serialized, so the writer doesn't have to be safe for concurrent use.
+	// Colors are only enabled for writers that are a terminal (*os.File) unless forced (see ColoredOutput)
+	ConsoleOutput io.Writer
+
+	// Writer used instead of stderr for the console messages (see ConsoleRouting and ConsoleOutput)
+	ErrorOutput io.Writer
+
 	// Colorizes the log messages for the console.
 	// Even if you set this to true the user is able to overwrite this behaviour by
-	// setting the environment variables "TERMINAL_DISABLE_COLORS" and
-	// "TERMINAL_ENABLE_COLORS" (to force coloring for "unsupported" terminals)
+	// setting the environment variables "NO_COLOR" or "TERMINAL_DISABLE_COLORS" and
+	// "CLICOLOR_FORCE", "FORCE_COLOR" or "TERMINAL_ENABLE_COLORS" (to force coloring
+	// for "unsupported" terminals). "CLICOLOR=0" disables colors too
 	ColoredOutput bool
 
-	// Whether to print the file and line number of the invoking (calling line)
+	// Defines which parts of the text messages are colored. Defaults to ColorStyleDefault
+	ColorStyle ColorStyle
+
+	// Whether to print the file and line number of the invoking (calling line).
+	// If disabled, the source is only determined if a target requires it (see SourceTarget)
 	PrintSource bool
 
+	// Format of the source printed with "PrintSource" like the function name
+	// before the file. Defaults to SourceFormatFile
+	SourceFormat SourceFormat
+
+	// Defines how the path of the source file is printed like the path relative to
+	// the module root. Defaults to SourcePathShort (only the file name)
+	SourcePathMode SourcePathMode
+
+	// Prefix that is removed from the path of the source file for SourcePathTrimPrefix
+	SourcePathPrefix string
+
+	// Add the ID of the goroutine, the process ID and the hostname to every message
+	// as the fields "goroutine", "pid" and "host". This helps to correlate messages
+	// of multiple processes that write to the same file
+	PrintGoroutineID bool
+	PrintPID         bool
+	PrintHostname    bool
+
+	// Add the time since the creation of the logger as the field "uptime" to every message.
+	// The duration is measured with the monotonic clock, so it's also reliable on systems
+	// without a correct wall clock. The start can be reset with ResetUptime()
+	PrintUptime bool
+
+	// Level of the messages logged by TimeOperation() and TraceSpan(). Defaults to LevelTrace
+	TimingLevel Level
+
 	// Only print the log message without any additional info. This property will ignore other options linke
 	// PrintSource or FuncCallIncrement
 	OnlyPrintMessage bool
@@ -34,20 +87,175 @@ type Logger struct {
 	// invoking (calling) line can be printed out.
 	// This defines an offset that is applied to the call stack.
 	// If you are using an own wrapper function, you
-	// have to set this value to one
+	// have to set this value to one. Prefer WithCallerSkip() to
+	// derive a logger for a wrapper
 	FuncCallIncrement int
 
+	// Format of the messages printed to the console. Defaults to FormatText
+	Format Format
+
+	// Formatter used to render the messages printed to the console.
+	// If set, the option "Format" is ignored
+	Formatter Formatter
+
+	// Layout of the timestamp as used by "time.Format()". Additionally, the values
+	// TimeFormatUnix and TimeFormatUnixMilli are supported for epoch timestamps.
+	// Defaults to "2006-01-02 15:04:05" for the text format and RFC3339 for JSON
+	TimeFormat string
+
+	// Fractional seconds of the default layouts and of TimeFormatUnix. Use a higher
+	// precision to order entries that are logged within the same second.
+	// Custom layouts of "TimeFormat" contain the fractional seconds themselves
+	TimePrecision TimePrecision
+
+	// Customizes the label of the level in the text format like "[INFO ]".
+	// Changes after the logger was created are not applied
+	LevelLabels *LevelLabelConfig
+
+	// Print the timestamps in UTC instead of the local time zone
+	UseUTC bool
+
 	// Prefix is applied as a prefix for all log messages.
 	// It's positioned after all other information:
 	//  [INFO ] 2024-04-10 19:00:00 (file:1)PREFIX - Message
+	//
+	// The prefix can contain placeholders that are replaced for every message: "{goroutine}"
+	// is the ID of the goroutine, "{logger}" the name of the logger and all other names like
+	// "{request_id}" are the values of the fields (including the fields of the context
+	// extractors). Missing fields are replaced by "-"
 	Prefix string
 
+	// Function that returns the prefix for every message. It replaces "Prefix" if set
+	PrefixFunc func(e Entry) string
+
+	// Derive the prefix from the package of the caller like " db:" if no "Prefix" is set.
+	// The name of the component is the last element of the package path or the name
+	// configured in "Components"
+	ComponentPrefix bool
+
+	// Names of the components by their package path like "example.com/app/internal/storage": "db".
+	// A package path also matches all packages below it. The longest match is used
+	Components map[string]string
+
+	// Minimum level for which the stack trace of the goroutine is appended to the messages.
+	// In structured formats it's added as the field "stacktrace". Defaults to LevelFatal if nil
+	StacktraceLevel *Level
+
+	// Function that is called when a target fails to write a message. The error is of
+	// the type *WriteError. It may be called concurrently and from the background
+	// goroutine of "Async", so it must not log with this logger again.
+	// The errors are ignored if this is nil
+	ErrorHandler func(err error)
+
+	// Exit code that is used when a fatal message was logged. Defaults to 1
+	FatalExitCode int
+
+	// Don't exit the program when a fatal message was logged. The registered OnFatal
+	// callbacks are still invoked, but the targets are not closed
+	FatalNoExit bool
+
+	// Throttles messages that are logged repeatedly.
+	// Sampling is disabled if this is nil
+	Sampling *SamplingConfig
+
+	// Rules that change the level of messages matching a pattern. The first matching rule is used
+	LevelRules []LevelRule
+
+	// Masks secrets like passwords and tokens in the messages and the fields
+	// before they are written. Redaction is disabled if this is nil
+	Redaction *RedactionConfig
+
+	// Identical consecutive messages logged within this duration are collapsed into a
+	// single message "Last message repeated N times". A value <= 0 disables the deduplication
+	DedupWindow time.Duration
+
+	// Writes the messages to the targets in a background goroutine so that
+	// slow targets don't block the logging calls. It's disabled if this is nil
+	Async *AsyncConfig
+
 	// Configuration options for logging into a file
 	File *FileLogger
 
-	colorConf        colorConfig
-	consoleLogger    *log.Logger
-	consoleLoggerErr *log.Logger
+	// Configuration options for logging to a syslog daemon.
+	// Logging to syslog is disabled if this is nil
+	Syslog *SyslogLogger
+
+	// Configuration options for logging into the systemd journal.
+	// Logging to journald is disabled if this is nil
+	Journald *JournaldLogger
+
+	// Additional destinations to which the log messages are written.
+	// They are written to in addition to the console and the configured file, syslog and journald loggers
+	Targets []Target
+
+	// Key/value pairs that describe the application like the service name, version,
+	// environment or commit. They are added to every message of the structured formats
+	// (JSON, logfmt and GELF) but not to the text format.
+	// Changes after the logger was created are not applied
+	StaticFields map[string]any
+
+	// Structured key/value pairs that are appended to every message.
+	// Use WithField() or WithFields() to add fields
+	fields map[string]any
+
+	// Whether the fields contain a Lazy value that has to be evaluated
+	lazyFields bool
+
+	// Prefix of the keys of fields added via WithFields() (the opened groups
+	// separated by a dot like "http.request.")
+	group string
+
+	// Hierarchical name of the logger separated by dots like "api.handlers".
+	// Use Named() to create a named child logger
+	name string
+
+	// Registered hooks that are shared with all copies of this logger
+	hooks *hooks
+
+	// Sampler for the configuration "Sampling" shared with all copies of this logger
+	sampler *sampler
+
+	// Deduplicator for the configuration "DedupWindow" shared with all copies of this logger
+	dedup *deduplicator
+
+	// Background writer for the configuration "Async" shared with all copies of this logger
+	async *asyncWriter
+
+	// Compiled configuration "Redaction"
+	redactor *redactor
+
+	// Compiled configuration "LevelRules"
+	levelRules *levelRules
+
+	// State of Shutdown() shared with all copies of this logger
+	shutdown *shutdownState
+
+	// Failed writes of the targets for Health() shared with all copies of this logger
+	health *healthState
+
+	// Logger that replaced this logger after a reload of the configuration file
+	// (see WatchConfigFile()) shared with all copies of this logger
+	reload *reloadState
+
+	// Hostname for "PrintHostname"
+	hostname string
+
+	// Start of the duration for "PrintUptime" shared with all copies of this logger
+	uptimeStart *atomic.Pointer[time.Time]
+
+	// Sorted keys of "StaticFields"
+	staticKeys []string
+
+	// Labels of "LevelLabels" by the level
+	levelLabels map[Level]string
+
+	colorConf   colorConfig
+	consoleOut  io.Writer
+	consoleErr  io.Writer
+	consoleSync *sync.Mutex
+
+	// Whether setup() was called. It's accessed atomically (see ensureSetup())
+	initialized uint32
 }
 
 // Globally available logging instance. This will be uesed if log functions
@@ -80,11 +288,22 @@ func NewLogger(logger *Logger) *Logger {
 // the old file reference of the other logger will be used internal.
 // This enables you to write to the same file with different log configurations.
 func NewLoggerWithFile(logger *Logger, file *Logger) *Logger {
+	file.ensureSetup()
+	if logger.File == nil {
+		logger.File = &FileLogger{}
+	}
+
 	logger.File.file = file.File.file
 	logger.File.Path = file.File.Path
 	logger.File.logger = file.File.logger
 	logger.File.fileSync = file.File.fileSync
 	logger.File.fileSyncWrite = file.File.fileSyncWrite
+	logger.File.fileSize = file.File.fileSize
+	logger.File.buffer = file.File.buffer
+	logger.File.bufferSync = file.File.bufferSync
+	logger.File.flushStop = file.File.flushStop
+	logger.File.cipher = file.File.cipher
+	logger.File.shared = true
 
 	logger.setup(true)
 	return logger
@@ -94,6 +313,10 @@ func NewLoggerWithFile(logger *Logger, file *Logger) *Logger {
 // file reference.
 // All configuration options are cloned from "logger" to the new one
 func CloneLogger(logger *Logger) *Logger {
+	if !logger.ensureSetup() {
+		return nil
+	}
+
 	// Copy by dereference the pointer
 	copyIn := *logger
 	copy := &copyIn
@@ -101,109 +324,518 @@ func CloneLogger(logger *Logger) *Logger {
 	file := &fileIn
 
 	copy.File = file
-	copy.consoleLogger = nil
-	copy.consoleLoggerErr = nil
+	copy.consoleOut = nil
+	copy.consoleErr = nil
 
 	return NewLoggerWithFile(copy, logger)
 }
 
+// WithField returns a copy of the logger with the given key/value pair
+// attached to every message that is logged with it
+func (l *Logger) WithField(key string, value any) *Logger {
+	return l.WithFields(map[string]any{key: value})
+}
+
+// WithFields returns a copy of the logger with the given key/value pairs
+// attached to every message that is logged with it.
+// The file reference and configuration are shared with the original logger
+func (l *Logger) WithFields(fields map[string]any) *Logger {
+	if !l.ensureSetup() {
+		return nil
+	}
+
+	copy := *l
+	copy.fields = make(map[string]any, len(l.fields)+len(fields))
+	for key, value := range l.fields {
+		copy.fields[key] = value
+	}
+	for key, value := range fields {
+		copy.fields[l.group+key] = value
+	}
+	copy.lazyFields = l.lazyFields || hasLazyValue(fields)
+
+	return &copy
+}
+
+// WithGroup returns a copy of the logger that prefixes the keys of all fields
+// added afterwards with the name of the group. Groups can be nested:
+//
+//	l.WithGroup("http").WithGroup("request").WithField("method", "GET") // http.request.method=GET
+//
+// Fields that were added before are not changed
+func (l *Logger) WithGroup(name string) *Logger {
+	if !l.ensureSetup() {
+		return nil
+	}
+
+	copy := *l
+	if name != "" {
+		copy.group = l.group + name + "."
+	}
+
+	return &copy
+}
+
+// WithCallerSkip returns a copy of the logger that skips n additional stack frames when
+// determining the source of the messages. Use it for own wrapper functions around
+// the logger, so that the caller of the wrapper is printed instead of the wrapper:
+//
+//	var log = logger.WithCallerSkip(1)
+//
+//	func logRequestError(err error) {
+//		log.Error("Request failed: %s", err)
+//	}
+//
+// The skip is added to "FuncCallIncrement" of the logger
+func (l *Logger) WithCallerSkip(n int) *Logger {
+	if !l.ensureSetup() {
+		return nil
+	}
+
+	copy := *l
+	copy.FuncCallIncrement += n
+
+	return &copy
+}
+
+// SetLevel changes the minimum log level for printing to the console.
+// In contrast to setting the field "Level" directly, this method is safe to call
+// while other goroutines are logging
+func (l *Logger) SetLevel(level Level) {
+	if l != nil {
+		l.resolve().Level.store(level)
+	}
+}
+
+// GetLevel returns the minimum log level for printing to the console.
+// LevelOff is returned for a nil logger
+func (l *Logger) GetLevel() Level {
+	if l == nil {
+		return LevelOff
+	}
+	return l.resolve().Level.load()
+}
+
+// SetFileLevel changes the minimum log level for logging into the file.
+// This method is safe to call while other goroutines are logging
+func (l *Logger) SetFileLevel(level Level) {
+	if l.ensureSetup() {
+		l.resolve().File.SetLevel(level)
+	}
+}
+
+// GetFileLevel returns the minimum log level for logging into the file.
+// LevelOff is returned for a nil logger
+func (l *Logger) GetFileLevel() Level {
+	if !l.ensureSetup() {
+		return LevelOff
+	}
+	return l.resolve().File.GetLevel()
+}
+
+// ResetUptime restarts the duration of the field "uptime" (see PrintUptime).
+// Use it like a stopwatch to measure the steps of a startup sequence
+func (l *Logger) ResetUptime() {
+	if l.ensureSetup() {
+		now := time.Now()
+		l.uptimeStart.Store(&now)
+	}
+}
+
+// Named creates a child logger with the given name. The name is appended to the name of
+// this logger separated by a dot ("api" → "api.handlers") and rendered in every message.
+// The child inherits the configuration and the file reference of this logger. Its levels
+// can be changed independently of the parent.
+// Module levels registered via SetModuleLevel() are also matched against the name
+// and its parents
+func (l *Logger) Named(name string) *Logger {
+	if l == nil {
+		return nil
+	}
+
+	child := CloneLogger(l)
+	if l.name != "" && name != "" {
+		child.name = l.name + "." + name
+	} else {
+		child.name = l.name + name
+	}
+
+	return child
+}
+
+// Name returns the hierarchical name of the logger
+func (l *Logger) Name() string {
+	if l == nil {
+		return ""
+	}
+	return l.name
+}
+
 // Log logs a message with the given level. As additional parameters you can specify
 // replace values for the message. See "fmt.printf()" for more infos.
 func (l *Logger) Log(level Level, message string, parameters ...any) {
-	// This function is needed that "runtime.Caller(2)" is always correct (even on direct call)
-	l.log(level, message, parameters...)
+	l.log(nil, level, message, parameters...)
+}
+
+// log builds the entry for the message and writes it to the targets.
+// The context is optional and can be nil.
+// To determine the source correctly, it has to be called directly by the exported
+// function that is called by the user (like Info() or Log())
+func (l *Logger) log(ctx context.Context, level Level, message string, parameters ...any) {
+	if e, ok := l.buildEntry(ctx, 3, level, message, parameters); ok {
+		l.write(e, false)
+	}
 }
 
-func (l *Logger) log(level Level, message string, parameters ...any) {
-	pc, file, line, ok := runtime.Caller(3 + l.FuncCallIncrement)
-	if !ok {
-		file = "#unknown"
-		line = 0
+// buildEntry builds the entry for the message. The skip has the same meaning as for runtime.Caller()
+// and is used to determine the source. False is returned if no target would write the entry.
+// In this case the message is not even formatted. The source is only determined if it's
+// needed (see needsSource())
+func (l *Logger) buildEntry(ctx context.Context, skip int, level Level, message string, parameters []any) (Entry, bool) {
+	if !l.ensureSetup() {
+		return Entry{}, false
 	}
 
-	// Get the name of the level to log
-	var levelName = fmt.Sprintf("%-5s", level)
+	// The options are used from the logger of the reloaded configuration
+	options := l.resolve()
+
+	// Fatal entries are always processed because the program has to exit.
+	// Entries of disabled levels are only built if a level rule could raise their level
+	enabled := level == LevelFatal || l.Enabled(level)
+	if !enabled && (options.levelRules == nil || !options.levelRules.canEnable(l)) {
+		return Entry{}, false
+	}
 
 	// Build the message to print
-	printMessage := message
+	e := Entry{
+		Time:       time.Now(),
+		Level:      level,
+		Message:    message,
+		Fields:     l.fields,
+		LoggerName: l.name,
+		logger:     options,
+		template:   message,
+	}
+	if options.needsSource() {
+		var ok bool
+		if e.PC, e.File, e.Line, ok = runtime.Caller(skip + l.FuncCallIncrement); !ok {
+			e.File = "#unknown"
+			e.Line = 0
+		}
+	}
+	if options.needsGoroutineID() {
+		e.GoroutineID = getGoroutineID()
+	}
+	if options.stacktraceEnabled(level) {
+		e.Stack = captureStack(skip+l.FuncCallIncrement, 0)
+	}
 	if len(parameters) > 0 {
-		printMessage = fmt.Sprintf(message, parameters...)
+		e.Message = fmt.Sprintf(message, parameters...)
+	}
+	if ctx != nil {
+		e.Fields = addContextFields(ctx, e.Fields)
 	}
-	if !l.OnlyPrintMessage {
-		printMessage = "[" + levelName + "] " + time.Now().Local().Format("2006-01-02 15:04:05") +
-			getSourceMessage(file, line, pc, l) + l.Prefix + " - " + printMessage
+	if l.lazyFields || ctx != nil {
+		e.Fields = resolveLazyFields(e.Fields)
 	}
 
-	// Build the colored message to print
-	printMessageColored := l.getColored(printMessage, level.getColor())
-	if !l.OnlyPrintMessage {
-		printMessageColored =
-			l.getColored("["+levelName+"] ", level.getColor()) +
-				l.getColored(time.Now().Local().Format("2006-01-02 15:04:05"), colCyan) +
-				l.getColored(getSourceMessage(file, line, pc, l), colPurple) +
-				l.getColored(l.Prefix, colBlueLight) +
-				" - " + printMessageColored
+	if !enabled {
+		if e = options.levelRules.apply(e); !l.Enabled(e.Level) {
+			return Entry{}, false
+		}
 	}
 
-	if l.File.Level <= level && l.File.logger != nil {
-		l.File.writeToFile(printMessage, level)
+	return e, true
+}
+
+// needsSource returns whether the source (file, line and program counter) has to be
+// determined for the entries. This is the case if "PrintSource" is enabled (for the file), module
+// levels are registered or a target implements SourceTarget and requires it
+func (l *Logger) needsSource() bool {
+	if l.PrintSource || l.ComponentPrefix || (l.File != nil && l.File.PrintSource != nil && *l.File.PrintSource) || hasModuleLevels() {
+		return true
 	}
 
-	if l.Level <= level {
-		if level == LevelError {
-			l.consoleLoggerErr.Println(printMessageColored)
-		} else if level == LevelFatal {
-			l.consoleLoggerErr.Fatal(printMessageColored)
+	needed := false
+	l.forEachTarget(func(target Target) bool {
+		if t, ok := target.(SourceTarget); ok && t.NeedsSource() {
+			needed = true
+		}
+		return !needed
+	})
+
+	return needed
+}
+
+// errDropped is returned by write() if the entry was dropped by a filter, a hook,
+// a module level, the sampling or the deduplication
+var errDropped = errors.New("the message was dropped by a filter, hook, module level, sampling or deduplication")
+
+// write writes the entry to all targets that have the level of the entry enabled.
+// For the level fatal the OnFatal callbacks are invoked, all targets are closed and
+// the program exits afterwards.
+// If "sync" is true, the entry is written directly even if "Async" is configured. The
+// returned error contains the errors of the targets or errDropped. It's always nil for queued entries
+func (l *Logger) write(e Entry, sync bool) (err error) {
+	if r := l.resolve(); r != l {
+		e.logger = r
+		return r.write(e, sync)
+	}
+
+	level := e.Level
+	if l.levelRules != nil {
+		e = l.levelRules.apply(e)
+	}
+
+	if hasModuleLevels() {
+		e.moduleLevel, e.hasModuleLevel = getModuleLevel(e)
+		if e.hasModuleLevel && e.Level < e.moduleLevel && level != LevelFatal {
+			return errDropped
+		}
+	}
+
+	if l.applyFilters(e) && (l.dedup == nil || l.dedup.check(e)) && (l.sampler == nil || l.sampler.sample(l, e)) {
+		if l.async != nil && level != LevelFatal && !sync {
+			l.async.write(e)
 		} else {
-			l.consoleLogger.Println(printMessageColored)
+			if l.async != nil {
+				l.async.flush()
+			}
+			err = l.dispatch(e)
 		}
+	} else {
+		err = errDropped
 	}
 
+	if level == LevelFatal {
+		l.handleFatal()
+	}
+	return err
 }
 
-// getColored returns a message padded by with a color code if coloring is supported and specified
-func (l *Logger) getColored(message string, color func(str string) string) string {
-	if l.colorConf.enableColors {
-		return color(message)
+// dispatch redacts the entry, applies the hooks to it and writes it to the targets.
+// Errors of the targets are passed to the "ErrorHandler" and returned combined
+func (l *Logger) dispatch(e Entry) error {
+	if l.reload != nil {
+		// Replaced targets are only closed after all writes to them finished
+		targets := l.reload.acquire()
+		defer targets.release()
+
+		if targets.logger != nil {
+			l = targets.logger
+			e.logger = targets.logger
+		}
+	}
+
+	if l.redactor != nil {
+		e = l.redactor.redact(e)
+	}
+	if e = l.applyHooks(e); e.dropped {
+		return errDropped
+	}
+
+	var errs []error
+	index := 0
+	l.forEachTarget(func(target Target) bool {
+		// The module level replaces the levels of the targets. Only check if the target is active
+		enabled := (e.hasModuleLevel && target.Enabled(LevelFatal)) || (!e.hasModuleLevel && target.Enabled(e.Level))
+		if enabled {
+			err := target.Write(e)
+			if err != nil {
+				err = &WriteError{Target: target, Err: err}
+				l.handleError(err)
+				errs = append(errs, err)
+			}
+			if l.health != nil {
+				l.health.record(index, err)
+			}
+		} else if e.requireFile && target == Target(l.File) {
+			// The level was lowered by a level rule or a module level (see MustLog())
+			errs = append(errs, errNotWrittenToFile)
+		}
+		index++
+		return true
+	})
+	l.applyErrorHooks(e)
+
+	return errors.Join(errs...)
+}
+
+// Enabled returns whether any target of the logger writes entries with the given level.
+// Use this to skip expensive computations of log parameters. Logging calls
+// for disabled levels return immediately without formatting the message
+func (l *Logger) Enabled(level Level) bool {
+	if !l.ensureSetup() {
+		return false
+	}
+	if r := l.resolve(); r != l {
+		return r.Enabled(level)
+	}
+
+	if hasModuleLevels() {
+		if minLevel, ok := getMinModuleLevel(); ok && minLevel <= level {
+			return true
+		}
+	}
+
+	// Error hooks are invoked independent of the targets
+	if level >= LevelError && l.hasErrorHooks() {
+		return true
 	}
-	return message
+
+	enabled := false
+	l.forEachTarget(func(target Target) bool {
+		enabled = target.Enabled(level)
+		return !enabled
+	})
+
+	return enabled
 }
 
-func getSourceMessage(file string, line int, _ uintptr, l *Logger) string {
-	if !l.PrintSource {
-		return ""
+// IsLevelEnabled returns whether any target of the logger writes entries with the given
+// level. It's the same as Enabled()
+func (l *Logger) IsLevelEnabled(level Level) bool {
+	return l.Enabled(level)
+}
+
+// getSourceName returns the file name and line number of the source like "file.go:1"
+func getSourceName(file string, line int) string {
+	return file[strings.LastIndex(file, "/")+1:] + ":" + strconv.Itoa(line)
+}
+
+// setupState serializes the setup of the loggers. It contains the goroutine that currently
+// sets up a logger, so that messages logged during the setup (e.g. if the log file can't be
+// opened) are written with the partially set up logger instead of waiting for the setup
+var setupState struct {
+	sync.Mutex
+	goroutine atomic.Uint64
+}
+
+// ensureSetup sets up the logger if it was not created with NewLogger(), like a zero
+// value "Logger{}". It returns false for a nil logger, which discards all messages
+func (l *Logger) ensureSetup() bool {
+	if l == nil {
+		return false
 	}
 
-	fileName := file[strings.LastIndex(file, "/")+1:] + ":" + strconv.Itoa(line)
+	if atomic.LoadUint32(&l.initialized) == 0 && setupState.goroutine.Load() != getGoroutineID() {
+		setupState.Lock()
+		if atomic.LoadUint32(&l.initialized) == 0 {
+			l.setupLocked(false)
+		}
+		setupState.Unlock()
+	}
 
-	return " (" + fileName + ")"
+	return true
 }
 
 // setup setups the provided logger.
 // This function has to be called before you can use the logger
 // struct!
 func (l *Logger) setup(keepFile bool) {
+	setupState.Lock()
+	defer setupState.Unlock()
+
+	l.setupLocked(keepFile)
+}
+
+// setupLocked setups the logger while "setupState" is locked
+func (l *Logger) setupLocked(keepFile bool) {
+	setupState.goroutine.Store(getGoroutineID())
+	defer func() {
+		setupState.goroutine.Store(0)
+		atomic.StoreUint32(&l.initialized, 1)
+	}()
 
 	// Setup reference for file logger
+	if l.File == nil {
+		l.File = &FileLogger{}
+	}
 	l.File.rootLogger = l
+	l.getHooks()
+	if l.Sampling != nil && (l.sampler == nil || l.sampler.config != *l.Sampling) {
+		l.sampler = newSampler(*l.Sampling)
+	}
+	if l.PrintHostname {
+		l.hostname, _ = os.Hostname()
+	}
+	if l.uptimeStart == nil {
+		l.uptimeStart = &atomic.Pointer[time.Time]{}
+		now := time.Now()
+		l.uptimeStart.Store(&now)
+	}
+	l.staticKeys = Entry{Fields: l.StaticFields}.getFieldKeys()
+	if l.LevelLabels != nil {
+		l.levelLabels = l.LevelLabels.getLabels()
+	}
+	if l.health == nil {
+		l.health = &healthState{targets: make(map[int]*targetHealth)}
+	}
+	if l.shutdown == nil {
+		l.shutdown = &shutdownState{done: make(chan struct{})}
+	}
+	if l.reload == nil {
+		l.reload = newReloadState()
+	}
+	if l.Async != nil && l.async == nil {
+		l.async = newAsyncWriter(*l.Async, l)
+	}
+	if l.DedupWindow > 0 && (l.dedup == nil || l.dedup.window != l.DedupWindow) {
+		l.dedup = newDeduplicator(l.DedupWindow)
+	}
 
-	// log.Ldate|log.Ltime|log.Lshortfile
-	l.consoleLogger = log.New(os.Stdout, "", 0)
-	l.consoleLoggerErr = log.New(os.Stderr, "", 0)
+	// Writes to the console outputs are serialized
+	l.consoleSync = &sync.Mutex{}
+	l.consoleOut = l.ConsoleOutput
+	if l.consoleOut == nil {
+		l.consoleOut = os.Stdout
+	}
+	l.consoleErr = l.ErrorOutput
+	if l.consoleErr == nil {
+		l.consoleErr = os.Stderr
+	}
+
+	if len(l.LevelRules) == 0 {
+		l.levelRules = nil
+	} else if l.levelRules == nil || !l.levelRules.isFor(l.LevelRules) {
+		l.levelRules = newLevelRules(l.LevelRules, l)
+	}
+	if l.Redaction == nil {
+		l.redactor = nil
+	} else if l.redactor == nil || l.redactor.config != l.Redaction {
+		l.redactor = newRedactor(l.Redaction, l)
+	}
 
 	if strings.TrimSpace(l.File.Path) != "" && !keepFile {
-		l.File.openFile()
+		l.File.logLinkError(l.File.openFile())
 	} else if !keepFile {
 		l.File.CloseFile()
 	}
 
+	if l.Syslog != nil {
+		l.Syslog.rootLogger = l
+		l.Syslog.setup()
+	}
+
+	if l.Journald != nil {
+		l.Journald.setup()
+	}
+
 	// Functions that could produce a panic
 	defer func() {
 		if err := recover(); err != nil {
-			l.log(LevelDebug, "Panic occured: %s", err)
+			l.log(nil, LevelDebug, "Panic occured: %s", err)
 		}
 	}()
-	l.colorConf = *newColorConfig(l.ColoredOutput)
+	l.colorConf = *newColorConfig(l.ColoredOutput, l.consoleOut, l.consoleErr)
+
+	// Older Windows consoles are colored by text attributes instead of ANSI codes
+	if l.colorConf.enableColors {
+		l.consoleOut = wrapConsoleWriter(l.consoleOut)
+	}
+	if l.colorConf.enableColorsErr {
+		l.consoleErr = wrapConsoleWriter(l.consoleErr)
+	}
 }
 
 // SetGlobalLogger updates the global default logger with a custom one.
@@ -219,43 +851,80 @@ func GetGlobalLogger() *Logger {
 // Global available methods per logging levels //
 
 func Trace(message string, parameters ...any) {
-	dLogger.Log(LevelTrace, message, parameters...)
+	dLogger.log(nil, LevelTrace, message, parameters...)
 }
 func Debug(message string, parameters ...any) {
-	dLogger.Log(LevelDebug, message, parameters...)
+	dLogger.log(nil, LevelDebug, message, parameters...)
 }
 func Info(message string, parameters ...any) {
-	dLogger.Log(LevelInfo, message, parameters...)
+	dLogger.log(nil, LevelInfo, message, parameters...)
 }
 func Warning(message string, parameters ...any) {
-	dLogger.Log(LevelWarning, message, parameters...)
+	dLogger.log(nil, LevelWarning, message, parameters...)
 }
 func Error(message string, parameters ...any) {
-	dLogger.Log(LevelError, message, parameters...)
+	dLogger.log(nil, LevelError, message, parameters...)
+}
+func Panic(message string, parameters ...any) {
+	dLogger.log(nil, LevelPanic, message, parameters...)
+	panic(formatMessage(message, parameters...))
 }
 func Fatal(message string, parameters ...any) {
-	dLogger.Log(LevelFatal, message, parameters...)
+	dLogger.log(nil, LevelFatal, message, parameters...)
 }
 
 // Available methods for each logger per logging level
 
 func (l *Logger) Trace(message string, parameters ...any) {
-	l.Log(LevelTrace, message, parameters...)
+	l.log(nil, LevelTrace, message, parameters...)
 }
 func (l *Logger) Debug(message string, parameters ...any) {
-	l.Log(LevelDebug, message, parameters...)
+	l.log(nil, LevelDebug, message, parameters...)
 }
 func (l *Logger) Info(message string, parameters ...any) {
-	l.Log(LevelInfo, message, parameters...)
+	l.log(nil, LevelInfo, message, parameters...)
 }
 func (l *Logger) Warning(message string, parameters ...any) {
-	l.Log(LevelWarning, message, parameters...)
+	l.log(nil, LevelWarning, message, parameters...)
 }
 func (l *Logger) Error(message string, parameters ...any) {
-	l.Log(LevelError, message, parameters...)
+	l.log(nil, LevelError, message, parameters...)
+}
+func (l *Logger) Panic(message string, parameters ...any) {
+	l.log(nil, LevelPanic, message, parameters...)
+	panic(formatMessage(message, parameters...))
 }
 func (l *Logger) Fatal(message string, parameters ...any) {
-	l.Log(LevelFatal, message, parameters...)
+	l.log(nil, LevelFatal, message, parameters...)
+}
+
+// Named creates a child logger of the global logger with the given name
+func Named(name string) *Logger {
+	return dLogger.Named(name)
+}
+
+// WithField returns a copy of the global logger with the given key/value pair
+// attached to every message
+func WithField(key string, value any) *Logger {
+	return dLogger.WithField(key, value)
+}
+
+// WithFields returns a copy of the global logger with the given key/value pairs
+// attached to every message
+func WithFields(fields map[string]any) *Logger {
+	return dLogger.WithFields(fields)
+}
+
+// WithGroup returns a copy of the global logger that prefixes the keys of all
+// fields added afterwards with the name of the group
+func WithGroup(name string) *Logger {
+	return dLogger.WithGroup(name)
+}
+
+// WithCallerSkip returns a copy of the global logger that skips n additional
+// stack frames when determining the source (see Logger.WithCallerSkip())
+func WithCallerSkip(n int) *Logger {
+	return dLogger.WithCallerSkip(n)
 }
 
 // CloseFile closes the underlaying file to which the logger messages are written.
@@ -263,6 +932,47 @@ func CloseFile() {
 	dLogger.File.CloseFile()
 }
 
+// SetLevel changes the minimum console log level of the global logger
+func SetLevel(level Level) {
+	dLogger.SetLevel(level)
+}
+
+// SetFileLevel changes the minimum file log level of the global logger
+func SetFileLevel(level Level) {
+	dLogger.SetFileLevel(level)
+}
+
+// ResetUptime restarts the duration of the field "uptime" for the global logger
+func ResetUptime() {
+	dLogger.ResetUptime()
+}
+
+// Enabled returns whether the global logger writes entries with the given level
+func Enabled(level Level) bool {
+	return dLogger.Enabled(level)
+}
+
+// IsLevelEnabled returns whether the global logger writes entries with the given level
+func IsLevelEnabled(level Level) bool {
+	return dLogger.Enabled(level)
+}
+
+// Close closes all targets of the global logger including the log file
+func Close() error {
+	return dLogger.Close()
+}
+
+// Shutdown writes all queued messages and closes the targets of the global logger
+// (see Logger.Shutdown())
+func Shutdown(ctx context.Context) error {
+	return dLogger.Shutdown(ctx)
+}
+
+// Flush writes the buffered messages of the global logger (see Logger.Flush())
+func Flush() error {
+	return dLogger.Flush()
+}
+
 // GetLoggerFromEnv returns a logging instance configured
 // from the available environment variables.
 //
@@ -273,19 +983,15 @@ func CloseFile() {
 //
 // If no env variable was found the default value of the given
 // logger struct will be used.
-//
-// Note that only generic options can be set like:
-// - Print and Log Level
-// - Log path
-// - ColoredOutput
-// - Tracing disabled
+// All exported options that can be represented as a string are supported (see ApplyEnv()).
+// Additionally "LOGGER_CONSOLE_STDERR" writes all console messages to stderr.
+// Invalid values are ignored and logged as a warning
 func GetLoggerFromEnv(defaultLogger *Logger) *Logger {
-	defaultLogger.ColoredOutput = getEnvBool("LOGGER_COLOREDOUTPUT", defaultLogger.ColoredOutput)
-	defaultLogger.Level = GetLevelByName(getEnvString("LOGGER_LEVEL", defaultLogger.Level.String()))
-	defaultLogger.OnlyPrintMessage = getEnvBool("LOGGER_ONLYPRINTMESSAGE", defaultLogger.OnlyPrintMessage)
-	defaultLogger.File.Level = GetLevelByName(getEnvString("LOGGER_FILE_LEVEL", defaultLogger.File.Level.String()))
-	defaultLogger.File.Path = getEnvString("LOGGER_FILE_PATH", defaultLogger.File.Path)
-	defaultLogger.File.AppendDate = getEnvBool("LOGGER_FILE_APPENDDATE", defaultLogger.File.AppendDate)
-	defaultLogger.PrintSource = getEnvBool("LOGGER_PRINTSOURCE", defaultLogger.PrintSource)
-	return NewLogger(defaultLogger)
+	_, err := ApplyEnv(defaultLogger)
+
+	l := NewLogger(defaultLogger)
+	if err != nil {
+		l.Warning("%s", err)
+	}
+	return l
 }
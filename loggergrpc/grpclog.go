@@ -0,0 +1,132 @@
+// loggergrpc integrates the logger into gRPC. It's a separate module, so that only
+// programs that use it depend on "google.golang.org/grpc".
+//
+// LoggerV2 implements the interfaces "grpclog.LoggerV2" and "grpclog.DepthLoggerV2"
+// so that the internal logging of gRPC is written to a logger:
+//
+//	grpclog.SetLoggerV2(loggergrpc.NewLoggerV2(logger.Named("grpc"), 0))
+//
+// The server interceptors log every call with the method, the status code and the latency:
+//
+//	server := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(loggergrpc.UnaryServerInterceptor(logger.GetGlobalLogger())),
+//		grpc.ChainStreamInterceptor(loggergrpc.StreamServerInterceptor(logger.GetGlobalLogger())),
+//	)
+package loggergrpc
+
+import (
+	"fmt"
+	"runtime"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"google.golang.org/grpc/grpclog"
+)
+
+var (
+	_ grpclog.LoggerV2      = (*LoggerV2)(nil)
+	_ grpclog.DepthLoggerV2 = (*LoggerV2)(nil)
+)
+
+// LoggerV2 writes the internal log messages of gRPC to a logger
+type LoggerV2 struct {
+	logger    *logger.Logger
+	verbosity int
+}
+
+// NewLoggerV2 returns a gRPC logger that writes to the given logger.
+// The verbosity is compared against the verbosity level requested by gRPC with V()
+// (like the environment variable "GRPC_GO_LOG_VERBOSITY_LEVEL")
+func NewLoggerV2(l *logger.Logger, verbosity int) *LoggerV2 {
+	return &LoggerV2{logger: l, verbosity: verbosity}
+}
+
+func (g *LoggerV2) Info(args ...any) {
+	g.log(logger.LevelInfo, 0, fmt.Sprint(args...))
+}
+
+func (g *LoggerV2) Infoln(args ...any) {
+	g.log(logger.LevelInfo, 0, sprintln(args))
+}
+
+func (g *LoggerV2) Infof(format string, args ...any) {
+	g.log(logger.LevelInfo, 0, fmt.Sprintf(format, args...))
+}
+
+func (g *LoggerV2) Warning(args ...any) {
+	g.log(logger.LevelWarning, 0, fmt.Sprint(args...))
+}
+
+func (g *LoggerV2) Warningln(args ...any) {
+	g.log(logger.LevelWarning, 0, sprintln(args))
+}
+
+func (g *LoggerV2) Warningf(format string, args ...any) {
+	g.log(logger.LevelWarning, 0, fmt.Sprintf(format, args...))
+}
+
+func (g *LoggerV2) Error(args ...any) {
+	g.log(logger.LevelError, 0, fmt.Sprint(args...))
+}
+
+func (g *LoggerV2) Errorln(args ...any) {
+	g.log(logger.LevelError, 0, sprintln(args))
+}
+
+func (g *LoggerV2) Errorf(format string, args ...any) {
+	g.log(logger.LevelError, 0, fmt.Sprintf(format, args...))
+}
+
+func (g *LoggerV2) Fatal(args ...any) {
+	g.log(logger.LevelFatal, 0, fmt.Sprint(args...))
+}
+
+func (g *LoggerV2) Fatalln(args ...any) {
+	g.log(logger.LevelFatal, 0, sprintln(args))
+}
+
+func (g *LoggerV2) Fatalf(format string, args ...any) {
+	g.log(logger.LevelFatal, 0, fmt.Sprintf(format, args...))
+}
+
+// The depth methods are preferred by gRPC. The depth 0 is the caller of the method
+func (g *LoggerV2) InfoDepth(depth int, args ...any) {
+	g.log(logger.LevelInfo, depth, fmt.Sprint(args...))
+}
+
+func (g *LoggerV2) WarningDepth(depth int, args ...any) {
+	g.log(logger.LevelWarning, depth, fmt.Sprint(args...))
+}
+
+func (g *LoggerV2) ErrorDepth(depth int, args ...any) {
+	g.log(logger.LevelError, depth, fmt.Sprint(args...))
+}
+
+func (g *LoggerV2) FatalDepth(depth int, args ...any) {
+	g.log(logger.LevelFatal, depth, fmt.Sprint(args...))
+}
+
+// V reports whether the verbosity level l is enabled
+func (g *LoggerV2) V(l int) bool {
+	return l <= g.verbosity
+}
+
+// log writes the message to the logger. The source of the entry is the caller
+// with the given depth relative to the caller of the exported method
+func (g *LoggerV2) log(level logger.Level, depth int, message string) {
+	if level != logger.LevelFatal && !g.logger.Enabled(level) {
+		return
+	}
+
+	e := g.logger.NewEntry(level, message)
+	pcs := make([]uintptr, 1)
+	if runtime.Callers(depth+3, pcs) > 0 {
+		e.PC = pcs[0]
+	}
+	g.logger.LogEntry(e)
+}
+
+// sprintln formats the arguments like fmt.Sprintln() without the trailing line break
+func sprintln(args []any) string {
+	message := fmt.Sprintln(args...)
+	return message[:len(message)-1]
+}
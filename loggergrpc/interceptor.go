@@ -0,0 +1,98 @@
+package loggergrpc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+)
+
+// Names of the gRPC status codes indexed by the code
+var codeNames = []string{
+	"OK", "Canceled", "Unknown", "InvalidArgument", "DeadlineExceeded", "NotFound",
+	"AlreadyExists", "PermissionDenied", "ResourceExhausted", "FailedPrecondition",
+	"Aborted", "OutOfRange", "Unimplemented", "Internal", "Unavailable", "DataLoss",
+	"Unauthenticated",
+}
+
+// StartCall is used by the server interceptors at the beginning of a call.
+// It returns a context with a call scoped logger carrying the field "method" (retrievable by
+// "logger.FromContext(ctx)") and a function that has to be called with the returned error
+// after the handler finished.
+// The finish function logs the method, the status code and the latency of the call. Calls with
+// the code OK are logged as info, errors caused by the client (like NotFound or InvalidArgument)
+// as a warning and all other errors as an error
+func StartCall(ctx context.Context, l *logger.Logger, method string) (context.Context, func(err error)) {
+	start := time.Now()
+	callLogger := l.WithField("method", method)
+
+	return logger.NewContext(ctx, callLogger), func(err error) {
+		code := Code(err)
+
+		level := logger.LevelInfo
+		switch code {
+		case 0:
+		case 1, 3, 5, 6, 7, 9, 11, 16:
+			level = logger.LevelWarning
+		default:
+			level = logger.LevelError
+		}
+
+		fields := map[string]any{
+			"code":    CodeName(code),
+			"latency": time.Since(start).String(),
+		}
+		if err != nil {
+			fields["error"] = err
+		}
+		callLogger.WithFields(fields).Log(level, "%s", method)
+	}
+}
+
+// Code returns the gRPC status code of the error. The code is read from an error
+// that implements the method "GRPCStatus()" like the errors of the package "status".
+// Context errors are converted to the codes Canceled and DeadlineExceeded.
+// A nil error returns the code OK (0) and unknown errors the code Unknown (2)
+func Code(err error) uint32 {
+	if err == nil {
+		return 0
+	}
+
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		method := reflect.ValueOf(e).MethodByName("GRPCStatus")
+		if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+			continue
+		}
+
+		status := method.Call(nil)[0]
+		if status.Kind() == reflect.Pointer && status.IsNil() {
+			continue
+		}
+		if getCode := status.MethodByName("Code"); getCode.IsValid() && getCode.Type().NumIn() == 0 && getCode.Type().NumOut() == 1 {
+			if code := getCode.Call(nil)[0]; code.CanUint() {
+				return uint32(code.Uint())
+			}
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return 1
+	case errors.Is(err, context.DeadlineExceeded):
+		return 4
+	}
+
+	return 2
+}
+
+// CodeName returns the name of the gRPC status code like "NotFound"
+func CodeName(code uint32) string {
+	if int(code) < len(codeNames) {
+		return codeNames[code]
+	}
+
+	return "Code(" + strconv.FormatUint(uint64(code), 10) + ")"
+}
@@ -0,0 +1,41 @@
+package loggergrpc
+
+import (
+	"context"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"google.golang.org/grpc"
+)
+
+// UnaryServerInterceptor logs every unary call with the method, the status code and
+// the latency (see StartCall()). The handlers can retrieve a call scoped logger
+// with "logger.FromContext(ctx)"
+func UnaryServerInterceptor(l *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, finish := StartCall(ctx, l, info.FullMethod)
+		resp, err := handler(ctx, req)
+		finish(err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor logs every streaming call after the stream was closed
+// like UnaryServerInterceptor()
+func StreamServerInterceptor(l *logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, finish := StartCall(ss.Context(), l, info.FullMethod)
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		finish(err)
+		return err
+	}
+}
+
+// serverStream replaces the context of the stream with the one of the call scoped logger
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
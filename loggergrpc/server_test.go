@@ -0,0 +1,80 @@
+package loggergrpc
+
+import (
+	"context"
+	"testing"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/loggertest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	tests := []struct {
+		err   error
+		level logger.Level
+		code  string
+	}{
+		{nil, logger.LevelInfo, "OK"},
+		{status.Error(codes.NotFound, "no user"), logger.LevelWarning, "NotFound"},
+		{status.Error(codes.Internal, "database down"), logger.LevelError, "Internal"},
+	}
+
+	for _, test := range tests {
+		l := loggertest.NewTestLogger(t)
+		interceptor := UnaryServerInterceptor(l.Logger)
+		info := &grpc.UnaryServerInfo{FullMethod: "/users.Users/Get"}
+
+		_, err := interceptor(context.Background(), nil, info, func(ctx context.Context, req any) (any, error) {
+			logger.FromContext(ctx).Info("in handler")
+			return nil, test.err
+		})
+		if err != test.err {
+			t.Fatalf("the error of the handler was not returned: %v", err)
+		}
+
+		entries := l.Entries()
+		if len(entries) != 2 {
+			t.Fatalf("expected 2 entries, got %d", len(entries))
+		}
+		if entries[0].Fields["method"] != info.FullMethod {
+			t.Errorf("the handler logger has no method field: %v", entries[0].Fields)
+		}
+		if call := entries[1]; call.Level != test.level || call.Fields["code"] != test.code || call.Fields["latency"] == nil {
+			t.Errorf("unexpected call entry for %s: %s %v", test.code, call.Level, call.Fields)
+		}
+	}
+}
+
+// testStream is a server stream that only provides a context
+type testStream struct {
+	grpc.ServerStream
+}
+
+func (testStream) Context() context.Context {
+	return context.Background()
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	l := loggertest.NewTestLogger(t)
+	interceptor := StreamServerInterceptor(l.Logger)
+	info := &grpc.StreamServerInfo{FullMethod: "/users.Users/Watch", IsServerStream: true}
+
+	err := interceptor(nil, testStream{}, info, func(srv any, stream grpc.ServerStream) error {
+		logger.FromContext(stream.Context()).Info("in handler")
+		return status.Error(codes.Unavailable, "shutting down")
+	})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("the error of the handler was not returned: %v", err)
+	}
+
+	entries := l.Entries()
+	if len(entries) != 2 || entries[0].Fields["method"] != info.FullMethod {
+		t.Fatalf("the stream context has no call scoped logger: %v", entries)
+	}
+	if entries[1].Level != logger.LevelError || entries[1].Fields["code"] != "Unavailable" {
+		t.Errorf("unexpected call entry: %s %v", entries[1].Level, entries[1].Fields)
+	}
+}
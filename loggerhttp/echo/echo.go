@@ -0,0 +1,54 @@
+// loggerecho replaces the request logger and the recovery of Echo with the logger.
+// It's a separate module, so that only programs that use it depend on Echo:
+//
+//	e := echo.New()
+//	e.Use(loggerecho.Logger(l), loggerecho.Recovery(l))
+package loggerecho
+
+import (
+	"net/http"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/loggerhttp"
+	"github.com/labstack/echo/v4"
+)
+
+// Logger returns a middleware that logs every request like loggerhttp.Middleware().
+// Returned errors are passed to the error handler of Echo first, so that the final
+// status code is logged. The request scoped logger can be retrieved within the handlers
+// by calling "logger.FromContext(c.Request().Context())"
+func Logger(l *logger.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			r, finish := loggerhttp.StartRequest(l, c.Response(), c.Request())
+			c.SetRequest(r)
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+
+			finish(c.Response().Status, int(c.Response().Size))
+			return nil
+		}
+	}
+}
+
+// Recovery returns a middleware that recovers from panics of the handlers like
+// loggerhttp.Recovery(). Register it after Logger() so that the request ID is logged too
+func Recovery(l *logger.Logger) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					if r == http.ErrAbortHandler {
+						panic(r)
+					}
+
+					loggerhttp.LogPanic(l, c.Request(), r)
+					err = echo.ErrInternalServerError
+				}
+			}()
+
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package loggerecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/loggertest"
+	"github.com/labstack/echo/v4"
+)
+
+func TestLoggerAndRecovery(t *testing.T) {
+	l := loggertest.NewTestLogger(t)
+
+	e := echo.New()
+	e.Use(Logger(l.Logger), Recovery(l.Logger))
+	e.GET("/users", func(c echo.Context) error {
+		logger.FromContext(c.Request().Context()).Info("in handler")
+		return echo.NewHTTPError(http.StatusNotFound, "not found")
+	})
+	e.GET("/panic", func(c echo.Context) error {
+		panic("handler failed")
+	})
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if rec.Header().Get("X-Request-Id") == "" {
+		t.Error("no request ID was set")
+	}
+
+	entries := l.Entries()
+	if len(entries) != 2 || entries[0].Fields["request_id"] == nil {
+		t.Fatalf("the handler has no request scoped logger: %v", entries)
+	}
+	if e := entries[1]; e.Level != logger.LevelWarning || e.Message != "GET /users" || e.Fields["status"] != http.StatusNotFound {
+		t.Errorf("unexpected request entry: %s %q %v", e.Level, e.Message, e.Fields)
+	}
+
+	l.Reset()
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status code %d", rec.Code)
+	}
+	if !l.Logged(logger.LevelError, "handler failed") {
+		t.Error("the panic was not logged")
+	}
+	if entries := l.Entries(); len(entries) != 2 || entries[1].Fields["status"] != http.StatusInternalServerError {
+		t.Errorf("the request was not logged with the status 500: %v", entries)
+	}
+}
@@ -0,0 +1,22 @@
+module git.rpjosh.de/RPJosh/go-logger/loggerhttp/echo
+
+go 1.21
+
+require (
+	git.rpjosh.de/RPJosh/go-logger v0.0.0-20261016032005-162d53f8b99c
+	github.com/labstack/echo/v4 v4.12.0
+)
+
+require (
+	github.com/labstack/gommon v0.4.2 // indirect
+	github.com/mattn/go-colorable v0.1.13 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+	github.com/valyala/fasttemplate v1.2.2 // indirect
+	golang.org/x/crypto v0.22.0 // indirect
+	golang.org/x/net v0.24.0 // indirect
+	golang.org/x/sys v0.19.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+replace git.rpjosh.de/RPJosh/go-logger => ../../
@@ -0,0 +1,47 @@
+// loggergin replaces the request logger and the recovery of Gin with the logger.
+// It's a separate module, so that only programs that use it depend on Gin:
+//
+//	router := gin.New()
+//	router.Use(loggergin.Logger(l), loggergin.Recovery(l))
+package loggergin
+
+import (
+	"net/http"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/loggerhttp"
+	"github.com/gin-gonic/gin"
+)
+
+// Logger returns a middleware that logs every request like loggerhttp.Middleware().
+// The request scoped logger can be retrieved within the handlers by calling
+// "logger.FromContext(c.Request.Context())"
+func Logger(l *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var finish func(status, size int)
+		c.Request, finish = loggerhttp.StartRequest(l, c.Writer, c.Request)
+		c.Next()
+
+		// The size is -1 if nothing was written
+		finish(c.Writer.Status(), max(c.Writer.Size(), 0))
+	}
+}
+
+// Recovery returns a middleware that recovers from panics of the handlers like
+// loggerhttp.Recovery(). Register it after Logger() so that the request ID is logged too
+func Recovery(l *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if err := recover(); err != nil {
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
+
+				loggerhttp.LogPanic(l, c.Request, err)
+				c.AbortWithStatus(http.StatusInternalServerError)
+			}
+		}()
+
+		c.Next()
+	}
+}
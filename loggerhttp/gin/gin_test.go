@@ -0,0 +1,57 @@
+package loggergin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/loggertest"
+	"github.com/gin-gonic/gin"
+)
+
+func TestLoggerAndRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	l := loggertest.NewTestLogger(t)
+
+	router := gin.New()
+	router.Use(Logger(l.Logger), Recovery(l.Logger))
+	router.GET("/users", func(c *gin.Context) {
+		logger.FromContext(c.Request.Context()).Info("in handler")
+		c.String(http.StatusNotFound, "not found")
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("handler failed")
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/users", nil))
+	if rec.Header().Get("X-Request-Id") == "" {
+		t.Error("no request ID was set")
+	}
+
+	entries := l.Entries()
+	if len(entries) != 2 || entries[0].Fields["request_id"] == nil {
+		t.Fatalf("the handler has no request scoped logger: %v", entries)
+	}
+	if e := entries[1]; e.Level != logger.LevelWarning || e.Message != "GET /users" || e.Fields["status"] != http.StatusNotFound || e.Fields["size"] != len("not found") {
+		t.Errorf("unexpected request entry: %s %q %v", e.Level, e.Message, e.Fields)
+	}
+
+	l.Reset()
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("unexpected status code %d", rec.Code)
+	}
+	if !l.Logged(logger.LevelError, "handler failed") {
+		t.Error("the panic was not logged")
+	}
+	if entries := l.Entries(); len(entries) != 2 || entries[1].Fields["status"] != http.StatusInternalServerError {
+		t.Errorf("the request was not logged with the status 500: %v", entries)
+	}
+	if !strings.Contains(l.Entries()[0].Message, "gin_test.go") {
+		t.Error("the stack trace is missing")
+	}
+}
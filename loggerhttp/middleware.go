@@ -0,0 +1,128 @@
+// loggerhttp provides a middleware for the "net/http" package that logs
+// every handled request
+package loggerhttp
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+)
+
+// RequestIDHeader is the name of the header that is used to read the ID of
+// a request. If the header is missing, a random ID is generated
+const RequestIDHeader = "X-Request-Id"
+
+// requestIDKey is the key used to store the ID of a request within a context
+type requestIDKey struct{}
+
+// Middleware returns a middleware that logs the method, path, status code, latency and
+// response size of every request.
+// A request scoped logger with the field "request_id" is injected into the context of the
+// request. It can be retrieved within the handlers by calling "logger.FromContext(r.Context())".
+// The ID of the request is available by RequestID().
+//
+// Requests are logged with the level info. Responses with a status code >= 400 are logged
+// as a warning and status codes >= 500 as an error
+func Middleware(l *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r, finish := StartRequest(l, w, r)
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+			finish(rw.status, rw.size)
+		})
+	}
+}
+
+// StartRequest is used by the middlewares at the beginning of a request. It sets the
+// header "X-Request-Id" of the response and returns the request with a request scoped
+// logger and the request ID within its context. The returned function has to be called with
+// the status code and the size of the response after the handler finished. It logs the request
+// like Middleware().
+// Use it to build middlewares for other frameworks like the ones in "loggerhttp/gin"
+func StartRequest(l *logger.Logger, w http.ResponseWriter, r *http.Request) (*http.Request, func(status, size int)) {
+	start := time.Now()
+
+	requestID := r.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	w.Header().Set(RequestIDHeader, requestID)
+
+	reqLogger := l.WithField("request_id", requestID)
+	ctx := context.WithValue(logger.NewContext(r.Context(), reqLogger), requestIDKey{}, requestID)
+
+	return r.WithContext(ctx), func(status, size int) {
+		level := logger.LevelInfo
+		if status >= 500 {
+			level = logger.LevelError
+		} else if status >= 400 {
+			level = logger.LevelWarning
+		}
+
+		reqLogger.WithFields(map[string]any{
+			"status":  status,
+			"latency": time.Since(start).String(),
+			"size":    size,
+		}).Log(level, "%s %s", r.Method, r.URL.Path)
+	}
+}
+
+// RequestID returns the ID of the request that was stored inside the context
+// by Middleware(). If the context does not contain an ID, an empty string is returned
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID returns a random hex encoded ID for a request
+func newRequestID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "unknown"
+	}
+
+	return hex.EncodeToString(id)
+}
+
+// responseWriter records the status code and the number of written bytes
+// of a response
+type responseWriter struct {
+	http.ResponseWriter
+
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	return n, err
+}
+
+// Flush sends any buffered data to the client if supported by the underlying writer
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the original response writer. This is used by the
+// "http.ResponseController"
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,48 @@
+package loggerhttp
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+)
+
+// Recovery returns a middleware that recovers from panics of the handlers.
+// The panic is logged together with the stack trace with the level error (see LogPanic())
+// and the status code 500 is returned to the client.
+// Panics with the value "http.ErrAbortHandler" are not logged and passed through, because
+// they are used to abort a response on purpose.
+//
+// Register it after Middleware() so that the request ID is logged too:
+//
+//	handler = loggerhttp.Middleware(l)(loggerhttp.Recovery(l)(handler))
+func Recovery(l *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if err := recover(); err != nil {
+					if err == http.ErrAbortHandler {
+						panic(err)
+					}
+
+					LogPanic(l, r, err)
+					w.WriteHeader(http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// LogPanic logs the recovered value of a panic that occurred while handling the request
+// with the level error. The message contains the method, the path and the stack trace.
+// The ID of the request is added as the field "request_id" if it's available (see RequestID()).
+// Call this from the recovery handlers of web frameworks
+func LogPanic(l *logger.Logger, r *http.Request, recovered any) {
+	if requestID := RequestID(r.Context()); requestID != "" {
+		l = l.WithField("request_id", requestID)
+	}
+
+	l.Error("Recovered from panic while handling %s %s: %v\n%s", r.Method, r.URL.Path, recovered, debug.Stack())
+}
@@ -0,0 +1,126 @@
+// loggertest provides helpers for testing code that logs.
+//
+// A TestLogger writes all entries through the log of the test (so they are only
+// shown for failed tests or with "go test -v") and records them for assertions:
+//
+//	func TestSomething(t *testing.T) {
+//		l := loggertest.NewTestLogger(t)
+//		doSomething(l.Logger)
+//		l.AssertLogged(logger.LevelError, "connection refused")
+//	}
+package loggertest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+)
+
+// TestLogger is a logger that records all entries for the assertions
+type TestLogger struct {
+	*logger.Logger
+
+	t        testing.TB
+	recorder *recorder
+}
+
+// NewTestLogger creates a new logger for the test. All levels are logged and
+// fatal entries don't exit the program
+func NewTestLogger(t testing.TB) *TestLogger {
+	rec := &recorder{target: NewTarget(t)}
+	l := logger.NewLogger(&logger.Logger{
+		// Disable the console. The entries are written to the log of the test instead
+		Level:       logger.LevelOff,
+		PrintSource: true,
+		FatalNoExit: true,
+		File:        &logger.FileLogger{},
+		Targets:     []logger.Target{rec},
+	})
+
+	return &TestLogger{Logger: l, t: t, recorder: rec}
+}
+
+// Entries returns all recorded entries
+func (l *TestLogger) Entries() []logger.Entry {
+	return l.recorder.getEntries()
+}
+
+// Reset removes all recorded entries
+func (l *TestLogger) Reset() {
+	l.recorder.reset()
+}
+
+// Logged returns whether an entry with the level was recorded whose message
+// contains the given text
+func (l *TestLogger) Logged(level logger.Level, substring string) bool {
+	for _, e := range l.Entries() {
+		if e.Level == level && strings.Contains(e.Message, substring) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AssertLogged marks the test as failed if no entry with the level
+// containing the text was recorded
+func (l *TestLogger) AssertLogged(level logger.Level, substring string) bool {
+	l.t.Helper()
+
+	if !l.Logged(level, substring) {
+		l.t.Errorf("expected a %s entry containing %q, but it was not logged", level, substring)
+		return false
+	}
+	return true
+}
+
+// AssertNotLogged marks the test as failed if an entry with the level
+// containing the text was recorded
+func (l *TestLogger) AssertNotLogged(level logger.Level, substring string) bool {
+	l.t.Helper()
+
+	if l.Logged(level, substring) {
+		l.t.Errorf("expected no %s entry containing %q, but it was logged", level, substring)
+		return false
+	}
+	return true
+}
+
+// recorder is a target that records the entries and writes them to the log of the test
+type recorder struct {
+	target *Target
+
+	mux     sync.Mutex
+	entries []logger.Entry
+}
+
+func (r *recorder) Enabled(level logger.Level) bool {
+	return true
+}
+
+func (r *recorder) Write(e logger.Entry) error {
+	r.mux.Lock()
+	r.entries = append(r.entries, e)
+	r.mux.Unlock()
+
+	return r.target.Write(e)
+}
+
+func (r *recorder) Close() error {
+	return nil
+}
+
+func (r *recorder) getEntries() []logger.Entry {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return append([]logger.Entry(nil), r.entries...)
+}
+
+func (r *recorder) reset() {
+	r.mux.Lock()
+	r.entries = nil
+	r.mux.Unlock()
+}
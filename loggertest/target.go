@@ -0,0 +1,64 @@
+package loggertest
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+)
+
+// Target writes the formatted entries via the log of a test (testing.TB.Logf).
+// That way the entries are attached to the test they belong to and are only
+// shown for failed tests or with "go test -v".
+//
+// Entries written after the test finished are dropped, because the testing
+// package panics in that case
+type Target struct {
+
+	// Minimum log level of the entries to write
+	Level logger.Level
+
+	// Formatter used for the entries. Defaults to the text format without colors
+	Formatter logger.Formatter
+
+	tb       testing.TB
+	finished atomic.Bool
+}
+
+// NewTarget creates a new target that writes to the log of the test.
+// Add it to the field "Targets" of a logger
+func NewTarget(tb testing.TB) *Target {
+	t := &Target{tb: tb}
+	tb.Cleanup(func() { t.finished.Store(true) })
+
+	return t
+}
+
+// Enabled returns whether entries with the level are written
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.Level <= level
+}
+
+// Write writes the entry to the log of the test
+func (t *Target) Write(e logger.Entry) error {
+	if t.finished.Load() {
+		return nil
+	}
+
+	formatter := t.Formatter
+	if formatter == nil {
+		formatter = logger.TextFormatter{}
+	}
+	message, err := formatter.Format(e)
+	if err != nil {
+		return err
+	}
+
+	t.tb.Logf("%s", message)
+	return nil
+}
+
+// Close does nothing
+func (t *Target) Close() error {
+	return nil
+}
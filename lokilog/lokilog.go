@@ -0,0 +1,339 @@
+// lokilog provides a target that pushes the log entries to Grafana Loki.
+//
+// The entries are collected in batches and sent to the endpoint "/loki/api/v1/push".
+// Every entry has the label "level". Additional static labels can be configured and
+// structured fields can be promoted to labels with "LabelFields"
+package lokilog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/batch"
+	"git.rpjosh.de/RPJosh/go-logger/internal/spill"
+	"git.rpjosh.de/RPJosh/go-logger/retry"
+)
+
+// Config contains the configuration options of the Loki target
+type Config struct {
+
+	// Minimum log level of the entries to push
+	Level logger.Level
+
+	// Base URL of Loki like "http://localhost:3100". The path "/loki/api/v1/push"
+	// is appended if it's missing
+	URL string
+
+	// Tenant ID sent with the header "X-Scope-OrgID" for multi-tenant setups
+	TenantID string
+
+	// Credentials for basic authentication
+	Username string
+	Password string
+
+	// Additional HTTP headers sent with every request
+	Headers map[string]string
+
+	// Static labels added to every stream (e.g. {"app": "myApp"})
+	Labels map[string]string
+
+	// Names of structured fields that are used as labels. Only use fields
+	// with a small set of possible values (high cardinality hurts Loki)
+	LabelFields []string
+
+	// Formatter used for the log line. Defaults to logfmt
+	Formatter logger.Formatter
+
+	// Maximum number of entries per request. Defaults to 500
+	BatchSize int
+
+	// Maximum size of the log lines per request in bytes. No limit is applied if this is <= 0
+	MaxBatchBytes int
+
+	// Maximum duration an entry is kept before it's pushed. Defaults to 2 seconds
+	FlushInterval time.Duration
+
+	// Maximum number of entries that are queued. Defaults to 5000
+	QueueSize int
+
+	// Wait for space in the queue instead of dropping entries if Loki can't keep up.
+	// Note that this slows down the logging calls of your application
+	BlockOnFull bool
+
+	// Number of attempts to push a batch. Defaults to 5
+	MaxAttempts int
+
+	// Policy for retrying failed requests like the delays between the attempts.
+	// It replaces "MaxAttempts" if set
+	RetryPolicy *retry.Policy
+
+	// Directory of a persistent queue on disk. Batches that could not be sent are stored
+	// in it and sent again before the next batch or every 30 seconds. Stored batches also survive a restart
+	// of the program. The directory must not be shared with other targets.
+	// The queue is disabled if this is empty
+	SpillDir string
+
+	// Maximum size of the queue on disk in bytes. If it's exceeded, the oldest
+	// batches are dropped. Defaults to 100 MB
+	SpillMaxSize int64
+
+	// Timeout of a single request. Defaults to 10 seconds
+	Timeout time.Duration
+
+	// HTTP client used for the requests
+	HTTPClient *http.Client
+
+	// TLS options used to build the HTTP client if "HTTPClient" is nil (see logger.TLSOptions)
+	TLS *logger.TLSOptions
+}
+
+// Target pushes the entries to Loki
+type Target struct {
+	config  Config
+	batcher *batch.Batcher[entry]
+	health  logger.HealthStatus
+	sender  spill.Sender
+}
+
+// entry is a formatted log line with its labels
+type entry struct {
+	labels    map[string]string
+	timestamp time.Time
+	line      string
+}
+
+// New creates a new Loki target with the given configuration.
+// Add it to the field "Targets" of a logger
+func New(config Config) *Target {
+	config.URL = strings.TrimSuffix(config.URL, "/")
+	if !strings.HasSuffix(config.URL, "/loki/api/v1/push") {
+		config.URL += "/loki/api/v1/push"
+	}
+	if config.Formatter == nil {
+		config.Formatter = logger.LogfmtFormatter{}
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 500
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 2 * time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 5000
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = &retry.Policy{MaxAttempts: config.MaxAttempts, InitialDelay: time.Second}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.HTTPClient == nil && config.TLS != nil {
+		client, err := config.TLS.HTTPClient(config.Timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "lokilog: invalid TLS options: %s\n", err)
+		}
+		config.HTTPClient = client
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: config.Timeout}
+	}
+
+	t := &Target{config: config}
+	t.sender = spill.Sender{Name: "lokilog", Policy: config.RetryPolicy, Send: t.send}
+	if config.SpillDir != "" {
+		queue, err := spill.Open(spill.Options{Dir: config.SpillDir, MaxSize: config.SpillMaxSize})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "lokilog: failed to open the spill queue: %s\n", err)
+		}
+		t.sender.Queue = queue
+		t.sender.Start()
+	}
+	t.batcher = batch.New(batch.Options[entry]{
+		MaxItems:   config.BatchSize,
+		MaxBytes:   config.MaxBatchBytes,
+		Size:       func(e entry) int { return len(e.line) },
+		MaxLatency: config.FlushInterval,
+		QueueSize:  config.QueueSize,
+		Block:      config.BlockOnFull,
+	}, t.push)
+
+	return t
+}
+
+// Enabled returns whether entries with the level are pushed
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.config.Level <= level
+}
+
+// Write queues the entry for the push
+func (t *Target) Write(e logger.Entry) error {
+	line, err := t.config.Formatter.Format(e)
+	if err != nil {
+		return err
+	}
+
+	if !t.batcher.Add(entry{labels: t.getLabels(e), timestamp: e.Time, line: string(line)}) {
+		return errors.New("lokilog: queue is full, entry dropped")
+	}
+
+	return nil
+}
+
+// Flush pushes all queued entries
+func (t *Target) Flush() {
+	t.batcher.Flush()
+}
+
+// Stats returns the metrics of the sent batches like their size and the flush latency
+func (t *Target) Stats() batch.Stats {
+	return t.batcher.Stats()
+}
+
+// Health returns the state of the target with the last error of sending a batch
+func (t *Target) Health() logger.SinkHealth {
+	health := t.health.Status()
+	health.QueueDepth = t.batcher.Stats().Queued + t.sender.Len()
+	return health
+}
+
+// Close pushes all queued entries and stops the background worker
+func (t *Target) Close() error {
+	t.batcher.Close()
+	return t.sender.Close()
+}
+
+// getLabels returns the labels of the stream for the entry
+func (t *Target) getLabels(e logger.Entry) map[string]string {
+	labels := make(map[string]string, len(t.config.Labels)+len(t.config.LabelFields)+1)
+	for key, value := range t.config.Labels {
+		labels[sanitizeLabel(key)] = value
+	}
+	for _, key := range t.config.LabelFields {
+		if value, ok := e.Fields[key]; ok {
+			labels[sanitizeLabel(key)] = fmt.Sprintf("%v", value)
+		}
+	}
+	labels["level"] = strings.ToLower(e.Level.String())
+
+	return labels
+}
+
+// push sends a batch of entries grouped by their labels to Loki
+func (t *Target) push(entries []entry) {
+	streams := make(map[string]*stream)
+	order := make([]string, 0)
+	for _, e := range entries {
+		key := getStreamKey(e.labels)
+		s, ok := streams[key]
+		if !ok {
+			s = &stream{Stream: e.labels}
+			streams[key] = s
+			order = append(order, key)
+		}
+		s.Values = append(s.Values, [2]string{strconv.FormatInt(e.timestamp.UnixNano(), 10), e.line})
+	}
+
+	req := pushRequest{Streams: make([]*stream, 0, len(streams))}
+	for _, key := range order {
+		req.Streams = append(req.Streams, streams[key])
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return
+	}
+
+	stored, err := t.sender.Deliver(body)
+	t.health.Report(err)
+	if err != nil && !stored {
+		fmt.Fprintf(os.Stderr, "lokilog: failed to push %d entries: %s\n", len(entries), err)
+	}
+}
+
+// send executes a single push request. Client errors (except "429 Too Many Requests")
+// are not retried
+func (t *Target) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, t.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.config.TenantID != "" {
+		req.Header.Set("X-Scope-OrgID", t.config.TenantID)
+	}
+	if t.config.Username != "" || t.config.Password != "" {
+		req.SetBasicAuth(t.config.Username, t.config.Password)
+	}
+	for key, value := range t.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	err = fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	if resp.StatusCode >= 400 && resp.StatusCode <= 499 && resp.StatusCode != http.StatusTooManyRequests {
+		return retry.Permanent(err)
+	}
+	return err
+}
+
+// getStreamKey returns a unique key for the label set
+func getStreamKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		b.WriteString(key + "=" + strconv.Quote(labels[key]) + ",")
+	}
+
+	return b.String()
+}
+
+// sanitizeLabel replaces all characters that are not allowed within a label name
+// of Loki with an underscore
+func sanitizeLabel(name string) string {
+	rtc := []rune(name)
+	for i, r := range rtc {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || (i > 0 && r >= '0' && r <= '9')) {
+			rtc[i] = '_'
+		}
+	}
+
+	return string(rtc)
+}
+
+// JSON representation of the push API
+
+type pushRequest struct {
+	Streams []*stream `json:"streams"`
+}
+
+type stream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][2]string       `json:"values"`
+}
@@ -0,0 +1,294 @@
+// maillog provides a target that sends a digest email via SMTP when log entries
+// with a high severity occur.
+//
+// To not flood the inbox, at most one mail is sent per throttle window. All entries
+// that occurred within the window are collected and sent together with the next mail
+package maillog
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/internal/spill"
+)
+
+// Config contains the configuration options of the mail target
+type Config struct {
+
+	// Minimum log level of the entries to send. Levels below Error are ignored
+	Level logger.Level
+
+	// Host name and port of the SMTP server
+	Host string
+	Port int
+
+	// Credentials for the authentication (PLAIN). No authentication is
+	// done if the user name is empty
+	Username string
+	Password string
+
+	// Use implicit TLS ("SMTPS", usually port 465). Otherwise STARTTLS is
+	// used when the server supports it
+	UseTLS bool
+
+	// TLS configuration used for the connection (optional)
+	TLSConfig *tls.Config
+
+	// TLS options used to build "TLSConfig" if it's nil (see logger.TLSOptions)
+	TLS *logger.TLSOptions
+
+	// Sender and recipients of the mail
+	From string
+	To   []string
+
+	// Subject of the mail. Defaults to "Log entries with a high severity on <hostname>"
+	Subject string
+
+	// At most one mail is sent within this window. Defaults to 10 minutes
+	ThrottleWindow time.Duration
+
+	// Maximum number of entries included in a mail. Further entries are only counted.
+	// Defaults to 500
+	MaxEntries int
+
+	// Timeout for the connection to the server. Defaults to 30 seconds
+	Timeout time.Duration
+
+	// Directory of a persistent queue on disk. Mails that could not be sent are stored
+	// in it and sent again before the next mail or every 30 seconds. Stored mails also survive a restart
+	// of the program. The directory must not be shared with other targets.
+	// The queue is disabled if this is empty
+	SpillDir string
+
+	// Maximum size of the queue on disk in bytes. If it's exceeded, the oldest
+	// mails are dropped. Defaults to 100 MB
+	SpillMaxSize int64
+}
+
+// Target sends the entries as digest mails
+type Target struct {
+	config Config
+
+	mux      sync.Mutex
+	entries  []logger.Entry
+	dropped  int
+	lastSent time.Time
+	timer    *time.Timer
+	closed   bool
+
+	// Serializes sending the mails (see Flush())
+	sendMux sync.Mutex
+	sender  spill.Sender
+}
+
+// New creates a new mail target with the given configuration.
+// Add it to the field "Targets" of a logger
+func New(config Config) *Target {
+	if config.Level < logger.LevelError {
+		config.Level = logger.LevelError
+	}
+	if config.Port == 0 {
+		config.Port = 587
+		if config.UseTLS {
+			config.Port = 465
+		}
+	}
+	if config.Subject == "" {
+		hostname, _ := os.Hostname()
+		config.Subject = "Log entries with a high severity on " + hostname
+	}
+	if config.ThrottleWindow <= 0 {
+		config.ThrottleWindow = 10 * time.Minute
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 500
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if config.TLS != nil && config.TLSConfig == nil {
+		tlsConfig, err := config.TLS.Config()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "maillog: invalid TLS options: %s\n", err)
+		}
+		config.TLSConfig = tlsConfig
+	}
+
+	t := &Target{config: config}
+	t.sender = spill.Sender{Name: "maillog", Send: t.send}
+	if config.SpillDir != "" {
+		queue, err := spill.Open(spill.Options{Dir: config.SpillDir, MaxSize: config.SpillMaxSize})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "maillog: failed to open the spill queue: %s\n", err)
+		}
+		t.sender.Queue = queue
+		t.sender.Start()
+	}
+
+	return t
+}
+
+// Enabled returns whether entries with the level are sent
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.config.Level <= level
+}
+
+// Write buffers the entry for the next mail. The mail is sent as soon as the
+// throttle window since the last mail expired
+func (t *Target) Write(e logger.Entry) error {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if t.closed {
+		return errors.New("maillog: target is closed")
+	}
+
+	if len(t.entries) >= t.config.MaxEntries {
+		t.dropped++
+	} else {
+		t.entries = append(t.entries, e)
+	}
+
+	if t.timer == nil {
+		delay := time.Until(t.lastSent.Add(t.config.ThrottleWindow))
+		if delay < 0 {
+			delay = 0
+		}
+		t.timer = time.AfterFunc(delay, func() { t.Flush() })
+	}
+
+	return nil
+}
+
+// Flush sends all buffered entries immediately ignoring the throttle window
+func (t *Target) Flush() error {
+	t.mux.Lock()
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	entries, dropped := t.entries, t.dropped
+	t.entries, t.dropped = nil, 0
+	if len(entries) > 0 {
+		t.lastSent = time.Now()
+	}
+	t.mux.Unlock()
+
+	if len(entries) == 0 {
+		return nil
+	}
+
+	t.sendMux.Lock()
+	defer t.sendMux.Unlock()
+
+	stored, err := t.sender.Deliver(t.getMessage(entries, dropped))
+	if err != nil && !stored {
+		fmt.Fprintf(os.Stderr, "maillog: failed to send mail: %s\n", err)
+		return err
+	}
+	return nil
+}
+
+// Close sends all buffered entries. Further entries are rejected
+func (t *Target) Close() error {
+	t.mux.Lock()
+	t.closed = true
+	t.mux.Unlock()
+
+	err := t.Flush()
+	return errors.Join(err, t.sender.Close())
+}
+
+// send sends the mail
+func (t *Target) send(message []byte) error {
+	address := net.JoinHostPort(t.config.Host, strconv.Itoa(t.config.Port))
+	tlsConfig := t.config.TLSConfig
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{ServerName: t.config.Host}
+	}
+
+	dialer := &net.Dialer{Timeout: t.config.Timeout}
+	var conn net.Conn
+	var err error
+	if t.config.UseTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(t.config.Timeout))
+
+	client, err := smtp.NewClient(conn, t.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok && !t.config.UseTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return err
+		}
+	}
+	if t.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", t.config.Username, t.config.Password, t.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(t.config.From); err != nil {
+		return err
+	}
+	for _, to := range t.config.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(message); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// getMessage returns the mail including the headers
+func (t *Target) getMessage(entries []logger.Entry, dropped int) []byte {
+	var b bytes.Buffer
+	b.WriteString("From: " + t.config.From + "\r\n")
+	b.WriteString("To: " + strings.Join(t.config.To, ", ") + "\r\n")
+	b.WriteString("Subject: " + t.config.Subject + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+
+	fmt.Fprintf(&b, "%d log entries with a high severity occurred:\r\n\r\n", len(entries)+dropped)
+	for _, e := range entries {
+		b.WriteString(strings.ReplaceAll(e.String(), "\n", "\r\n") + "\r\n")
+	}
+	if dropped > 0 {
+		fmt.Fprintf(&b, "\r\n… and %d more entries that were not included\r\n", dropped)
+	}
+
+	return b.Bytes()
+}
@@ -0,0 +1,264 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a target that keeps the most recent entries in memory.
+// The entries can be queried with Entries() or inspected via the http handler
+// returned by Handler().
+// Create it with NewMemoryStore() and add it to the field "Targets" of the logger
+type MemoryStore struct {
+
+	// Minimum log level of the entries to keep
+	Level Level
+
+	mux     sync.RWMutex
+	entries []Entry
+	next    int
+	full    bool
+}
+
+// MemoryQuery filters the entries of a MemoryStore. Empty values are ignored
+type MemoryQuery struct {
+
+	// Minimum level of the entries
+	Level Level
+
+	// Only entries within this time range
+	Since time.Time
+	Until time.Time
+
+	// Text that has to be contained (case insensitive) in the message, the name
+	// of the logger or a field
+	Text string
+
+	// Maximum number of returned entries. The newest entries are returned
+	Limit int
+}
+
+// NewMemoryStore creates a new memory store that keeps the last "size" entries
+func NewMemoryStore(size int) *MemoryStore {
+	if size <= 0 {
+		size = 1000
+	}
+
+	return &MemoryStore{entries: make([]Entry, size)}
+}
+
+// Enabled returns whether entries with the level are kept
+func (s *MemoryStore) Enabled(level Level) bool {
+	return s.Level.load() <= level
+}
+
+// Write stores the entry. If the store is full, the oldest entry is removed
+func (s *MemoryStore) Write(e Entry) error {
+	s.mux.Lock()
+	s.entries[s.next] = e
+	s.next = (s.next + 1) % len(s.entries)
+	if s.next == 0 {
+		s.full = true
+	}
+	s.mux.Unlock()
+
+	return nil
+}
+
+// Close does nothing. The entries are kept
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// Reset removes all stored entries
+func (s *MemoryStore) Reset() {
+	s.mux.Lock()
+	for i := range s.entries {
+		s.entries[i] = Entry{}
+	}
+	s.next, s.full = 0, false
+	s.mux.Unlock()
+}
+
+// Entries returns the stored entries matching the query ordered from the oldest to the newest
+func (s *MemoryStore) Entries(query MemoryQuery) []Entry {
+	s.mux.RLock()
+	all := make([]Entry, 0, len(s.entries))
+	if s.full {
+		all = append(all, s.entries[s.next:]...)
+	}
+	all = append(all, s.entries[:s.next]...)
+	s.mux.RUnlock()
+
+	rtc := make([]Entry, 0, len(all))
+	text := strings.ToLower(query.Text)
+	for _, e := range all {
+		if e.Level < query.Level ||
+			(!query.Since.IsZero() && e.Time.Before(query.Since)) ||
+			(!query.Until.IsZero() && e.Time.After(query.Until)) ||
+			(text != "" && !e.contains(text)) {
+			continue
+		}
+		rtc = append(rtc, e)
+	}
+
+	if query.Limit > 0 && len(rtc) > query.Limit {
+		rtc = rtc[len(rtc)-query.Limit:]
+	}
+	return rtc
+}
+
+// contains returns whether the lower case text is contained in the message,
+// the name of the logger or a field of the entry
+func (e Entry) contains(text string) bool {
+	if strings.Contains(strings.ToLower(e.Message), text) || strings.Contains(strings.ToLower(e.LoggerName), text) {
+		return true
+	}
+	for key, value := range e.Fields {
+		if strings.Contains(strings.ToLower(key+"="+fmt.Sprintf("%v", value)), text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// memoryEntry is the JSON representation of an entry used by the handler of the MemoryStore
+type memoryEntry struct {
+	Level   string         `json:"level"`
+	Time    time.Time      `json:"time"`
+	Source  string         `json:"source,omitempty"`
+	Logger  string         `json:"logger,omitempty"`
+	Message string         `json:"message"`
+	Fields  map[string]any `json:"fields,omitempty"`
+}
+
+// Handler returns a http handler that serves the stored entries as HTML page or as JSON
+// (with the query parameter "format=json" or the header "Accept: application/json").
+// The entries can be filtered with the following query parameters:
+//
+//	level: minimum level like "warn"
+//	since, until: time as RFC 3339 or a duration relative to now like "15m"
+//	q: text to search for
+//	limit: maximum number of entries (default 500)
+func (s *MemoryStore) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := r.URL.Query()
+		query := MemoryQuery{Text: params.Get("q"), Limit: 500}
+
+		var ok bool
+		if level := params.Get("level"); level != "" {
+			if query.Level, ok = parseLevel(level); !ok {
+				http.Error(w, "Unknown level name", http.StatusBadRequest)
+				return
+			}
+		}
+		if query.Since, ok = parseQueryTime(params.Get("since")); !ok {
+			http.Error(w, "Invalid time for 'since'", http.StatusBadRequest)
+			return
+		}
+		if query.Until, ok = parseQueryTime(params.Get("until")); !ok {
+			http.Error(w, "Invalid time for 'until'", http.StatusBadRequest)
+			return
+		}
+		if limit := params.Get("limit"); limit != "" {
+			var err error
+			if query.Limit, err = strconv.Atoi(limit); err != nil {
+				http.Error(w, "Invalid limit", http.StatusBadRequest)
+				return
+			}
+		}
+
+		entries := s.Entries(query)
+		result := make([]memoryEntry, 0, len(entries))
+		for i := len(entries) - 1; i >= 0; i-- {
+			e := entries[i]
+			entry := memoryEntry{Level: e.Level.String(), Time: e.Time, Logger: e.LoggerName, Message: e.Message, Fields: e.Fields}
+			if e.Line > 0 {
+				entry.Source = e.sourceName()
+			}
+			result = append(result, entry)
+		}
+
+		if params.Get("format") == "json" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(result)
+			return
+		}
+
+		levels := []string{""}
+		for _, level := range Levels() {
+			levels = append(levels, strings.ToLower(level.String()))
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		memoryTemplate.Execute(w, map[string]any{
+			"Entries": result,
+			"Level":   params.Get("level"),
+			"Since":   params.Get("since"),
+			"Until":   params.Get("until"),
+			"Text":    query.Text,
+			"Limit":   query.Limit,
+			"Levels":  levels,
+		})
+	})
+}
+
+// parseQueryTime parses a time given as RFC 3339 or as duration relative to now
+func parseQueryTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, true
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d.Abs()), true
+	}
+	t, err := time.Parse(time.RFC3339, value)
+
+	return t, err == nil
+}
+
+// memoryTemplate is the HTML page of the MemoryStore handler
+var memoryTemplate = template.Must(template.New("memory").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Logs</title>
+<style>
+body { font-family: sans-serif; margin: 1em; }
+table { border-collapse: collapse; width: 100%; font-family: monospace; font-size: 13px; }
+td, th { text-align: left; padding: 2px 8px; vertical-align: top; border-bottom: 1px solid #eee; }
+td.msg { white-space: pre-wrap; }
+.TRACE, .DEBUG { color: #777; }
+.WARN { background: #fff8e1; }
+.ERROR { background: #ffebee; }
+.PANIC, .FATAL { background: #ffcdd2; font-weight: bold; }
+.fields { color: #555; }
+</style>
+</head>
+<body>
+<form method="get">
+Level <select name="level">
+{{range $l := .Levels}}<option{{if eq $l $.Level}} selected{{end}}>{{$l}}</option>{{end}}
+</select>
+Since <input name="since" value="{{.Since}}" placeholder="15m">
+Until <input name="until" value="{{.Until}}">
+Text <input name="q" value="{{.Text}}">
+Limit <input name="limit" value="{{.Limit}}" size="5">
+<button type="submit">Filter</button>
+</form>
+<table>
+<tr><th>Time</th><th>Level</th><th>Source</th><th>Logger</th><th>Message</th></tr>
+{{range .Entries}}<tr class="{{.Level}}">
+<td>{{.Time.Format "2006-01-02 15:04:05.000"}}</td><td>{{.Level}}</td><td>{{.Source}}</td><td>{{.Logger}}</td>
+<td class="msg">{{.Message}}{{range $k, $v := .Fields}} <span class="fields">{{$k}}={{$v}}</span>{{end}}</td>
+</tr>
+{{end}}</table>
+</body>
+</html>
+`))
@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// moduleLevels contains the levels registered via SetModuleLevel()
+var moduleLevels = struct {
+	sync.RWMutex
+	levels map[string]Level
+
+	// Number of registered modules (accessed atomically) to skip the lookup
+	// if no module level is registered
+	count int32
+}{levels: make(map[string]Level)}
+
+// Cache of the package paths per program counter
+var modulePackageCache sync.Map
+
+// SetModuleLevel sets the minimum log level for all messages logged by the given module.
+// A module is either the name of a logger created with Named() ("api.handlers" or the parent "api"),
+// a package path ("github.com/user/app/db"), the last element of a package path ("db") or the
+// path of a parent package ("github.com/user/app").
+//
+// For messages of a module the module level replaces the levels of the targets (console, file, ...)
+// so that you can enable verbose logging only for a single subsystem:
+//
+//	logger.SetModuleLevel("db", logger.LevelTrace)
+func SetModuleLevel(module string, level Level) {
+	moduleLevels.Lock()
+	moduleLevels.levels[module] = level
+	atomic.StoreInt32(&moduleLevels.count, int32(len(moduleLevels.levels)))
+	moduleLevels.Unlock()
+}
+
+// RemoveModuleLevel removes the level of the module that was set with SetModuleLevel().
+// Messages of the module are filtered by the levels of the targets again
+func RemoveModuleLevel(module string) {
+	moduleLevels.Lock()
+	delete(moduleLevels.levels, module)
+	atomic.StoreInt32(&moduleLevels.count, int32(len(moduleLevels.levels)))
+	moduleLevels.Unlock()
+}
+
+// hasModuleLevels returns whether any module level is registered
+func hasModuleLevels() bool {
+	return atomic.LoadInt32(&moduleLevels.count) > 0
+}
+
+// getModuleLevel returns the level registered for the module of the entry.
+// The name of the logger is checked first. Afterwards the package of the caller is used.
+// The most specific registered module is used
+func getModuleLevel(e Entry) (Level, bool) {
+	moduleLevels.RLock()
+	defer moduleLevels.RUnlock()
+
+	// Look for the logger name or one of its parents
+	for name := e.LoggerName; name != ""; {
+		if level, ok := moduleLevels.levels[name]; ok {
+			return level, true
+		}
+
+		lastDot := strings.LastIndex(name, ".")
+		if lastDot == -1 {
+			break
+		}
+		name = name[:lastDot]
+	}
+
+	pkg := getPackagePath(e.PC)
+	if pkg == "" {
+		return 0, false
+	}
+
+	// Look for the package path or one of its parents
+	for path := pkg; path != ""; {
+		if level, ok := moduleLevels.levels[path]; ok {
+			return level, true
+		}
+
+		lastSlash := strings.LastIndex(path, "/")
+		if lastSlash == -1 {
+			break
+		}
+		path = path[:lastSlash]
+	}
+
+	// Look for the short package name
+	if level, ok := moduleLevels.levels[pkg[strings.LastIndex(pkg, "/")+1:]]; ok {
+		return level, true
+	}
+
+	return 0, false
+}
+
+// getMinModuleLevel returns the lowest registered module level
+func getMinModuleLevel() (Level, bool) {
+	moduleLevels.RLock()
+	defer moduleLevels.RUnlock()
+
+	found := false
+	var minLevel Level
+	for _, level := range moduleLevels.levels {
+		if !found || level < minLevel {
+			minLevel = level
+			found = true
+		}
+	}
+
+	return minLevel, found
+}
+
+// getPackagePath returns the package path of the function with the given program counter
+func getPackagePath(pc uintptr) string {
+	if pc == 0 {
+		return ""
+	}
+	if pkg, ok := modulePackageCache.Load(pc); ok {
+		return pkg.(string)
+	}
+
+	pkg := ""
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		pkg = getPackageFromFunc(fn.Name())
+	}
+	modulePackageCache.Store(pc, pkg)
+
+	return pkg
+}
+
+// getPackageFromFunc extracts the package path from a fully qualified function name like
+// "github.com/user/app/db.(*Conn).Query"
+func getPackageFromFunc(funcName string) string {
+	lastSlash := strings.LastIndex(funcName, "/")
+	if dot := strings.Index(funcName[lastSlash+1:], "."); dot != -1 {
+		return funcName[:lastSlash+1+dot]
+	}
+
+	return funcName
+}
+
+// getComponent returns the name of the component of the package path. The longest
+// matching package path of the components is used. Otherwise the last element of the path
+func getComponent(pkg string, components map[string]string) string {
+	name, matched := "", -1
+	for path, component := range components {
+		if len(path) > matched && (pkg == path || strings.HasPrefix(pkg, path+"/")) {
+			name, matched = component, len(path)
+		}
+	}
+	if matched != -1 {
+		return name
+	}
+
+	return pkg[strings.LastIndex(pkg, "/")+1:]
+}
@@ -0,0 +1,257 @@
+// mongolog provides a target that writes the log entries as structured documents
+// into a MongoDB collection.
+//
+// To keep this module free of dependencies, the target does not ship a MongoDB driver.
+// Instead the collection is passed as an "Inserter" which is implemented with a few
+// lines for the official driver:
+//
+//	type collection struct{ *mongo.Collection }
+//
+//	func (c collection) InsertMany(ctx context.Context, documents []any) error {
+//		_, err := c.Collection.InsertMany(ctx, documents)
+//		return err
+//	}
+//
+// The driver handles the connection pool and reconnects automatically. Failed inserts are retried.
+//
+// To limit the size of the collection, create it as capped collection:
+//
+//	db.createCollection("logs", { capped: true, size: 100 * 1024 * 1024 })
+//
+// Or let MongoDB delete old entries with a TTL index on the field "expireAt" and configure "TTL":
+//
+//	db.logs.createIndex({ expireAt: 1 }, { expireAfterSeconds: 0 })
+package mongolog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/batch"
+	"git.rpjosh.de/RPJosh/go-logger/internal/spill"
+	"git.rpjosh.de/RPJosh/go-logger/retry"
+)
+
+// Inserter inserts documents into a collection
+type Inserter interface {
+	InsertMany(ctx context.Context, documents []any) error
+}
+
+// Document is the structure of the entries within the collection
+type Document struct {
+	Time     time.Time      `bson:"time" json:"time"`
+	Level    string         `bson:"level" json:"level"`
+	Message  string         `bson:"message" json:"message"`
+	Logger   string         `bson:"logger,omitempty" json:"logger,omitempty"`
+	Source   string         `bson:"source,omitempty" json:"source,omitempty"`
+	Fields   map[string]any `bson:"fields,omitempty" json:"fields,omitempty"`
+	ExpireAt *time.Time     `bson:"expireAt,omitempty" json:"expireAt,omitempty"`
+}
+
+// Config contains the configuration options of the MongoDB target
+type Config struct {
+
+	// Minimum log level of the entries to write
+	Level logger.Level
+
+	// Collection the documents are inserted into
+	Collection Inserter
+
+	// Retention of the entries. If set, the field "expireAt" of the documents is
+	// set accordingly. A TTL index on that field is required (see package documentation)
+	TTL time.Duration
+
+	// Maximum number of documents inserted at once. Defaults to 100
+	BatchSize int
+
+	// Maximum duration an entry is kept before it's inserted. Defaults to 2 seconds
+	FlushInterval time.Duration
+
+	// Maximum number of entries that are queued. If the queue is full, entries are dropped.
+	// Defaults to 1000
+	QueueSize int
+
+	// Number of attempts to insert a batch. Defaults to 5
+	MaxAttempts int
+
+	// Policy for retrying failed requests like the delays between the attempts.
+	// It replaces "MaxAttempts" if set
+	RetryPolicy *retry.Policy
+
+	// Directory of a persistent queue on disk. Batches that could not be sent are stored
+	// in it and sent again before the next batch or every 30 seconds. Stored batches also survive a restart
+	// of the program. The directory must not be shared with other targets.
+	// The queue is disabled if this is empty
+	SpillDir string
+
+	// Maximum size of the queue on disk in bytes. If it's exceeded, the oldest
+	// batches are dropped. Defaults to 100 MB
+	SpillMaxSize int64
+
+	// Timeout of a single insert. Defaults to 10 seconds
+	Timeout time.Duration
+}
+
+// Target writes the entries into a MongoDB collection
+type Target struct {
+	config  Config
+	batcher *batch.Batcher[any]
+	health  logger.HealthStatus
+	sender  spill.Sender
+}
+
+// New creates a new MongoDB target with the given configuration.
+// Add it to the field "Targets" of a logger
+func New(config Config) (*Target, error) {
+	if config.Collection == nil {
+		return nil, errors.New("no collection given")
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = 2 * time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 5
+	}
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = &retry.Policy{MaxAttempts: config.MaxAttempts, InitialDelay: time.Second}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	t := &Target{config: config}
+	t.sender = spill.Sender{Name: "mongolog", Policy: config.RetryPolicy, Send: t.insertPayload}
+	if config.SpillDir != "" {
+		queue, err := spill.Open(spill.Options{Dir: config.SpillDir, MaxSize: config.SpillMaxSize})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "mongolog: failed to open the spill queue: %s\n", err)
+		}
+		t.sender.Queue = queue
+		t.sender.Start()
+	}
+	t.batcher = batch.New(batch.Options[any]{
+		MaxItems:   config.BatchSize,
+		MaxLatency: config.FlushInterval,
+		QueueSize:  config.QueueSize,
+	}, t.insert)
+
+	return t, nil
+}
+
+// Enabled returns whether entries with the level are written
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.config.Level <= level
+}
+
+// Write queues the entry for the insert
+func (t *Target) Write(e logger.Entry) error {
+	if !t.batcher.Add(t.newDocument(e)) {
+		return errors.New("mongolog: queue is full, entry dropped")
+	}
+
+	return nil
+}
+
+// Flush inserts all queued entries
+func (t *Target) Flush() {
+	t.batcher.Flush()
+}
+
+// Stats returns the metrics of the sent batches like their size and the flush latency
+func (t *Target) Stats() batch.Stats {
+	return t.batcher.Stats()
+}
+
+// Health returns the state of the target with the last error of sending a batch
+func (t *Target) Health() logger.SinkHealth {
+	health := t.health.Status()
+	health.QueueDepth = t.batcher.Stats().Queued + t.sender.Len()
+	return health
+}
+
+// Close inserts all queued entries and stops the background worker.
+// The collection itself is not closed
+func (t *Target) Close() error {
+	t.batcher.Close()
+	return t.sender.Close()
+}
+
+// newDocument converts the entry to a document
+func (t *Target) newDocument(e logger.Entry) *Document {
+	doc := &Document{
+		Time:    e.Time,
+		Level:   e.Level.String(),
+		Message: e.Message,
+		Logger:  e.LoggerName,
+	}
+	if e.Line > 0 {
+		doc.Source = filepath.Base(e.File) + ":" + strconv.Itoa(e.Line)
+	}
+	if len(e.Fields) > 0 {
+		doc.Fields = make(map[string]any, len(e.Fields))
+		for key, value := range e.Fields {
+			if err, ok := value.(error); ok {
+				value = err.Error()
+			}
+			doc.Fields[key] = value
+		}
+	}
+	if t.config.TTL > 0 {
+		expireAt := e.Time.Add(t.config.TTL)
+		doc.ExpireAt = &expireAt
+	}
+
+	return doc
+}
+
+// insert inserts a batch of documents. The documents are only encoded
+// if they have to be stored in the spill queue
+func (t *Target) insert(documents []any) {
+	var payload []byte
+	if t.sender.Queue != nil {
+		payload, _ = json.Marshal(documents)
+	}
+
+	stored, err := t.sender.DeliverFunc(payload, func() error {
+		return t.insertMany(documents)
+	})
+	t.health.Report(err)
+	if err != nil && !stored {
+		fmt.Fprintf(os.Stderr, "mongolog: failed to insert %d entries: %s\n", len(documents), err)
+	}
+}
+
+// insertPayload inserts the documents of the spill queue
+func (t *Target) insertPayload(payload []byte) error {
+	var documents []*Document
+	if err := json.Unmarshal(payload, &documents); err != nil {
+		return retry.Permanent(err)
+	}
+
+	batch := make([]any, len(documents))
+	for i, doc := range documents {
+		batch[i] = doc
+	}
+	return t.insertMany(batch)
+}
+
+// insertMany executes a single insert of the documents
+func (t *Target) insertMany(documents []any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), t.config.Timeout)
+	defer cancel()
+
+	return t.config.Collection.InsertMany(ctx, documents)
+}
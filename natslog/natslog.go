@@ -0,0 +1,540 @@
+// natslog provides a target that publishes the log entries to a NATS subject.
+//
+// It implements the required subset of the NATS client protocol so no additional
+// dependency is needed. With "JetStream" enabled, every entry is published with a reply
+// subject and the acknowledgment of the stream is awaited, so the entries are persisted.
+// Note that a stream capturing the subject has to exist on the server
+package natslog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/batch"
+	"git.rpjosh.de/RPJosh/go-logger/internal/spill"
+	"git.rpjosh.de/RPJosh/go-logger/retry"
+)
+
+// Config contains the configuration options of the NATS target
+type Config struct {
+
+	// Minimum log level of the entries to publish
+	Level logger.Level
+
+	// URL of the NATS server like "nats://localhost:4222". Use the scheme "tls"
+	// for an encrypted connection. Credentials can be given within the URL
+	URL string
+
+	// Credentials for the authentication (optional)
+	Username string
+	Password string
+	Token    string
+
+	// TLS configuration used for the scheme "tls"
+	TLSConfig *tls.Config
+
+	// TLS options used to build "TLSConfig" if it's nil (see logger.TLSOptions)
+	TLS *logger.TLSOptions
+
+	// Subject the entries are published to. Defaults to "logs"
+	Subject string
+
+	// Append the level to the subject like "logs.error"
+	AppendLevel bool
+
+	// Wait for the acknowledgment of JetStream for every entry
+	JetStream bool
+
+	// Formatter used for the payload. Defaults to JSON
+	Formatter logger.Formatter
+
+	// Maximum number of entries that are published at once. Defaults to 100
+	BatchSize int
+
+	// Maximum duration an entry is kept before it's published. Defaults to 1 second
+	FlushInterval time.Duration
+
+	// Maximum number of entries that are queued. If the queue is full, entries are dropped.
+	// Defaults to 1000
+	QueueSize int
+
+	// Number of attempts to publish a batch. Note that entries may be published
+	// twice if an attempt partially failed. Defaults to 3
+	MaxAttempts int
+
+	// Policy for retrying failed requests like the delays between the attempts.
+	// It replaces "MaxAttempts" if set
+	RetryPolicy *retry.Policy
+
+	// Directory of a persistent queue on disk. Batches that could not be sent are stored
+	// in it and sent again before the next batch or every 30 seconds. Stored batches also survive a restart
+	// of the program. The directory must not be shared with other targets.
+	// The queue is disabled if this is empty
+	SpillDir string
+
+	// Maximum size of the queue on disk in bytes. If it's exceeded, the oldest
+	// batches are dropped. Defaults to 100 MB
+	SpillMaxSize int64
+
+	// Timeout for connecting and waiting for acknowledgments. Defaults to 10 seconds
+	Timeout time.Duration
+}
+
+// Target publishes the entries to NATS
+type Target struct {
+	config  Config
+	url     *url.URL
+	batcher *batch.Batcher[message]
+	health  logger.HealthStatus
+	sender  spill.Sender
+
+	// The connection is only used by the background worker of the batcher
+	conn *connection
+}
+
+// message is a formatted entry with its subject
+type message struct {
+	subject string
+	payload []byte
+}
+
+// New creates a new NATS target with the given configuration.
+// Add it to the field "Targets" of a logger
+func New(config Config) (*Target, error) {
+	if config.URL == "" {
+		config.URL = "nats://localhost:4222"
+	}
+	u, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	if u.User != nil && config.Username == "" && config.Token == "" {
+		config.Password, _ = u.User.Password()
+		if config.Password == "" {
+			config.Token = u.User.Username()
+		} else {
+			config.Username = u.User.Username()
+		}
+	}
+
+	if config.Subject == "" {
+		config.Subject = "logs"
+	}
+	if config.Formatter == nil {
+		config.Formatter = logger.JSONFormatter{}
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = &retry.Policy{MaxAttempts: config.MaxAttempts, InitialDelay: 500 * time.Millisecond}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.TLS != nil && config.TLSConfig == nil {
+		tlsConfig, err := config.TLS.Config()
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS options: %w", err)
+		}
+		config.TLSConfig = tlsConfig
+	}
+
+	t := &Target{config: config, url: u}
+	t.sender = spill.Sender{Name: "natslog", Policy: config.RetryPolicy, Send: t.publishPayload}
+	if config.SpillDir != "" {
+		queue, err := spill.Open(spill.Options{Dir: config.SpillDir, MaxSize: config.SpillMaxSize})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "natslog: failed to open the spill queue: %s\n", err)
+		}
+		t.sender.Queue = queue
+		t.sender.Start()
+	}
+	t.batcher = batch.New(batch.Options[message]{
+		MaxItems:   config.BatchSize,
+		MaxLatency: config.FlushInterval,
+		QueueSize:  config.QueueSize,
+	}, t.publish)
+
+	return t, nil
+}
+
+// Enabled returns whether entries with the level are published
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.config.Level <= level
+}
+
+// Write formats the entry and queues it for publishing
+func (t *Target) Write(e logger.Entry) error {
+	payload, err := t.config.Formatter.Format(e)
+	if err != nil {
+		return err
+	}
+
+	subject := t.config.Subject
+	if t.config.AppendLevel {
+		subject += "." + strings.ToLower(e.Level.String())
+	}
+
+	if !t.batcher.Add(message{subject: subject, payload: payload}) {
+		return errors.New("natslog: queue is full, entry dropped")
+	}
+
+	return nil
+}
+
+// Flush publishes all queued entries
+func (t *Target) Flush() {
+	t.batcher.Flush()
+}
+
+// Stats returns the metrics of the sent batches like their size and the flush latency
+func (t *Target) Stats() batch.Stats {
+	return t.batcher.Stats()
+}
+
+// Health returns the state of the target with the last error of sending a batch
+func (t *Target) Health() logger.SinkHealth {
+	health := t.health.Status()
+	health.QueueDepth = t.batcher.Stats().Queued + t.sender.Len()
+	return health
+}
+
+// Close publishes all queued entries and closes the connection
+func (t *Target) Close() error {
+	t.batcher.Close()
+	if t.conn != nil {
+		t.conn.close()
+		t.conn = nil
+	}
+
+	return t.sender.Close()
+}
+
+// publish publishes a batch of messages. The messages are only encoded
+// if they have to be stored in the spill queue
+func (t *Target) publish(messages []message) {
+	var payload []byte
+	if t.sender.Queue != nil {
+		payload = encodeMessages(messages)
+	}
+
+	stored, err := t.sender.DeliverFunc(payload, func() error {
+		return t.publishMessages(messages)
+	})
+	t.health.Report(err)
+	if err != nil && !stored {
+		fmt.Fprintf(os.Stderr, "natslog: failed to publish %d entries: %s\n", len(messages), err)
+	}
+}
+
+// publishMessages publishes the messages once. The connection is established if needed
+func (t *Target) publishMessages(messages []message) error {
+	if t.conn == nil || t.conn.isClosed() {
+		conn, err := t.connect()
+		if err != nil {
+			return err
+		}
+		t.conn = conn
+	}
+
+	if err := t.conn.publish(messages, t.config.JetStream, t.config.Timeout); err != nil {
+		t.conn.close()
+		t.conn = nil
+		return err
+	}
+	return nil
+}
+
+// publishPayload publishes the messages of the spill queue
+func (t *Target) publishPayload(payload []byte) error {
+	messages, err := decodeMessages(payload)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	return t.publishMessages(messages)
+}
+
+// encodeMessages encodes the messages for the spill queue. Every message is stored
+// as the subject and the size of the payload followed by the payload in the next line
+func encodeMessages(messages []message) []byte {
+	var b bytes.Buffer
+	for _, msg := range messages {
+		b.WriteString(msg.subject + " " + strconv.Itoa(len(msg.payload)) + "\n")
+		b.Write(msg.payload)
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// decodeMessages decodes the messages that were encoded with encodeMessages()
+func decodeMessages(data []byte) ([]message, error) {
+	messages := make([]message, 0)
+	for len(data) > 0 {
+		header, rest, ok := bytes.Cut(data, []byte("\n"))
+		subject, size, _ := strings.Cut(string(header), " ")
+		length, err := strconv.Atoi(size)
+		if !ok || err != nil || length < 0 || len(rest) < length+1 {
+			return nil, errors.New("invalid message in the spill queue")
+		}
+
+		messages = append(messages, message{subject: subject, payload: rest[:length]})
+		data = rest[length+1:]
+	}
+	return messages, nil
+}
+
+// connect connects to the server and performs the handshake
+func (t *Target) connect() (*connection, error) {
+	dialer := &net.Dialer{Timeout: t.config.Timeout}
+	var conn net.Conn
+	var err error
+	if t.url.Scheme == "tls" {
+		tlsConfig := t.config.TLSConfig
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{ServerName: t.url.Hostname()}
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", t.url.Host, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", t.url.Host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &connection{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		pongs:  make(chan struct{}, 1),
+		acks:   make(chan []byte, t.config.BatchSize),
+		closed: make(chan struct{}),
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	c.inbox = "_INBOX." + hex.EncodeToString(id)
+
+	// The server starts with an INFO message
+	conn.SetReadDeadline(time.Now().Add(t.config.Timeout))
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, errors.New("unexpected greeting of the server")
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	options := map[string]any{
+		"verbose":  false,
+		"pedantic": false,
+		"lang":     "go",
+		"version":  "go-logger",
+		"name":     "natslog",
+		"protocol": 1,
+	}
+	if t.config.Username != "" {
+		options["user"] = t.config.Username
+		options["pass"] = t.config.Password
+	}
+	if t.config.Token != "" {
+		options["auth_token"] = t.config.Token
+	}
+	optionsJSON, _ := json.Marshal(options)
+	cmd := "CONNECT " + string(optionsJSON) + "\r\n"
+	if t.config.JetStream {
+		cmd += "SUB " + c.inbox + ".* 1\r\n"
+	}
+	if _, err := conn.Write([]byte(cmd)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	go c.read()
+
+	// Make sure that the server accepted the connection
+	if err := c.ping(t.config.Timeout); err != nil {
+		c.close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// connection is a connection to a NATS server
+type connection struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	inbox  string
+
+	writeSync sync.Mutex
+	pongs     chan struct{}
+	acks      chan []byte
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	err       error
+}
+
+// publish publishes the messages and waits until they were processed by the server
+func (c *connection) publish(messages []message, jetStream bool, timeout time.Duration) error {
+	// Drop late acknowledgments of a previous attempt
+	for len(c.acks) > 0 {
+		<-c.acks
+	}
+
+	var b strings.Builder
+	for i, msg := range messages {
+		b.WriteString("PUB " + msg.subject + " ")
+		if jetStream {
+			b.WriteString(c.inbox + "." + strconv.Itoa(i) + " ")
+		}
+		b.WriteString(strconv.Itoa(len(msg.payload)) + "\r\n")
+		b.Write(msg.payload)
+		b.WriteString("\r\n")
+	}
+	if err := c.write(b.String()); err != nil {
+		return err
+	}
+
+	if !jetStream {
+		return c.ping(timeout)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for range messages {
+		select {
+		case ack := <-c.acks:
+			var resp struct {
+				Error *struct {
+					Description string `json:"description"`
+				} `json:"error"`
+			}
+			if json.Unmarshal(ack, &resp) == nil && resp.Error != nil {
+				return errors.New("JetStream: " + resp.Error.Description)
+			}
+		case <-timer.C:
+			return errors.New("timeout waiting for the acknowledgment of JetStream")
+		case <-c.closed:
+			return c.err
+		}
+	}
+
+	return nil
+}
+
+// ping sends a PING and waits for the PONG of the server
+func (c *connection) ping(timeout time.Duration) error {
+	if err := c.write("PING\r\n"); err != nil {
+		return err
+	}
+
+	select {
+	case <-c.pongs:
+		return nil
+	case <-time.After(timeout):
+		return errors.New("timeout waiting for the server")
+	case <-c.closed:
+		return c.err
+	}
+}
+
+// write writes the raw command to the connection
+func (c *connection) write(cmd string) error {
+	c.writeSync.Lock()
+	defer c.writeSync.Unlock()
+
+	_, err := io.WriteString(c.conn, cmd)
+	return err
+}
+
+// read handles the messages of the server until the connection is closed
+func (c *connection) read() {
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			c.closeWithError(err)
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case line == "PING":
+			c.write("PONG\r\n")
+		case line == "PONG":
+			select {
+			case c.pongs <- struct{}{}:
+			default:
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			c.closeWithError(errors.New("server error: " + strings.Trim(strings.TrimPrefix(line, "-ERR "), "'")))
+			return
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			args := strings.Fields(line)
+			size, _ := strconv.Atoi(args[len(args)-1])
+			payload := make([]byte, size+2)
+			if _, err := io.ReadFull(c.reader, payload); err != nil {
+				c.closeWithError(err)
+				return
+			}
+			select {
+			case c.acks <- payload[:size]:
+			default:
+			}
+		}
+	}
+}
+
+// closeWithError closes the connection and remembers the reason
+func (c *connection) closeWithError(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		c.conn.Close()
+		close(c.closed)
+	})
+}
+
+// close closes the connection
+func (c *connection) close() {
+	c.closeWithError(errors.New("connection closed"))
+}
+
+// isClosed returns whether the connection was closed
+func (c *connection) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,366 @@
+// netlog provides a target that streams the formatted log entries line by line
+// to a TCP or UDP endpoint like the TCP input of Logstash or Fluent Bit.
+//
+// The entries are written by a background worker. If the connection is lost, the worker
+// reconnects automatically. While disconnected, the entries are kept in a bounded buffer.
+// Configure "SpillDir" to store them on disk instead, so that longer outages of the
+// endpoint don't lose entries
+package netlog
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/internal/spill"
+)
+
+// Config contains the configuration options of the network target
+type Config struct {
+
+	// Minimum log level of the entries to send
+	Level logger.Level
+
+	// Network to use. Supported values are "tcp" (default), "udp" and "tls"
+	Network string
+
+	// Address of the endpoint like "localhost:5170"
+	Address string
+
+	// TLS configuration used for the network "tls"
+	TLSConfig *tls.Config
+
+	// TLS options used to build "TLSConfig" if it's nil (see logger.TLSOptions)
+	TLS *logger.TLSOptions
+
+	// Formatter used for the entries. Defaults to JSON
+	Formatter logger.Formatter
+
+	// Delimiter appended after every entry. Defaults to a line break.
+	// Use a null byte ("\x00") for GELF over TCP (see logger.GELFFormatter)
+	Delimiter string
+
+	// Maximum number of entries that are buffered while disconnected.
+	// If the buffer is full, new entries are dropped. Defaults to 1000
+	BufferSize int
+
+	// Delay before reconnecting. It's doubled after every failed attempt
+	// up to "MaxReconnectDelay". Defaults to 1 second and 1 minute
+	ReconnectDelay    time.Duration
+	MaxReconnectDelay time.Duration
+
+	// Timeout for connecting and writing. Defaults to 10 seconds
+	Timeout time.Duration
+
+	// Directory of a persistent queue on disk. While the endpoint is not reachable, the
+	// entries are stored in it and sent after reconnecting. Stored entries also survive
+	// a restart of the program. The directory must not be shared with other targets.
+	// The queue is disabled if this is empty
+	SpillDir string
+
+	// Maximum size of the queue on disk in bytes. If it's exceeded, the oldest
+	// entries are dropped. Defaults to 100 MB
+	SpillMaxSize int64
+}
+
+// Target streams the entries to a network endpoint
+type Target struct {
+	config Config
+
+	queue chan []byte
+	done  chan struct{}
+	once  sync.Once
+	wg    sync.WaitGroup
+
+	conn  net.Conn
+	spill *spill.Queue
+
+	health    logger.HealthStatus
+	connected atomic.Bool
+	dropped   atomic.Uint64
+}
+
+// New creates a new network target with the given configuration and starts
+// the background worker. Add it to the field "Targets" of a logger
+func New(config Config) *Target {
+	if config.Network == "" {
+		config.Network = "tcp"
+	}
+	if config.Formatter == nil {
+		config.Formatter = logger.JSONFormatter{}
+	}
+	if config.Delimiter == "" {
+		config.Delimiter = "\n"
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = 1000
+	}
+	if config.ReconnectDelay <= 0 {
+		config.ReconnectDelay = time.Second
+	}
+	if config.MaxReconnectDelay <= 0 {
+		config.MaxReconnectDelay = time.Minute
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.TLS != nil && config.TLSConfig == nil {
+		tlsConfig, err := config.TLS.Config()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "netlog: invalid TLS options: %s\n", err)
+		}
+		config.TLSConfig = tlsConfig
+	}
+
+	t := &Target{
+		config: config,
+		queue:  make(chan []byte, config.BufferSize),
+		done:   make(chan struct{}),
+	}
+	if config.SpillDir != "" {
+		queue, err := spill.Open(spill.Options{Dir: config.SpillDir, MaxSize: config.SpillMaxSize})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "netlog: failed to open the spill queue: %s\n", err)
+		}
+		t.spill = queue
+	}
+	t.wg.Add(1)
+	go t.run()
+
+	return t
+}
+
+// Enabled returns whether entries with the level are sent
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.config.Level <= level
+}
+
+// Write formats the entry and queues it for sending
+func (t *Target) Write(e logger.Entry) error {
+	line, err := t.config.Formatter.Format(e)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-t.done:
+		return errors.New("netlog: target is closed")
+	default:
+	}
+
+	select {
+	case t.queue <- append(line, t.config.Delimiter...):
+		return nil
+	default:
+		return errors.New("netlog: buffer is full, entry dropped")
+	}
+}
+
+// Health returns the connection state of the target with the number of queued entries
+func (t *Target) Health() logger.SinkHealth {
+	health := t.health.Status()
+	health.Connected = t.connected.Load()
+	health.QueueDepth = len(t.queue)
+	if t.spill != nil {
+		health.QueueDepth += t.spill.Len()
+	}
+	health.Dropped = t.dropped.Load()
+
+	return health
+}
+
+// Close sends the buffered entries if connected and closes the connection
+func (t *Target) Close() error {
+	t.once.Do(func() {
+		close(t.done)
+	})
+	t.wg.Wait()
+
+	return nil
+}
+
+// run writes the queued lines to the connection
+func (t *Target) run() {
+	defer t.wg.Done()
+	defer t.disconnect()
+
+	if t.spill != nil {
+		defer t.spill.Close()
+		t.runSpill()
+		return
+	}
+
+	for {
+		select {
+		case line := <-t.queue:
+			t.send(line)
+		case <-t.done:
+			// Send the remaining lines. If the connection is lost, they are dropped
+			for {
+				select {
+				case line := <-t.queue:
+					if (t.conn == nil && t.connect() != nil) || t.write(line) != nil {
+						t.dropped.Add(uint64(len(t.queue) + 1))
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// runSpill writes the queued lines to the connection. While the endpoint is not reachable,
+// the lines are stored in the spill queue. They are replayed after reconnecting before
+// any new lines are written
+func (t *Target) runSpill() {
+	delay := t.config.ReconnectDelay
+
+	// Channel for the next reconnect. It's nil while connected and the spill queue is empty
+	var reconnect <-chan time.Time
+	if t.spill.Len() > 0 {
+		reconnect = time.After(0)
+	}
+
+	for {
+		select {
+		case line := <-t.queue:
+			if reconnect == nil {
+				if t.connect() == nil && t.write(line) == nil {
+					continue
+				}
+				reconnect = time.After(delay)
+			}
+			t.pushSpill(line)
+		case <-reconnect:
+			if t.replaySpill() {
+				reconnect = nil
+				delay = t.config.ReconnectDelay
+				continue
+			}
+
+			if delay *= 2; delay > t.config.MaxReconnectDelay {
+				delay = t.config.MaxReconnectDelay
+			}
+			reconnect = time.After(delay)
+		case <-t.done:
+			// Send the remaining lines if connected. Otherwise they are kept on disk
+			connected := reconnect == nil || t.replaySpill()
+			for {
+				select {
+				case line := <-t.queue:
+					if !connected || t.connect() != nil || t.write(line) != nil {
+						connected = false
+						t.pushSpill(line)
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// replaySpill sends the lines of the spill queue. It returns whether all lines were sent
+func (t *Target) replaySpill() bool {
+	if t.connect() != nil {
+		return false
+	}
+
+	return t.spill.Replay(t.write) == nil
+}
+
+// pushSpill stores the line in the spill queue
+func (t *Target) pushSpill(line []byte) {
+	dropped, err := t.spill.Push(line)
+	if err != nil {
+		t.dropped.Add(1)
+		fmt.Fprintf(os.Stderr, "netlog: failed to store entry in the spill queue: %s\n", err)
+	} else if dropped > 0 {
+		t.dropped.Add(uint64(dropped))
+		fmt.Fprintf(os.Stderr, "netlog: spill queue is full, dropped %d entries\n", dropped)
+	}
+}
+
+// send writes the line. It reconnects with an increasing delay until the line
+// was written or the target is closed
+func (t *Target) send(line []byte) {
+	delay := t.config.ReconnectDelay
+	for {
+		err := t.connect()
+		if err == nil {
+			if err = t.write(line); err == nil {
+				return
+			}
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-t.done:
+			// Try it a last time when closing
+			if t.connect() != nil || t.write(line) != nil {
+				t.dropped.Add(1)
+			}
+			return
+		}
+
+		if delay *= 2; delay > t.config.MaxReconnectDelay {
+			delay = t.config.MaxReconnectDelay
+		}
+	}
+}
+
+// write writes the line to the connection. The connection is closed on failure
+func (t *Target) write(line []byte) error {
+	t.conn.SetWriteDeadline(time.Now().Add(t.config.Timeout))
+	if _, err := t.conn.Write(line); err != nil {
+		t.health.Report(err)
+		fmt.Fprintf(os.Stderr, "netlog: failed to write to %q: %s\n", t.config.Address, err)
+		t.disconnect()
+		return err
+	}
+
+	return nil
+}
+
+// connect connects to the endpoint if not already connected
+func (t *Target) connect() (err error) {
+	if t.conn != nil {
+		return nil
+	}
+
+	dialer := &net.Dialer{Timeout: t.config.Timeout}
+	switch t.config.Network {
+	case "tls":
+		t.conn, err = tls.DialWithDialer(dialer, "tcp", t.config.Address, t.config.TLSConfig)
+	case "tcp", "udp":
+		t.conn, err = dialer.Dial(t.config.Network, t.config.Address)
+	default:
+		err = fmt.Errorf("unsupported network %q", t.config.Network)
+	}
+	if err != nil {
+		t.conn = nil
+		t.health.Report(err)
+		return err
+	}
+
+	t.connected.Store(true)
+	t.health.Report(nil)
+	return nil
+}
+
+// disconnect closes the connection
+func (t *Target) disconnect() {
+	if t.conn != nil {
+		t.conn.Close()
+		t.conn = nil
+		t.connected.Store(false)
+	}
+}
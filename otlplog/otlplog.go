@@ -0,0 +1,365 @@
+// otlplog provides a target that exports the log entries via the OpenTelemetry
+// logs protocol (OTLP) to an OpenTelemetry Collector or any other compatible backend.
+//
+// The entries are sent in batches as JSON encoded protobuf messages over HTTP ("OTLP/HTTP").
+// OTLP over gRPC is not supported to keep this module free of dependencies
+package otlplog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/batch"
+	"git.rpjosh.de/RPJosh/go-logger/internal/spill"
+	"git.rpjosh.de/RPJosh/go-logger/retry"
+)
+
+// Config contains the configuration options of the OTLP target
+type Config struct {
+
+	// Minimum log level of the entries to export
+	Level logger.Level
+
+	// URL of the OTLP/HTTP logs endpoint. Defaults to "http://localhost:4318/v1/logs"
+	Endpoint string
+
+	// Additional HTTP headers sent with every request (e.g. for authentication)
+	Headers map[string]string
+
+	// Value of the resource attribute "service.name". Defaults to the name of the executable
+	ServiceName string
+
+	// Additional attributes of the resource
+	ResourceAttributes map[string]any
+
+	// Maximum number of entries per request. Defaults to 512
+	BatchSize int
+
+	// Maximum duration an entry is kept before it's exported. Defaults to 5 seconds
+	FlushInterval time.Duration
+
+	// Maximum number of entries that are queued. If the queue is full, entries are dropped.
+	// Defaults to 2048
+	QueueSize int
+
+	// Number of attempts to export a batch. Defaults to 3
+	MaxAttempts int
+
+	// Policy for retrying failed requests like the delays between the attempts.
+	// It replaces "MaxAttempts" if set
+	RetryPolicy *retry.Policy
+
+	// Directory of a persistent queue on disk. Batches that could not be sent are stored
+	// in it and sent again before the next batch or every 30 seconds. Stored batches also survive a restart
+	// of the program. The directory must not be shared with other targets.
+	// The queue is disabled if this is empty
+	SpillDir string
+
+	// Maximum size of the queue on disk in bytes. If it's exceeded, the oldest
+	// batches are dropped. Defaults to 100 MB
+	SpillMaxSize int64
+
+	// Timeout of a single request. Defaults to 10 seconds
+	Timeout time.Duration
+
+	// HTTP client used for the requests
+	HTTPClient *http.Client
+
+	// TLS options used to build the HTTP client if "HTTPClient" is nil (see logger.TLSOptions)
+	TLS *logger.TLSOptions
+}
+
+// Target exports the entries to an OTLP endpoint
+type Target struct {
+	config  Config
+	batcher *batch.Batcher[logRecord]
+	health  logger.HealthStatus
+	sender  spill.Sender
+}
+
+// New creates a new OTLP target with the given configuration.
+// Add it to the field "Targets" of a logger
+func New(config Config) *Target {
+	if config.Endpoint == "" {
+		config.Endpoint = "http://localhost:4318/v1/logs"
+	}
+	if config.ServiceName == "" {
+		config.ServiceName = filepath.Base(os.Args[0])
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 512
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 2048
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = &retry.Policy{MaxAttempts: config.MaxAttempts, InitialDelay: time.Second}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.HTTPClient == nil && config.TLS != nil {
+		client, err := config.TLS.HTTPClient(config.Timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "otlplog: invalid TLS options: %s\n", err)
+		}
+		config.HTTPClient = client
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: config.Timeout}
+	}
+
+	t := &Target{config: config}
+	t.sender = spill.Sender{Name: "otlplog", Policy: config.RetryPolicy, Send: t.send}
+	if config.SpillDir != "" {
+		queue, err := spill.Open(spill.Options{Dir: config.SpillDir, MaxSize: config.SpillMaxSize})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "otlplog: failed to open the spill queue: %s\n", err)
+		}
+		t.sender.Queue = queue
+		t.sender.Start()
+	}
+	t.batcher = batch.New(batch.Options[logRecord]{
+		MaxItems:   config.BatchSize,
+		MaxLatency: config.FlushInterval,
+		QueueSize:  config.QueueSize,
+	}, t.export)
+
+	return t
+}
+
+// Enabled returns whether entries with the level are exported
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.config.Level <= level
+}
+
+// Write queues the entry for the export
+func (t *Target) Write(e logger.Entry) error {
+	if !t.batcher.Add(newLogRecord(e)) {
+		return errors.New("otlplog: queue is full, entry dropped")
+	}
+
+	return nil
+}
+
+// Flush exports all queued entries
+func (t *Target) Flush() {
+	t.batcher.Flush()
+}
+
+// Stats returns the metrics of the sent batches like their size and the flush latency
+func (t *Target) Stats() batch.Stats {
+	return t.batcher.Stats()
+}
+
+// Health returns the state of the target with the last error of sending a batch
+func (t *Target) Health() logger.SinkHealth {
+	health := t.health.Status()
+	health.QueueDepth = t.batcher.Stats().Queued + t.sender.Len()
+	return health
+}
+
+// Close exports all queued entries and stops the background worker
+func (t *Target) Close() error {
+	t.batcher.Close()
+	return t.sender.Close()
+}
+
+// export sends a batch of log records to the endpoint
+func (t *Target) export(records []logRecord) {
+	body, err := json.Marshal(exportRequest{ResourceLogs: []resourceLogs{{
+		Resource: resource{Attributes: t.getResourceAttributes()},
+		ScopeLogs: []scopeLogs{{
+			Scope:      scope{Name: "git.rpjosh.de/RPJosh/go-logger"},
+			LogRecords: records,
+		}},
+	}}})
+	if err != nil {
+		return
+	}
+
+	stored, err := t.sender.Deliver(body)
+	t.health.Report(err)
+	if err != nil && !stored {
+		fmt.Fprintf(os.Stderr, "otlplog: failed to export %d log records: %s\n", len(records), err)
+	}
+}
+
+// send executes a single export request. Client errors (except "429 Too Many Requests")
+// are not retried
+func (t *Target) send(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, t.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	err = errors.New("unexpected status code " + strconv.Itoa(resp.StatusCode))
+	if resp.StatusCode >= 400 && resp.StatusCode <= 499 && resp.StatusCode != http.StatusTooManyRequests {
+		return retry.Permanent(err)
+	}
+	return err
+}
+
+// getResourceAttributes returns the attributes of the resource
+func (t *Target) getResourceAttributes() []keyValue {
+	attributes := []keyValue{{Key: "service.name", Value: newAnyValue(t.config.ServiceName)}}
+	for key, value := range t.config.ResourceAttributes {
+		attributes = append(attributes, keyValue{Key: key, Value: newAnyValue(value)})
+	}
+
+	return attributes
+}
+
+// getSeverityNumber returns the OpenTelemetry severity number for the level.
+// Custom levels get the number of the next lower predefined level
+func getSeverityNumber(level logger.Level) int {
+	switch {
+	case level < logger.LevelDebug:
+		return 1
+	case level < logger.LevelInfo:
+		return 5
+	case level < logger.LevelWarning:
+		return 9
+	case level < logger.LevelError:
+		return 13
+	case level < logger.LevelPanic:
+		return 17
+	case level < logger.LevelFatal:
+		return 18
+	default:
+		return 21
+	}
+}
+
+// newLogRecord converts the entry to a log record of OTLP.
+// The fields "trace_id" and "span_id" are used for the trace correlation
+func newLogRecord(e logger.Entry) logRecord {
+	record := logRecord{
+		TimeUnixNano:         strconv.FormatInt(e.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       getSeverityNumber(e.Level),
+		SeverityText:         e.Level.String(),
+		Body:                 newAnyValue(e.Message),
+	}
+
+	if e.Line > 0 {
+		record.Attributes = append(record.Attributes,
+			keyValue{Key: "code.filepath", Value: newAnyValue(e.File)},
+			keyValue{Key: "code.lineno", Value: newAnyValue(e.Line)},
+		)
+	}
+	if e.LoggerName != "" {
+		record.Attributes = append(record.Attributes, keyValue{Key: "logger.name", Value: newAnyValue(e.LoggerName)})
+	}
+
+	for key, value := range e.Fields {
+		switch key {
+		case "trace_id":
+			record.TraceID = fmt.Sprintf("%v", value)
+		case "span_id":
+			record.SpanID = fmt.Sprintf("%v", value)
+		default:
+			record.Attributes = append(record.Attributes, keyValue{Key: key, Value: newAnyValue(value)})
+		}
+	}
+
+	return record
+}
+
+// newAnyValue converts a value to the AnyValue message of OTLP
+func newAnyValue(value any) anyValue {
+	switch v := value.(type) {
+	case string:
+		return anyValue{StringValue: &v}
+	case bool:
+		return anyValue{BoolValue: &v}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		str := fmt.Sprintf("%d", v)
+		return anyValue{IntValue: &str}
+	case float32:
+		f := float64(v)
+		return anyValue{DoubleValue: &f}
+	case float64:
+		return anyValue{DoubleValue: &v}
+	case error:
+		str := v.Error()
+		return anyValue{StringValue: &str}
+	default:
+		str := fmt.Sprintf("%v", v)
+		return anyValue{StringValue: &str}
+	}
+}
+
+// JSON representation of the OTLP messages
+
+type exportRequest struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeLogs struct {
+	Scope      scope       `json:"scope"`
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type logRecord struct {
+	TimeUnixNano         string     `json:"timeUnixNano"`
+	ObservedTimeUnixNano string     `json:"observedTimeUnixNano"`
+	SeverityNumber       int        `json:"severityNumber"`
+	SeverityText         string     `json:"severityText"`
+	Body                 anyValue   `json:"body"`
+	Attributes           []keyValue `json:"attributes,omitempty"`
+	TraceID              string     `json:"traceId,omitempty"`
+	SpanID               string     `json:"spanId,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
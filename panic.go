@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// RecoverAndLog recovers from a panic and logs the recovered value together with
+// the stack trace with the level panic. The program continues afterwards.
+// It has to be called directly by defer:
+//
+//	defer l.RecoverAndLog()
+func (l *Logger) RecoverAndLog() {
+	if err := recover(); err != nil {
+		l.logRecovered(err)
+	}
+}
+
+// RecoverAndRepanic recovers from a panic, logs the recovered value together with
+// the stack trace and panics again with the same value.
+// It has to be called directly by defer:
+//
+//	defer l.RecoverAndRepanic()
+func (l *Logger) RecoverAndRepanic() {
+	if err := recover(); err != nil {
+		l.logRecovered(err)
+		panic(err)
+	}
+}
+
+// RecoverAndLog recovers from a panic and logs it with the global logger.
+// It has to be called directly by defer
+func RecoverAndLog() {
+	if err := recover(); err != nil {
+		dLogger.logRecovered(err)
+	}
+}
+
+// RecoverAndRepanic recovers from a panic, logs it with the global logger and
+// panics again. It has to be called directly by defer
+func RecoverAndRepanic() {
+	if err := recover(); err != nil {
+		dLogger.logRecovered(err)
+		panic(err)
+	}
+}
+
+// logRecovered logs the recovered value of a panic with the current stack trace
+func (l *Logger) logRecovered(err any) {
+	l.Log(LevelPanic, "Recovered from panic: %v\n%s", err, debug.Stack())
+}
+
+// formatMessage returns the message with the replace values applied
+func formatMessage(message string, parameters ...any) string {
+	if len(parameters) > 0 {
+		return fmt.Sprintf(message, parameters...)
+	}
+
+	return message
+}
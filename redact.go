@@ -0,0 +1,133 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RedactionConfig contains configuration options to mask secrets within the messages
+// and the structured fields. The entries are redacted before the hooks are invoked,
+// so no hook or target receives the unmasked values
+type RedactionConfig struct {
+
+	// Names of fields whose values are always replaced (case-insensitive). Fields
+	// of groups like "request.password" are matched by their last segment.
+	// Occurrences like "password=value" or "token: value" in the message are masked too.
+	// Defaults to "password", "passwd", "secret", "token", "authorization" and "api_key"
+	Keys []string
+
+	// Regular expressions whose matches are replaced within the message and
+	// the values of the fields. Invalid expressions are logged as an error
+	Patterns []string
+
+	// Text that replaces the secrets. Defaults to "***"
+	Replacement string
+}
+
+// redactor applies a compiled RedactionConfig to the entries
+type redactor struct {
+
+	// Configuration the redactor was created from
+	config *RedactionConfig
+
+	keys        map[string]struct{}
+	keyPattern  *regexp.Regexp
+	patterns    []*regexp.Regexp
+	replacement string
+}
+
+// newRedactor compiles the configuration. Errors are logged with the given logger
+func newRedactor(config *RedactionConfig, l *Logger) *redactor {
+	r := &redactor{config: config, keys: map[string]struct{}{}, replacement: config.Replacement}
+	if r.replacement == "" {
+		r.replacement = "***"
+	}
+
+	keys := config.Keys
+	if len(keys) == 0 {
+		keys = []string{"password", "passwd", "secret", "token", "authorization", "api_key"}
+	}
+	quoted := make([]string, 0, len(keys))
+	for _, key := range keys {
+		r.keys[strings.ToLower(key)] = struct{}{}
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+	r.keyPattern = regexp.MustCompile(`(?i)\b(` + strings.Join(quoted, "|") + `)(\s*[=:]\s*)("[^"]*"|[^\s,;&]+)`)
+
+	for _, pattern := range config.Patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			l.Log(LevelError, "Invalid redaction pattern '%s'\n%s", pattern, err)
+			continue
+		}
+		r.patterns = append(r.patterns, compiled)
+	}
+
+	return r
+}
+
+// redact returns the entry with all secrets masked. The fields of the entry
+// are copied before they are modified
+func (r *redactor) redact(e Entry) Entry {
+	e.Message = r.redactString(e.Message)
+
+	var fields map[string]any
+	for key, value := range e.Fields {
+		redacted, changed := r.redactField(key, value)
+		if !changed {
+			continue
+		}
+
+		if fields == nil {
+			fields = make(map[string]any, len(e.Fields))
+			for key, value := range e.Fields {
+				fields[key] = value
+			}
+		}
+		fields[key] = redacted
+	}
+	if fields != nil {
+		e.Fields = fields
+	}
+
+	return e
+}
+
+// redactField returns the masked value of the field and whether it was changed
+func (r *redactor) redactField(key string, value any) (any, bool) {
+	name := strings.ToLower(key)
+	if i := strings.LastIndexByte(name, '.'); i != -1 {
+		name = name[i+1:]
+	}
+	if _, ok := r.keys[name]; ok {
+		return r.replacement, true
+	}
+
+	var text string
+	switch v := value.(type) {
+	case string:
+		text = v
+	case error:
+		text = v.Error()
+	case fmt.Stringer:
+		text = v.String()
+	default:
+		return value, false
+	}
+
+	if redacted := r.redactString(text); redacted != text {
+		return redacted, true
+	}
+	return value, false
+}
+
+// redactString masks the "key=value" pairs of the configured keys and all matches of the patterns
+func (r *redactor) redactString(text string) string {
+	text = r.keyPattern.ReplaceAllString(text, "${1}${2}"+strings.ReplaceAll(r.replacement, "$", "$$"))
+	for _, pattern := range r.patterns {
+		text = pattern.ReplaceAllLiteralString(text, r.replacement)
+	}
+
+	return text
+}
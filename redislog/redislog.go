@@ -0,0 +1,447 @@
+// redislog provides a target that writes the log entries to Redis.
+//
+// The entries are either appended to a stream (XADD) or pushed to a list (RPUSH).
+// Both can be trimmed to a maximum length. The required subset of the Redis protocol
+// is implemented directly so no additional dependency is needed
+package redislog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/batch"
+	"git.rpjosh.de/RPJosh/go-logger/internal/spill"
+	"git.rpjosh.de/RPJosh/go-logger/retry"
+)
+
+// Mode defines the data type the entries are written to
+type Mode uint8
+
+const (
+	// ModeStream appends the entries to a stream. Every entry contains the fields
+	// "level", "time", "message", "logger", "source" and the structured fields
+	ModeStream Mode = iota
+
+	// ModeList pushes the formatted entries to a list
+	ModeList
+)
+
+// Config contains the configuration options of the Redis target
+type Config struct {
+
+	// Minimum log level of the entries to write
+	Level logger.Level
+
+	// Address of the Redis server. Defaults to "localhost:6379"
+	Address string
+
+	// Credentials for the authentication (optional). The user name is only
+	// supported by Redis 6 and newer
+	Username string
+	Password string
+
+	// Number of the database to use
+	DB int
+
+	// Use TLS for the connection if not nil
+	TLSConfig *tls.Config
+
+	// TLS options used to build "TLSConfig" if it's nil (see logger.TLSOptions).
+	// Setting them enables TLS for the connection
+	TLS *logger.TLSOptions
+
+	// Key of the stream or list. Defaults to "logs"
+	Key string
+
+	// Data type the entries are written to
+	Mode Mode
+
+	// Maximum number of entries kept in the stream or list. Older entries are removed.
+	// A stream is trimmed approximately for a better performance unless "ExactTrim" is set
+	MaxLen    int64
+	ExactTrim bool
+
+	// Formatter used for the entries of a list. Defaults to JSON
+	Formatter logger.Formatter
+
+	// Maximum number of entries written at once. Defaults to 100
+	BatchSize int
+
+	// Maximum duration an entry is kept before it's written. Defaults to 1 second
+	FlushInterval time.Duration
+
+	// Maximum number of entries that are queued. If the queue is full, entries are dropped.
+	// Defaults to 1000
+	QueueSize int
+
+	// Number of attempts to write a batch. Defaults to 3
+	MaxAttempts int
+
+	// Policy for retrying failed requests like the delays between the attempts.
+	// It replaces "MaxAttempts" if set
+	RetryPolicy *retry.Policy
+
+	// Directory of a persistent queue on disk. Batches that could not be sent are stored
+	// in it and sent again before the next batch or every 30 seconds. Stored batches also survive a restart
+	// of the program. The directory must not be shared with other targets.
+	// The queue is disabled if this is empty
+	SpillDir string
+
+	// Maximum size of the queue on disk in bytes. If it's exceeded, the oldest
+	// batches are dropped. Defaults to 100 MB
+	SpillMaxSize int64
+
+	// Timeout for connecting and executing the commands. Defaults to 10 seconds
+	Timeout time.Duration
+}
+
+// Target writes the entries to Redis
+type Target struct {
+	config  Config
+	batcher *batch.Batcher[[]string]
+	health  logger.HealthStatus
+	sender  spill.Sender
+
+	// The connection is only used by the background worker of the batcher
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// New creates a new Redis target with the given configuration.
+// Add it to the field "Targets" of a logger
+func New(config Config) *Target {
+	if config.Address == "" {
+		config.Address = "localhost:6379"
+	}
+	if config.Key == "" {
+		config.Key = "logs"
+	}
+	if config.Formatter == nil {
+		config.Formatter = logger.JSONFormatter{}
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = &retry.Policy{MaxAttempts: config.MaxAttempts, InitialDelay: 500 * time.Millisecond}
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.TLS != nil && config.TLSConfig == nil {
+		tlsConfig, err := config.TLS.Config()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "redislog: invalid TLS options: %s\n", err)
+
+			// The connection is still encrypted
+			tlsConfig = &tls.Config{}
+		}
+		config.TLSConfig = tlsConfig
+	}
+
+	t := &Target{config: config}
+	t.sender = spill.Sender{Name: "redislog", Policy: config.RetryPolicy, Send: t.executePayload}
+	if config.SpillDir != "" {
+		queue, err := spill.Open(spill.Options{Dir: config.SpillDir, MaxSize: config.SpillMaxSize})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "redislog: failed to open the spill queue: %s\n", err)
+		}
+		t.sender.Queue = queue
+		t.sender.Start()
+	}
+	t.batcher = batch.New(batch.Options[[]string]{
+		MaxItems:   config.BatchSize,
+		MaxLatency: config.FlushInterval,
+		QueueSize:  config.QueueSize,
+	}, t.send)
+
+	return t
+}
+
+// Enabled returns whether entries with the level are written
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.config.Level <= level
+}
+
+// Write queues the entry for writing
+func (t *Target) Write(e logger.Entry) error {
+	cmd, err := t.getCommand(e)
+	if err != nil {
+		return err
+	}
+
+	if !t.batcher.Add(cmd) {
+		return errors.New("redislog: queue is full, entry dropped")
+	}
+
+	return nil
+}
+
+// Flush writes all queued entries
+func (t *Target) Flush() {
+	t.batcher.Flush()
+}
+
+// Stats returns the metrics of the sent batches like their size and the flush latency
+func (t *Target) Stats() batch.Stats {
+	return t.batcher.Stats()
+}
+
+// Health returns the state of the target with the last error of sending a batch
+func (t *Target) Health() logger.SinkHealth {
+	health := t.health.Status()
+	health.QueueDepth = t.batcher.Stats().Queued + t.sender.Len()
+	return health
+}
+
+// Close writes all queued entries and closes the connection
+func (t *Target) Close() error {
+	t.batcher.Close()
+	t.disconnect()
+
+	return t.sender.Close()
+}
+
+// getCommand returns the command that writes the entry
+func (t *Target) getCommand(e logger.Entry) ([]string, error) {
+	if t.config.Mode == ModeList {
+		value, err := t.config.Formatter.Format(e)
+		if err != nil {
+			return nil, err
+		}
+		return []string{"RPUSH", t.config.Key, string(value)}, nil
+	}
+
+	cmd := []string{"XADD", t.config.Key}
+	if t.config.MaxLen > 0 {
+		cmd = append(cmd, "MAXLEN")
+		if !t.config.ExactTrim {
+			cmd = append(cmd, "~")
+		}
+		cmd = append(cmd, strconv.FormatInt(t.config.MaxLen, 10))
+	}
+	cmd = append(cmd, "*",
+		"level", e.Level.String(),
+		"time", e.Time.Format(time.RFC3339Nano),
+		"message", e.Message,
+	)
+	if e.LoggerName != "" {
+		cmd = append(cmd, "logger", e.LoggerName)
+	}
+	if e.Line > 0 {
+		cmd = append(cmd, "source", filepath.Base(e.File)+":"+strconv.Itoa(e.Line))
+	}
+	for key, value := range e.Fields {
+		cmd = append(cmd, key, fmt.Sprintf("%v", value))
+	}
+
+	return cmd, nil
+}
+
+// send executes the commands of a batch within a pipeline
+func (t *Target) send(commands [][]string) {
+	if t.config.Mode == ModeList && t.config.MaxLen > 0 {
+		commands = append(commands, []string{"LTRIM", t.config.Key, strconv.FormatInt(-t.config.MaxLen, 10), "-1"})
+	}
+
+	payload := append([]byte(strconv.Itoa(len(commands))+"\n"), encodeCommands(commands)...)
+	stored, err := t.sender.Deliver(payload)
+	t.health.Report(err)
+	if err != nil && !stored {
+		fmt.Fprintf(os.Stderr, "redislog: failed to write %d entries: %s\n", len(commands), err)
+	}
+}
+
+// executePayload executes the pipeline of the payload. The payload starts with the number of
+// commands followed by a line break. On a network error the connection is closed so that the
+// next attempt reconnects. Error replies of the server to the commands are not retried because
+// they would fail again
+func (t *Target) executePayload(payload []byte) error {
+	header, pipeline, _ := bytes.Cut(payload, []byte("\n"))
+	count, err := strconv.Atoi(string(header))
+	if err != nil {
+		return retry.Permanent(errors.New("invalid commands in the spill queue"))
+	}
+
+	err = t.execute(pipeline, count)
+	if isRedisError(err) {
+		return retry.Permanent(err)
+	}
+	if err != nil {
+		t.disconnect()
+	}
+	return err
+}
+
+// execute sends the pipeline of commands and reads all replies. The first error reply is returned
+func (t *Target) execute(pipeline []byte, count int) error {
+	if err := t.connect(); err != nil {
+		return connectError{err: err}
+	}
+
+	t.conn.SetDeadline(time.Now().Add(t.config.Timeout))
+	if _, err := t.conn.Write(pipeline); err != nil {
+		return err
+	}
+
+	var rtc error
+	for i := 0; i < count; i++ {
+		if err := readReply(t.reader); err != nil {
+			if !isRedisError(err) {
+				return err
+			}
+			if rtc == nil {
+				rtc = err
+			}
+		}
+	}
+
+	return rtc
+}
+
+// connect connects to the server if not already connected and selects the database
+func (t *Target) connect() (err error) {
+	if t.conn != nil {
+		return nil
+	}
+
+	dialer := &net.Dialer{Timeout: t.config.Timeout}
+	if t.config.TLSConfig != nil {
+		t.conn, err = tls.DialWithDialer(dialer, "tcp", t.config.Address, t.config.TLSConfig)
+	} else {
+		t.conn, err = dialer.Dial("tcp", t.config.Address)
+	}
+	if err != nil {
+		t.conn = nil
+		return err
+	}
+	t.reader = bufio.NewReader(t.conn)
+
+	commands := make([][]string, 0, 2)
+	if t.config.Username != "" {
+		commands = append(commands, []string{"AUTH", t.config.Username, t.config.Password})
+	} else if t.config.Password != "" {
+		commands = append(commands, []string{"AUTH", t.config.Password})
+	}
+	if t.config.DB != 0 {
+		commands = append(commands, []string{"SELECT", strconv.Itoa(t.config.DB)})
+	}
+	if len(commands) > 0 {
+		if err = t.execute(encodeCommands(commands), len(commands)); err != nil {
+			t.disconnect()
+			return err
+		}
+	}
+
+	return nil
+}
+
+// disconnect closes the connection
+func (t *Target) disconnect() {
+	if t.conn != nil {
+		t.conn.Close()
+		t.conn = nil
+	}
+}
+
+// redisError is an error reply of the server
+type redisError string
+
+func (e redisError) Error() string { return string(e) }
+
+// connectError is an error while connecting to the server. In contrast to the replies to the
+// commands of a batch, error replies during the connection (like a wrong password) are retried.
+// It doesn't wrap the error, so that it's not detected as redisError
+type connectError struct {
+	err error
+}
+
+func (e connectError) Error() string { return "failed to connect: " + e.err.Error() }
+
+// isRedisError returns whether the error was returned by the server.
+// The connection is still usable in this case
+func isRedisError(err error) bool {
+	var rErr redisError
+	return errors.As(err, &rErr)
+}
+
+// encodeCommands encodes the commands as pipeline
+func encodeCommands(commands [][]string) []byte {
+	var b strings.Builder
+	for _, cmd := range commands {
+		writeCommand(&b, cmd)
+	}
+	return []byte(b.String())
+}
+
+// writeCommand appends the command encoded as RESP array to the builder
+func writeCommand(b *strings.Builder, cmd []string) {
+	b.WriteString("*" + strconv.Itoa(len(cmd)) + "\r\n")
+	for _, arg := range cmd {
+		b.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+}
+
+// readReply reads a single reply and discards its value
+func readReply(r *bufio.Reader) error {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return errors.New("invalid reply of the server")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return nil
+	case '-':
+		return redisError(line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return err
+		}
+		if size >= 0 {
+			_, err = r.Discard(size + 2)
+		}
+		return err
+	case '*':
+		count, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return err
+		}
+		var rtc error
+		for i := 0; i < count; i++ {
+			if err := readReply(r); err != nil {
+				if !isRedisError(err) {
+					return err
+				}
+				rtc = err
+			}
+		}
+		return rtc
+	default:
+		return errors.New("invalid reply of the server")
+	}
+}
@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"sort"
+	"sync"
+)
+
+// Registered loggers by their name
+var registry struct {
+	sync.RWMutex
+	loggers map[string]*Logger
+}
+
+// Register stores the logger under the given name so that other parts of the
+// application can retrieve it with Get(). An existing logger with the same name is replaced
+func Register(name string, l *Logger) {
+	registry.Lock()
+	if registry.loggers == nil {
+		registry.loggers = map[string]*Logger{}
+	}
+	registry.loggers[name] = l
+	registry.Unlock()
+}
+
+// Unregister removes the logger with the given name from the registry
+func Unregister(name string) {
+	registry.Lock()
+	delete(registry.loggers, name)
+	registry.Unlock()
+}
+
+// Lookup returns the logger registered with the given name. The returned boolean
+// is false if no logger was registered
+func Lookup(name string) (*Logger, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	l, ok := registry.loggers[name]
+	return l, ok
+}
+
+// Get returns the logger registered with the given name (see Register()).
+// If no logger was registered, the global logger is returned
+func Get(name string) *Logger {
+	if l, ok := Lookup(name); ok {
+		return l
+	}
+
+	return GetGlobalLogger()
+}
+
+// ConfigureAll calls the function for every registered logger sorted by the name.
+// Use this to reconfigure all loggers at once like changing the level:
+//
+//	logger.ConfigureAll(func(name string, l *logger.Logger) {
+//		l.SetLevel(logger.LevelDebug)
+//	})
+func ConfigureAll(fn func(name string, l *Logger)) {
+	registry.RLock()
+	names := make([]string, 0, len(registry.loggers))
+	for name := range registry.loggers {
+		names = append(names, name)
+	}
+	loggers := make(map[string]*Logger, len(registry.loggers))
+	for name, l := range registry.loggers {
+		loggers[name] = l
+	}
+	registry.RUnlock()
+
+	// The lock is released so that the function is able to register loggers
+	sort.Strings(names)
+	for _, name := range names {
+		fn(name, loggers[name])
+	}
+}
@@ -0,0 +1,128 @@
+// retry provides a retry policy with an exponential backoff. It's used by the network
+// targets and can be used for custom targets:
+//
+//	policy := retry.Policy{MaxAttempts: 5, InitialDelay: time.Second, Jitter: 0.2}
+//	err := policy.Do(ctx, func() error {
+//		return send(batch)
+//	})
+package retry
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+)
+
+// Policy defines how often and with which delays a failed operation is retried
+type Policy struct {
+
+	// Maximum number of attempts including the first one. Defaults to 3
+	MaxAttempts int
+
+	// Delay before the first retry. Defaults to 1 second
+	InitialDelay time.Duration
+
+	// Maximum delay between two attempts. No limit is applied if this is <= 0
+	MaxDelay time.Duration
+
+	// Factor by which the delay grows after every attempt. Defaults to 2
+	Multiplier float64
+
+	// Randomizes the delays by the given fraction (e.g. 0.2 for ±20%) so that multiple
+	// clients don't retry at the same time. Disabled if this is <= 0
+	Jitter float64
+
+	// Function that returns whether an error should be retried. By default
+	// all errors except the ones wrapped with Permanent() are retried
+	Retryable func(err error) bool
+}
+
+// permanentError marks an error that should not be retried
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps the error so that it's not retried
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// IsPermanent returns whether the error was wrapped with Permanent
+func IsPermanent(err error) bool {
+	return errors.As(err, &permanentError{})
+}
+
+// Do calls the function until it succeeds or the maximum number of attempts is reached.
+// It stops as well if the error should not be retried or the context is done.
+// The last error of the function is returned
+func (p Policy) Do(ctx context.Context, fn func() error) (err error) {
+	attempts := p.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+
+	for attempt := 0; attempt < attempts; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		if !p.retryable(err) {
+			return err
+		}
+
+		if attempt+1 < attempts {
+			timer := time.NewTimer(p.Delay(attempt))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			}
+		}
+	}
+
+	return err
+}
+
+// Delay returns the delay after the failed attempt. The first attempt is 0
+func (p Policy) Delay(attempt int) time.Duration {
+	delay := float64(p.InitialDelay)
+	if delay <= 0 {
+		delay = float64(time.Second)
+	}
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+
+	for i := 0; i < attempt; i++ {
+		delay *= multiplier
+		if p.MaxDelay > 0 && delay >= float64(p.MaxDelay) {
+			break
+		}
+	}
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
+
+// retryable returns whether the error should be retried
+func (p Policy) retryable(err error) bool {
+	if IsPermanent(err) {
+		return false
+	}
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+
+	return true
+}
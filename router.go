@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"errors"
+	"reflect"
+)
+
+// LevelRouter is a target that writes every entry to the targets that are
+// registered for the level of the entry. Entries of other levels are dropped.
+// Use it to write the levels to different destinations within one logger:
+//
+//	errorFile := logger.NewFileTarget(&logger.FileLogger{Path: "error.log"})
+//	logger.NewLogger(&logger.Logger{
+//		File: &logger.FileLogger{Path: "app.log"},
+//		Targets: []logger.Target{
+//			logger.NewLevelRouter(map[logger.Level]logger.Target{
+//				logger.LevelError: errorFile,
+//				logger.LevelFatal: errorFile,
+//			}),
+//		},
+//	})
+type LevelRouter struct {
+	routes map[Level]Target
+}
+
+// NewLevelRouter creates a router that writes the entries of a level to the
+// target of the mapping
+func NewLevelRouter(routes map[Level]Target) *LevelRouter {
+	return &LevelRouter{routes: routes}
+}
+
+// Enabled returns whether a target is registered for the level and writes entries of it
+func (r *LevelRouter) Enabled(level Level) bool {
+	target, ok := r.routes[level]
+	return ok && target.Enabled(level)
+}
+
+// Write writes the entry to the target registered for its level
+func (r *LevelRouter) Write(e Entry) error {
+	if target, ok := r.routes[e.Level]; ok && target.Enabled(e.Level) {
+		return target.Write(e)
+	}
+
+	return nil
+}
+
+// Close closes all targets. Targets registered for multiple levels are closed only once
+// if they are comparable like pointers
+func (r *LevelRouter) Close() error {
+	closed := make([]Target, 0, len(r.routes))
+	var errs []error
+	for _, target := range r.routes {
+		if containsTarget(closed, target) {
+			continue
+		}
+		closed = append(closed, target)
+
+		if err := target.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// containsTarget returns whether the target is within the list. Targets that are not
+// comparable (like a struct with a slice) are never equal to another target
+func containsTarget(targets []Target, target Target) bool {
+	if !reflect.ValueOf(target).Comparable() {
+		return false
+	}
+
+	for _, t := range targets {
+		if reflect.TypeOf(t) == reflect.TypeOf(target) && t == target {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NeedsSource returns whether one of the targets requires the source of the entries
+func (r *LevelRouter) NeedsSource() bool {
+	for _, target := range r.routes {
+		if t, ok := target.(SourceTarget); ok && t.NeedsSource() {
+			return true
+		}
+	}
+
+	return false
+}
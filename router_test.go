@@ -0,0 +1,39 @@
+package logger
+
+import "testing"
+
+// closeCounter counts the calls of Close(). It's not comparable because of the slice
+type closeCounter struct {
+	closed *int
+	tags   []string
+}
+
+func (c closeCounter) Enabled(level Level) bool { return true }
+func (c closeCounter) Write(e Entry) error      { return nil }
+func (c closeCounter) Close() error {
+	*c.closed++
+	return nil
+}
+
+func TestLevelRouterCloseNotComparable(t *testing.T) {
+	var closedValue, closedPointer int
+	value := closeCounter{closed: &closedValue, tags: []string{"value"}}
+	pointer := &closeCounter{closed: &closedPointer}
+
+	router := NewLevelRouter(map[Level]Target{
+		LevelDebug: value,
+		LevelInfo:  value,
+		LevelError: pointer,
+		LevelFatal: pointer,
+	})
+	if err := router.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if closedValue != 2 {
+		t.Errorf("expected the not comparable target to be closed for every level, got %d", closedValue)
+	}
+	if closedPointer != 1 {
+		t.Errorf("expected the shared target to be closed once, got %d", closedPointer)
+	}
+}
@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// SamplingConfig contains configuration options to throttle messages that are
+// logged repeatedly, so that a tight loop does not flood the log destinations.
+//
+// Messages are considered identical if they have the same level and the same message
+// before the replace values were applied. Within each tick the first "Initial"
+// identical messages are logged. Afterwards only every "Thereafter"th message is logged.
+// When the next identical message is logged after a tick, a summary with the number of
+// suppressed messages is logged before.
+//
+// Messages with the level panic or fatal are never dropped
+type SamplingConfig struct {
+
+	// Number of identical messages that are logged within each tick
+	Initial int
+
+	// After "Initial" messages were logged within a tick only every "Thereafter"th
+	// message is logged. If <= 0 all further identical messages of the tick are dropped
+	Thereafter int
+
+	// Duration of a tick. Defaults to one second
+	Tick time.Duration
+
+	// Maximum number of messages that are logged per second regardless of their content.
+	// Dropped messages are summarized after the second. A value <= 0 disables the limit
+	MaxPerSecond int
+}
+
+// sampler keeps track of the messages logged within a tick
+type sampler struct {
+	config SamplingConfig
+
+	lock     sync.Mutex
+	counters map[samplerKey]*samplerCounter
+
+	// State of the rate limiter
+	rateStart   time.Time
+	rateCount   int
+	rateDropped int
+}
+
+// samplerKey identifies identical messages
+type samplerKey struct {
+	level   Level
+	message string
+}
+
+// samplerCounter counts the identical messages within a tick
+type samplerCounter struct {
+	tickStart  time.Time
+	count      int
+	suppressed int
+}
+
+// Maximum number of different messages to keep track of.
+// The counters are reset if this limit is reached
+const maxSamplerCounters = 10000
+
+func newSampler(config SamplingConfig) *sampler {
+	if config.Tick <= 0 {
+		config.Tick = time.Second
+	}
+
+	return &sampler{
+		config:   config,
+		counters: make(map[samplerKey]*samplerCounter),
+	}
+}
+
+// sample returns whether the entry should be written.
+// Summaries of suppressed messages are written directly to the logger
+func (s *sampler) sample(l *Logger, e Entry) bool {
+	if e.Level >= LevelPanic {
+		return true
+	}
+
+	message := e.template
+	if message == "" {
+		message = e.Message
+	}
+
+	s.lock.Lock()
+	var summaries []Entry
+
+	// Check the limit of messages per second
+	if s.config.MaxPerSecond > 0 {
+		if e.Time.Sub(s.rateStart) >= time.Second {
+			if s.rateDropped > 0 {
+				summaries = append(summaries, newSummaryEntry(l, e, LevelWarning, "Suppressed %d messages because more than %d messages per second were logged", s.rateDropped, s.config.MaxPerSecond))
+			}
+			s.rateStart = e.Time
+			s.rateCount = 0
+			s.rateDropped = 0
+		}
+	}
+
+	// Check the number of identical messages
+	key := samplerKey{level: e.Level, message: message}
+	counter := s.counters[key]
+	if counter == nil {
+		if len(s.counters) >= maxSamplerCounters {
+			s.counters = make(map[samplerKey]*samplerCounter)
+		}
+		counter = &samplerCounter{tickStart: e.Time}
+		s.counters[key] = counter
+	} else if e.Time.Sub(counter.tickStart) >= s.config.Tick {
+		if counter.suppressed > 0 {
+			summaries = append(summaries, newSummaryEntry(l, e, e.Level, "Suppressed %d duplicates of the message '%s'", counter.suppressed, message))
+		}
+		counter.tickStart = e.Time
+		counter.count = 0
+		counter.suppressed = 0
+	}
+
+	counter.count++
+	ok := counter.count <= s.config.Initial ||
+		(s.config.Thereafter > 0 && (counter.count-s.config.Initial)%s.config.Thereafter == 0)
+	if !ok {
+		counter.suppressed++
+	} else if s.config.MaxPerSecond > 0 {
+		if s.rateCount >= s.config.MaxPerSecond {
+			ok = false
+			s.rateDropped++
+		} else {
+			s.rateCount++
+		}
+	}
+	s.lock.Unlock()
+
+	for _, summary := range summaries {
+		l.dispatch(summary)
+	}
+
+	return ok
+}
+
+// newSummaryEntry creates an entry that summarizes suppressed messages
+func newSummaryEntry(l *Logger, e Entry, level Level, message string, parameters ...any) Entry {
+	return Entry{
+		Time:    e.Time,
+		Level:   level,
+		Message: formatMessage(message, parameters...),
+		Fields:  l.fields,
+		File:    e.File,
+		Line:    e.Line,
+		PC:      e.PC,
+		logger:  l,
+	}
+}
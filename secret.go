@@ -0,0 +1,125 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+)
+
+// Text that is printed instead of secret values
+const maskedText = "***"
+
+// Whether the values of Secret and Masked() are printed unmasked (see ShowSecrets())
+var showSecrets atomic.Bool
+
+// ShowSecrets enables or disables the unsafe mode in which the values of Secret and
+// Masked() are printed in plain text. Only use this for local debugging
+func ShowSecrets(show bool) {
+	showSecrets.Store(show)
+}
+
+// Secret is a string that is always printed as "***" by the fmt package, the
+// formatters and "encoding/json". Sensitive values can flow through call sites
+// without leaking into the logs:
+//
+//	l.WithField("password", logger.Secret(password)).Info("Login")
+//
+// The plain value is retrieved with a conversion like "string(secret)"
+type Secret string
+
+// String returns the masked value
+func (s Secret) String() string {
+	return Masked(string(s)).String()
+}
+
+// GoString returns the masked value for the verb "%#v"
+func (s Secret) GoString() string {
+	return Masked(string(s)).GoString()
+}
+
+// Format masks the value for all verbs of the fmt package
+func (s Secret) Format(f fmt.State, verb rune) {
+	Masked(string(s)).Format(f, verb)
+}
+
+// MarshalJSON returns the masked value as a JSON string
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return Masked(string(s)).MarshalJSON()
+}
+
+// MarshalText returns the masked value
+func (s Secret) MarshalText() ([]byte, error) {
+	return Masked(string(s)).MarshalText()
+}
+
+// LogValue returns the masked value for the "log/slog" package
+func (s Secret) LogValue() slog.Value {
+	return Masked(string(s)).LogValue()
+}
+
+// MaskedValue wraps a value of any type that is printed as "***" (see Masked())
+type MaskedValue struct {
+	value any
+}
+
+// Masked wraps the value so that it's always printed as "***" like a Secret
+func Masked(value any) MaskedValue {
+	return MaskedValue{value: value}
+}
+
+// Value returns the plain wrapped value
+func (m MaskedValue) Value() any {
+	return m.value
+}
+
+// String returns the masked value
+func (m MaskedValue) String() string {
+	if showSecrets.Load() {
+		return fmt.Sprint(m.value)
+	}
+	return maskedText
+}
+
+// GoString returns the masked value for the verb "%#v"
+func (m MaskedValue) GoString() string {
+	if showSecrets.Load() {
+		return fmt.Sprintf("%#v", m.value)
+	}
+	return maskedText
+}
+
+// Format masks the value for all verbs of the fmt package
+func (m MaskedValue) Format(f fmt.State, verb rune) {
+	if showSecrets.Load() {
+		fmt.Fprintf(f, fmt.FormatString(f, verb), m.value)
+		return
+	}
+
+	if verb == 'q' {
+		fmt.Fprintf(f, "%q", maskedText)
+	} else {
+		fmt.Fprint(f, maskedText)
+	}
+}
+
+// MarshalJSON returns the masked value as a JSON string
+func (m MaskedValue) MarshalJSON() ([]byte, error) {
+	if showSecrets.Load() {
+		return json.Marshal(m.value)
+	}
+	return json.Marshal(maskedText)
+}
+
+// MarshalText returns the masked value
+func (m MaskedValue) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// LogValue returns the masked value for the "log/slog" package
+func (m MaskedValue) LogValue() slog.Value {
+	if showSecrets.Load() {
+		return slog.AnyValue(m.value)
+	}
+	return slog.StringValue(maskedText)
+}
@@ -0,0 +1,428 @@
+// sentrylog provides a target that reports log entries with a high severity
+// as events to Sentry.
+//
+// The events are sent directly to the envelope endpoint of the project that is
+// derived from the DSN. Errors within the structured fields are reported as exceptions
+// together with the stack trace captured at the logging call
+package sentrylog
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	mrand "math/rand"
+	"net/http"
+	"net/url"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/batch"
+	"git.rpjosh.de/RPJosh/go-logger/internal/spill"
+	"git.rpjosh.de/RPJosh/go-logger/retry"
+)
+
+// Config contains the configuration options of the Sentry target
+type Config struct {
+
+	// DSN of the Sentry project like "https://<key>@o0.ingest.sentry.io/<project>"
+	DSN string
+
+	// Minimum log level of the entries to report. Levels below Error are ignored
+	Level logger.Level
+
+	// Environment and release of the application (optional)
+	Environment string
+	Release     string
+
+	// Name of the server. Defaults to the host name
+	ServerName string
+
+	// Rate of the entries that are reported between 0 and 1. Defaults to 1 (all entries)
+	SampleRate float64
+
+	// Static tags added to every event
+	Tags map[string]string
+
+	// Names of the structured fields that are reported as tags. All other
+	// fields are reported as extras
+	TagFields []string
+
+	// Maximum number of events that are queued. Defaults to 100
+	QueueSize int
+
+	// Policy for retrying failed requests. Defaults to 3 attempts
+	RetryPolicy *retry.Policy
+
+	// Directory of a persistent queue on disk. Events that could not be sent are stored
+	// in it and sent again before the next batch or every 30 seconds. Stored events also survive a restart
+	// of the program. The directory must not be shared with other targets.
+	// The queue is disabled if this is empty
+	SpillDir string
+
+	// Maximum size of the queue on disk in bytes. If it's exceeded, the oldest
+	// events are dropped. Defaults to 100 MB
+	SpillMaxSize int64
+
+	// HTTP client used for the requests
+	HTTPClient *http.Client
+}
+
+// Target reports the entries as Sentry events
+type Target struct {
+	config    Config
+	endpoint  string
+	publicKey string
+	batcher   *batch.Batcher[*event]
+	health    logger.HealthStatus
+	sender    spill.Sender
+}
+
+// New creates a new Sentry target with the given configuration.
+// Add it to the field "Targets" of a logger
+func New(config Config) (*Target, error) {
+	dsn, err := url.Parse(config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSN: %w", err)
+	}
+	projectID := strings.TrimPrefix(dsn.Path, "/")
+	path := ""
+	if i := strings.LastIndex(projectID, "/"); i != -1 {
+		path, projectID = "/"+projectID[:i], projectID[i+1:]
+	}
+	if dsn.User == nil || dsn.User.Username() == "" || projectID == "" {
+		return nil, errors.New("invalid DSN: public key or project ID missing")
+	}
+
+	if config.Level < logger.LevelError {
+		config.Level = logger.LevelError
+	}
+	if config.ServerName == "" {
+		config.ServerName, _ = os.Hostname()
+	}
+	if config.SampleRate <= 0 || config.SampleRate > 1 {
+		config.SampleRate = 1
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 100
+	}
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = &retry.Policy{MaxAttempts: 3, InitialDelay: time.Second}
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	t := &Target{
+		config:    config,
+		endpoint:  dsn.Scheme + "://" + dsn.Host + path + "/api/" + projectID + "/envelope/",
+		publicKey: dsn.User.Username(),
+	}
+	t.sender = spill.Sender{Name: "sentrylog", Policy: config.RetryPolicy, Send: t.post}
+	if config.SpillDir != "" {
+		queue, err := spill.Open(spill.Options{Dir: config.SpillDir, MaxSize: config.SpillMaxSize})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "sentrylog: failed to open the spill queue: %s\n", err)
+		}
+		t.sender.Queue = queue
+		t.sender.Start()
+	}
+	t.batcher = batch.New(batch.Options[*event]{
+		MaxItems:   10,
+		MaxLatency: time.Second,
+		QueueSize:  config.QueueSize,
+	}, t.send)
+
+	return t, nil
+}
+
+// Enabled returns whether entries with the level are reported
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.config.Level <= level
+}
+
+// NeedsSource returns always true because the source is used to find the
+// frame of the logging call within the stack trace
+func (t *Target) NeedsSource() bool {
+	return true
+}
+
+// Write queues the entry for the report. Fatal entries are sent immediately
+// because the application exits afterwards
+func (t *Target) Write(e logger.Entry) error {
+	if t.config.SampleRate < 1 && mrand.Float64() >= t.config.SampleRate {
+		return nil
+	}
+
+	if !t.batcher.Add(t.newEvent(e)) {
+		return errors.New("sentrylog: queue is full, event dropped")
+	}
+	if e.Level >= logger.LevelFatal {
+		t.batcher.Flush()
+	}
+
+	return nil
+}
+
+// Flush sends all queued events
+func (t *Target) Flush() {
+	t.batcher.Flush()
+}
+
+// Stats returns the metrics of the sent batches like their size and the flush latency
+func (t *Target) Stats() batch.Stats {
+	return t.batcher.Stats()
+}
+
+// Health returns the state of the target with the last error of sending a batch
+func (t *Target) Health() logger.SinkHealth {
+	health := t.health.Status()
+	health.QueueDepth = t.batcher.Stats().Queued + t.sender.Len()
+	return health
+}
+
+// Close sends all queued events and stops the background worker
+func (t *Target) Close() error {
+	t.batcher.Close()
+	return t.sender.Close()
+}
+
+// newEvent converts the entry to a Sentry event
+func (t *Target) newEvent(e logger.Entry) *event {
+	ev := &event{
+		EventID:     newEventID(),
+		Timestamp:   float64(e.Time.UnixNano()) / 1e9,
+		Level:       getSentryLevel(e.Level),
+		Logger:      e.LoggerName,
+		Platform:    "go",
+		Message:     &message{Formatted: e.Message},
+		Environment: t.config.Environment,
+		Release:     t.config.Release,
+		ServerName:  t.config.ServerName,
+		Tags:        make(map[string]string),
+		Extra:       make(map[string]any),
+	}
+	for key, value := range t.config.Tags {
+		ev.Tags[key] = value
+	}
+
+	tagFields := make(map[string]bool, len(t.config.TagFields))
+	for _, key := range t.config.TagFields {
+		tagFields[key] = true
+	}
+
+	stacktrace := getStacktrace(e)
+	for key, value := range e.Fields {
+		if err, ok := value.(error); ok {
+			ev.Exception = append(ev.Exception, exception{
+				Type:       fmt.Sprintf("%T", err),
+				Value:      err.Error(),
+				Stacktrace: stacktrace,
+			})
+			continue
+		}
+
+		if tagFields[key] {
+			ev.Tags[key] = fmt.Sprintf("%v", value)
+		} else if _, err := json.Marshal(value); err == nil {
+			ev.Extra[key] = value
+		} else {
+			ev.Extra[key] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	if len(ev.Exception) == 0 {
+		ev.Threads = []thread{{ID: "0", Current: true, Crashed: e.Level >= logger.LevelPanic, Stacktrace: stacktrace}}
+	}
+
+	return ev
+}
+
+// getStacktrace captures the current stack trace. All frames that
+// belong to the logger itself are skipped
+func getStacktrace(e logger.Entry) *stacktrace {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	rtc := make([]frame, 0, n)
+	found := false
+	for {
+		f, more := frames.Next()
+		if found || (f.File == e.File && f.Line == e.Line) {
+			found = true
+
+			module, function := splitFunction(f.Function)
+			rtc = append(rtc, frame{
+				Function: function,
+				Module:   module,
+				AbsPath:  f.File,
+				Filename: f.File[strings.LastIndex(f.File, "/")+1:],
+				Lineno:   f.Line,
+				InApp:    module != "runtime" && !strings.HasPrefix(module, "net/http"),
+			})
+		}
+
+		if !more {
+			break
+		}
+	}
+	if len(rtc) == 0 {
+		return nil
+	}
+
+	// Sentry expects the oldest frame first
+	for i, j := 0, len(rtc)-1; i < j; i, j = i+1, j-1 {
+		rtc[i], rtc[j] = rtc[j], rtc[i]
+	}
+
+	return &stacktrace{Frames: rtc}
+}
+
+// splitFunction splits the full name of a function into the package path and the function name
+func splitFunction(name string) (module string, function string) {
+	lastSlash := strings.LastIndex(name, "/")
+	if i := strings.Index(name[lastSlash+1:], "."); i != -1 {
+		return name[:lastSlash+1+i], name[lastSlash+1+i+1:]
+	}
+
+	return "", name
+}
+
+// getSentryLevel returns the Sentry level for the level
+func getSentryLevel(level logger.Level) string {
+	switch {
+	case level >= logger.LevelPanic:
+		return "fatal"
+	case level >= logger.LevelError:
+		return "error"
+	case level >= logger.LevelWarning:
+		return "warning"
+	case level >= logger.LevelInfo:
+		return "info"
+	default:
+		return "debug"
+	}
+}
+
+// newEventID returns a random ID for an event
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// send sends the events to Sentry. Every event is sent as its own envelope
+func (t *Target) send(events []*event) {
+	for _, ev := range events {
+		payload, err := json.Marshal(ev)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "sentrylog: failed to encode event: %s\n", err)
+			continue
+		}
+
+		stored, err := t.sender.Deliver(payload)
+		t.health.Report(err)
+		if err != nil && !stored {
+			fmt.Fprintf(os.Stderr, "sentrylog: failed to send event: %s\n", err)
+		}
+	}
+}
+
+// post sends a single event encoded as JSON within an envelope
+func (t *Target) post(payload []byte) error {
+	var ev struct {
+		EventID string `json:"event_id"`
+	}
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return retry.Permanent(err)
+	}
+
+	var body bytes.Buffer
+	header, _ := json.Marshal(map[string]string{
+		"event_id": ev.EventID,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339),
+	})
+	body.Write(header)
+	body.WriteString("\n{\"type\":\"event\",\"length\":" + fmt.Sprint(len(payload)) + "}\n")
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequest(http.MethodPost, t.endpoint, &body)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", "Sentry sentry_version=7, sentry_client=go-logger/1.0, sentry_key="+t.publicKey)
+
+	resp, err := t.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	if resp.StatusCode >= 400 && resp.StatusCode <= 499 && resp.StatusCode != http.StatusTooManyRequests {
+		return retry.Permanent(err)
+	}
+	return err
+}
+
+// JSON representation of a Sentry event
+
+type event struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   float64           `json:"timestamp"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger,omitempty"`
+	Platform    string            `json:"platform"`
+	Message     *message          `json:"message,omitempty"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Extra       map[string]any    `json:"extra,omitempty"`
+	Exception   []exception       `json:"exception,omitempty"`
+	Threads     []thread          `json:"threads,omitempty"`
+}
+
+type message struct {
+	Formatted string `json:"formatted"`
+}
+
+type exception struct {
+	Type       string      `json:"type"`
+	Value      string      `json:"value"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+}
+
+type thread struct {
+	ID         string      `json:"id"`
+	Current    bool        `json:"current"`
+	Crashed    bool        `json:"crashed"`
+	Stacktrace *stacktrace `json:"stacktrace,omitempty"`
+}
+
+type stacktrace struct {
+	Frames []frame `json:"frames"`
+}
+
+type frame struct {
+	Function string `json:"function"`
+	Module   string `json:"module,omitempty"`
+	Filename string `json:"filename"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
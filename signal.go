@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"os"
+	"os/signal"
+)
+
+// EnableSignalReopen starts listening for the given signals and reopens the log file
+// whenever one of them is received. This enables the usage of external tools like
+// "logrotate" that move the log file.
+// If no signals are given SIGHUP is used (not available on windows).
+//
+// The returned function stops listening for the signals
+func (l *Logger) EnableSignalReopen(signals ...os.Signal) (stop func()) {
+	if !l.ensureSetup() {
+		return func() {}
+	}
+	if len(signals) == 0 {
+		signals = defaultReopenSignals
+	}
+
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, signals...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				l.resolve().File.Reopen()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
+
+// EnableSignalReopen reopens the log file of the global logger whenever one
+// of the signals is received
+func EnableSignalReopen(signals ...os.Signal) (stop func()) {
+	return dLogger.EnableSignalReopen(signals...)
+}
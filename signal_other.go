@@ -0,0 +1,9 @@
+//go:build !unix
+
+package logger
+
+import "os"
+
+// Signals that are used by default to reopen the log file.
+// There is no default signal on this platform
+var defaultReopenSignals = []os.Signal{}
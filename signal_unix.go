@@ -0,0 +1,11 @@
+//go:build unix
+
+package logger
+
+import (
+	"os"
+	"syscall"
+)
+
+// Signals that are used by default to reopen the log file
+var defaultReopenSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,318 @@
+// slacklog provides a target that sends notifications about high-severity
+// log entries to an incoming webhook of Slack or Mattermost.
+//
+// Only entries with the level Error or higher are forwarded. To not flood the channel,
+// the entries are collected for an interval and sent as a single message.
+// Identical entries are aggregated and shown with their number of occurrences
+package slacklog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/batch"
+	"git.rpjosh.de/RPJosh/go-logger/internal/spill"
+	"git.rpjosh.de/RPJosh/go-logger/retry"
+)
+
+// Config contains the configuration options of the notification target
+type Config struct {
+
+	// URL of the incoming webhook
+	WebhookURL string
+
+	// Minimum log level of the entries to send. Levels below Error are ignored
+	Level logger.Level
+
+	// Overrides the channel of the webhook (optional)
+	Channel string
+
+	// Overrides the user name of the webhook (optional)
+	Username string
+
+	// Overrides the icon of the webhook like ":rotating_light:" (optional)
+	IconEmoji string
+
+	// Entries are collected for this interval before a single message is sent.
+	// Defaults to 30 seconds
+	Interval time.Duration
+
+	// Maximum number of distinct entries shown within a message. Defaults to 10
+	MaxEntries int
+
+	// Maximum number of entries that are queued within an interval. Further
+	// entries are dropped. Defaults to 1000
+	QueueSize int
+
+	// Number of attempts to send a message. Defaults to 3
+	MaxAttempts int
+
+	// Policy for retrying failed requests like the delays between the attempts.
+	// It replaces "MaxAttempts" if set
+	RetryPolicy *retry.Policy
+
+	// Directory of a persistent queue on disk. Notifications that could not be sent are stored
+	// in it and sent again before the next batch or every 30 seconds. Stored notifications also survive a restart
+	// of the program. The directory must not be shared with other targets.
+	// The queue is disabled if this is empty
+	SpillDir string
+
+	// Maximum size of the queue on disk in bytes. If it's exceeded, the oldest
+	// notifications are dropped. Defaults to 100 MB
+	SpillMaxSize int64
+
+	// HTTP client used for the requests
+	HTTPClient *http.Client
+}
+
+// Target sends the entries to a Slack or Mattermost webhook
+type Target struct {
+	config  Config
+	batcher *batch.Batcher[logger.Entry]
+	health  logger.HealthStatus
+	sender  spill.Sender
+}
+
+// New creates a new notification target with the given configuration.
+// Add it to the field "Targets" of a logger
+func New(config Config) *Target {
+	if config.Level < logger.LevelError {
+		config.Level = logger.LevelError
+	}
+	if config.Interval <= 0 {
+		config.Interval = 30 * time.Second
+	}
+	if config.MaxEntries <= 0 {
+		config.MaxEntries = 10
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = &retry.Policy{MaxAttempts: config.MaxAttempts, InitialDelay: time.Second}
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	t := &Target{config: config}
+	t.sender = spill.Sender{Name: "slacklog", Policy: config.RetryPolicy, Send: t.post}
+	if config.SpillDir != "" {
+		queue, err := spill.Open(spill.Options{Dir: config.SpillDir, MaxSize: config.SpillMaxSize})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "slacklog: failed to open the spill queue: %s\n", err)
+		}
+		t.sender.Queue = queue
+		t.sender.Start()
+	}
+	t.batcher = batch.New(batch.Options[logger.Entry]{
+		MaxItems:   config.QueueSize,
+		MaxLatency: config.Interval,
+		QueueSize:  config.QueueSize,
+	}, t.send)
+
+	return t
+}
+
+// Enabled returns whether entries with the level are sent
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.config.Level <= level
+}
+
+// Write queues the entry for the next message
+func (t *Target) Write(e logger.Entry) error {
+	if !t.batcher.Add(e) {
+		return errors.New("slacklog: queue is full, entry dropped")
+	}
+
+	return nil
+}
+
+// Flush sends all queued entries immediately
+func (t *Target) Flush() {
+	t.batcher.Flush()
+}
+
+// Stats returns the metrics of the sent batches like their size and the flush latency
+func (t *Target) Stats() batch.Stats {
+	return t.batcher.Stats()
+}
+
+// Health returns the state of the target with the last error of sending a batch
+func (t *Target) Health() logger.SinkHealth {
+	health := t.health.Status()
+	health.QueueDepth = t.batcher.Stats().Queued + t.sender.Len()
+	return health
+}
+
+// Close sends all queued entries and stops the background worker
+func (t *Target) Close() error {
+	t.batcher.Close()
+	return t.sender.Close()
+}
+
+// aggregate contains identical entries
+type aggregate struct {
+	entry logger.Entry
+	count int
+}
+
+// send aggregates the entries and sends them as a single message
+func (t *Target) send(entries []logger.Entry) {
+	aggregates := make([]*aggregate, 0)
+	keys := make(map[string]*aggregate)
+	for _, e := range entries {
+		key := e.Level.String() + "|" + e.File + ":" + strconv.Itoa(e.Line) + "|" + e.Message
+		if a, ok := keys[key]; ok {
+			a.count++
+			continue
+		}
+
+		a := &aggregate{entry: e, count: 1}
+		keys[key] = a
+		aggregates = append(aggregates, a)
+	}
+
+	msg := message{
+		Text:      fmt.Sprintf("%d log entries with a high severity occurred", len(entries)),
+		Channel:   t.config.Channel,
+		Username:  t.config.Username,
+		IconEmoji: t.config.IconEmoji,
+	}
+	if len(entries) == 1 {
+		msg.Text = "A log entry with a high severity occurred"
+	}
+	for i, a := range aggregates {
+		if i >= t.config.MaxEntries {
+			msg.Attachments = append(msg.Attachments, attachment{
+				Color: "#808080",
+				Text:  fmt.Sprintf("… and %d more distinct entries", len(aggregates)-i),
+			})
+			break
+		}
+		msg.Attachments = append(msg.Attachments, getAttachment(a))
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+	stored, err := t.sender.Deliver(body)
+	t.health.Report(err)
+	if err != nil && !stored {
+		fmt.Fprintf(os.Stderr, "slacklog: failed to send notification: %s\n", err)
+	}
+}
+
+// getAttachment returns the formatted attachment for the aggregated entries
+func getAttachment(a *aggregate) attachment {
+	e := a.entry
+	rtc := attachment{
+		Color:     getColor(e.Level),
+		Title:     "[" + e.Level.String() + "] " + e.Message,
+		Timestamp: e.Time.Unix(),
+	}
+	if e.LoggerName != "" {
+		rtc.Fields = append(rtc.Fields, attachmentField{Title: "Logger", Value: e.LoggerName, Short: true})
+	}
+	if e.Line > 0 {
+		rtc.Fields = append(rtc.Fields, attachmentField{Title: "Source", Value: "`" + filepath.Base(e.File) + ":" + strconv.Itoa(e.Line) + "`", Short: true})
+	}
+
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := fmt.Sprintf("%v", e.Fields[key])
+		rtc.Fields = append(rtc.Fields, attachmentField{Title: key, Value: value, Short: len(value) < 40 && !strings.Contains(value, "\n")})
+	}
+
+	if a.count > 1 {
+		rtc.Footer = fmt.Sprintf("Occurred %d times", a.count)
+	}
+
+	return rtc
+}
+
+// getColor returns the color of the attachment for the level
+func getColor(level logger.Level) string {
+	switch {
+	case level >= logger.LevelFatal:
+		return "#8b0000"
+	case level >= logger.LevelPanic:
+		return "#c2185b"
+	case level >= logger.LevelError:
+		return "#e01e5a"
+	case level >= logger.LevelWarning:
+		return "#ecb22e"
+	default:
+		return "#2eb67d"
+	}
+}
+
+// post executes a single request to the webhook
+func (t *Target) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, t.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	if resp.StatusCode >= 400 && resp.StatusCode <= 499 && resp.StatusCode != http.StatusTooManyRequests {
+		return retry.Permanent(err)
+	}
+	return err
+}
+
+// JSON representation of a webhook message. It's compatible with Slack and Mattermost
+
+type message struct {
+	Text        string       `json:"text"`
+	Channel     string       `json:"channel,omitempty"`
+	Username    string       `json:"username,omitempty"`
+	IconEmoji   string       `json:"icon_emoji,omitempty"`
+	Attachments []attachment `json:"attachments,omitempty"`
+}
+
+type attachment struct {
+	Color     string            `json:"color"`
+	Title     string            `json:"title,omitempty"`
+	Text      string            `json:"text,omitempty"`
+	Fields    []attachmentField `json:"fields,omitempty"`
+	Footer    string            `json:"footer,omitempty"`
+	Timestamp int64             `json:"ts,omitempty"`
+}
+
+type attachmentField struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+	Short bool   `json:"short"`
+}
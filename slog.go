@@ -0,0 +1,126 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// slogHandler routes the records of the standard library "log/slog" package
+// into a Logger
+type slogHandler struct {
+	logger *Logger
+}
+
+// NewSlogHandler returns a handler for the "log/slog" package that writes
+// all records to the given logger. Attributes are added as structured fields
+// and groups are represented by keys separated with a dot like "group.key".
+//
+//	slog.SetDefault(slog.New(logger.NewSlogHandler(logger.GetGlobalLogger())))
+func NewSlogHandler(l *Logger) slog.Handler {
+	return &slogHandler{logger: l}
+}
+
+// Enabled reports whether the logger writes records with the given level
+// to the console or to the log file
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.logger.Enabled(getLevelFromSlog(level))
+}
+
+// Handle writes the record to the logger
+func (h *slogHandler) Handle(_ context.Context, record slog.Record) error {
+	l := h.logger
+	if record.NumAttrs() > 0 {
+		fields := make(map[string]any, record.NumAttrs())
+		record.Attrs(func(attr slog.Attr) bool {
+			addSlogAttr(fields, "", attr)
+			return true
+		})
+		l = l.WithFields(fields)
+	}
+
+	e := Entry{
+		Time:       record.Time,
+		Level:      getLevelFromSlog(record.Level),
+		Message:    record.Message,
+		Fields:     l.fields,
+		LoggerName: l.name,
+		File:       "#unknown",
+		PC:         record.PC,
+		logger:     l,
+	}
+	if l.needsGoroutineID() {
+		e.GoroutineID = getGoroutineID()
+	}
+	if l.stacktraceEnabled(e.Level) {
+		e.Stack = captureStack(1, record.PC)
+	}
+	if record.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{record.PC}).Next()
+		e.File = frame.File
+		e.Line = frame.Line
+	}
+
+	l.write(e, false)
+	return nil
+}
+
+// WithAttrs returns a new handler with the given attributes added as fields
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(map[string]any, len(attrs))
+	for _, attr := range attrs {
+		addSlogAttr(fields, "", attr)
+	}
+
+	return &slogHandler{logger: h.logger.WithFields(fields)}
+}
+
+// WithGroup returns a new handler that prefixes all following attribute keys
+// with the name of the group (see Logger.WithGroup())
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return &slogHandler{logger: h.logger.WithGroup(name)}
+}
+
+// addSlogAttr adds the attribute with the given key prefix to the fields.
+// Groups are flattened recursively
+func addSlogAttr(fields map[string]any, prefix string, attr slog.Attr) {
+	attr.Value = attr.Value.Resolve()
+	if attr.Equal(slog.Attr{}) {
+		return
+	}
+
+	if attr.Value.Kind() == slog.KindGroup {
+		// Attributes of groups without a name are inlined
+		if attr.Key != "" {
+			prefix += attr.Key + "."
+		}
+		for _, groupAttr := range attr.Value.Group() {
+			addSlogAttr(fields, prefix, groupAttr)
+		}
+		return
+	}
+
+	fields[prefix+attr.Key] = attr.Value.Any()
+}
+
+// getLevelFromSlog converts the level of the "log/slog" package to the
+// nearest Level. Records are never logged as fatal because this
+// would exit the program
+func getLevelFromSlog(level slog.Level) Level {
+	switch {
+	case level < slog.LevelDebug:
+		return LevelTrace
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarning
+	default:
+		return LevelError
+	}
+}
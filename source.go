@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SourceFormat defines how the source of a message is printed (see PrintSource)
+type SourceFormat uint8
+
+const (
+	// Only the file name and the line number like "server.go:42"
+	SourceFormatFile SourceFormat = iota
+
+	// The function name with the package name before the file like
+	// "server.(*Handler).Login server.go:42"
+	SourceFormatFunction
+
+	// The function name with the full package path before the file like
+	// "example.com/app/server.(*Handler).Login server.go:42"
+	SourceFormatPackage
+)
+
+// SourcePathMode defines how the path of the source file is printed
+type SourcePathMode uint8
+
+const (
+	// Only the name of the file like "handler.go"
+	SourcePathShort SourcePathMode = iota
+
+	// The full path of the file like "/home/user/app/api/handler.go"
+	SourcePathFull
+
+	// The path relative to the root of the module like "api/handler.go".
+	// The root is the directory containing the "go.mod" file. For binaries built
+	// with "-trimpath" the module path is removed instead
+	SourcePathRelative
+
+	// The full path without the prefix configured by "SourcePathPrefix"
+	SourcePathTrimPrefix
+)
+
+// Cache of the module root directories by the directory of a source file.
+// An empty root means that no module was found
+var moduleRootCache sync.Map
+
+// appendSource appends the source of the entry in the format configured
+// for the logger ("SourceFormat")
+func (e Entry) appendSource(buf []byte) []byte {
+	if e.logger != nil && e.logger.SourceFormat != SourceFormatFile {
+		if fn := runtime.FuncForPC(e.PC); fn != nil && e.PC != 0 {
+			name := fn.Name()
+			if e.logger.SourceFormat == SourceFormatFunction {
+				name = name[strings.LastIndex(name, "/")+1:]
+			}
+			buf = append(buf, name...)
+			buf = append(buf, ' ')
+		}
+	}
+
+	if e.logger == nil {
+		return appendSourceName(buf, e.File, e.Line)
+	}
+
+	switch e.logger.SourcePathMode {
+	case SourcePathFull:
+		buf = append(buf, e.File...)
+	case SourcePathRelative:
+		buf = append(buf, getRelativePath(e.File)...)
+	case SourcePathTrimPrefix:
+		buf = append(buf, strings.TrimPrefix(e.File, e.logger.SourcePathPrefix)...)
+	default:
+		return appendSourceName(buf, e.File, e.Line)
+	}
+
+	buf = append(buf, ':')
+	return strconv.AppendInt(buf, int64(e.Line), 10)
+}
+
+// getRelativePath returns the path of the source file relative to the root of its module.
+// If no module was found, the name of the file is returned
+func getRelativePath(file string) string {
+	dir := filepath.Dir(file)
+	fileName := filepath.Base(file)
+
+	// Paths of binaries built with "-trimpath" start with the module path
+	if !filepath.IsAbs(file) {
+		if info, ok := debug.ReadBuildInfo(); ok {
+			for _, mod := range append([]*debug.Module{&info.Main}, info.Deps...) {
+				if mod.Path != "" && strings.HasPrefix(file, mod.Path+"/") {
+					return file[len(mod.Path)+1:]
+				}
+			}
+		}
+		return fileName
+	}
+
+	root, ok := moduleRootCache.Load(dir)
+	if !ok {
+		root = ""
+		for current := dir; ; {
+			if _, err := os.Stat(filepath.Join(current, "go.mod")); err == nil {
+				root = current
+				break
+			}
+
+			parent := filepath.Dir(current)
+			if parent == current {
+				break
+			}
+			current = parent
+		}
+		moduleRootCache.Store(dir, root)
+	}
+
+	if root == "" {
+		return fileName
+	}
+	return strings.TrimPrefix(file[len(root.(string)):], "/")
+}
+
+// sourceName returns the source of the entry in the format configured for the logger
+func (e Entry) sourceName() string {
+	return string(e.appendSource(nil))
+}
@@ -0,0 +1,61 @@
+package logger
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Maximum number of frames of a captured stack trace
+const maxStackFrames = 64
+
+// stacktraceEnabled returns whether a stack trace is captured for entries with the level
+func (l *Logger) stacktraceEnabled(level Level) bool {
+	if l.StacktraceLevel == nil {
+		return level >= LevelFatal
+	}
+
+	return level >= *l.StacktraceLevel
+}
+
+// captureStack returns the stack trace of the current goroutine with one line per frame
+// like "main.handler (/app/main.go:12)". The skip has the same meaning as for runtime.Caller(),
+// so 0 is the function that calls captureStack(). If a program counter is given, the trace
+// starts at the frame of it. Frames of the runtime after the last function are omitted
+func captureStack(skip int, pc uintptr) string {
+	pcs := make([]uintptr, maxStackFrames)
+	pcs = pcs[:runtime.Callers(skip+2, pcs)]
+	if pc != 0 {
+		for i := range pcs {
+			if pcs[i] == pc {
+				pcs = pcs[i:]
+				break
+			}
+		}
+	}
+
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.main" || frame.Function == "runtime.goexit" {
+			break
+		}
+
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(frame.Function)
+		b.WriteString(" (")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		b.WriteByte(')')
+
+		if !more {
+			break
+		}
+	}
+
+	return b.String()
+}
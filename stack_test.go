@@ -0,0 +1,25 @@
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestStacktraceLevelTrace(t *testing.T) {
+	var out bytes.Buffer
+	level := LevelTrace
+	l := NewLogger(&Logger{Level: LevelTrace, ConsoleOutput: &out, StacktraceLevel: &level})
+
+	l.Trace("message")
+	if !strings.Contains(out.String(), "stack_test.go") {
+		t.Errorf("expected a stack trace for the level trace, got %q", out.String())
+	}
+
+	out.Reset()
+	l.StacktraceLevel = nil
+	l.Error("message")
+	if strings.Contains(out.String(), "stack_test.go") {
+		t.Errorf("expected no stack trace below the level fatal by default, got %q", out.String())
+	}
+}
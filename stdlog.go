@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"bytes"
+	"log"
+	"runtime"
+	"strings"
+)
+
+// stdWriter is the writer of a *log.Logger created by StdLogger(). Every
+// written line is logged as a single entry
+type stdWriter struct {
+	logger       *Logger
+	level        Level
+	detectLevels bool
+}
+
+// Prefixes of messages that are used to detect the level. The prefixes are
+// compared case insensitive and have to be followed by a colon or a space
+var stdLevelPrefixes = []struct {
+	prefix string
+	level  Level
+}{
+	{"trace", LevelTrace},
+	{"debug", LevelDebug},
+	{"info", LevelInfo},
+	{"warning", LevelWarning},
+	{"warn", LevelWarning},
+	{"error", LevelError},
+	{"err", LevelError},
+	{"panic", LevelError},
+	{"fatal", LevelError},
+}
+
+// StdLogger returns a logger of the standard library package "log" that writes
+// all messages with the given level to this logger. Use this for components that
+// only accept a *log.Logger like "http.Server.ErrorLog"
+func (l *Logger) StdLogger(level Level) *log.Logger {
+	return log.New(&stdWriter{logger: l, level: level}, "", 0)
+}
+
+// StdLoggerWithLevelDetection returns a logger of the standard library package "log" like
+// StdLogger(). Additionally the level is detected by the prefix of the messages like
+// "ERROR: ...", "[WARN] ..." or "info ...". The prefix is removed from the message.
+// Messages without a known prefix are logged with the default level.
+// Fatal and panic messages are logged as error, because "log" handles them itself
+func (l *Logger) StdLoggerWithLevelDetection(defaultLevel Level) *log.Logger {
+	return log.New(&stdWriter{logger: l, level: defaultLevel, detectLevels: true}, "", 0)
+}
+
+// StdLogger returns a logger of the standard library package "log" that writes
+// all messages with the given level to the global logger
+func StdLogger(level Level) *log.Logger {
+	return dLogger.StdLogger(level)
+}
+
+// StdLoggerWithLevelDetection returns a logger of the standard library package "log"
+// that writes to the global logger and detects the level by the prefix of the messages
+func StdLoggerWithLevelDetection(defaultLevel Level) *log.Logger {
+	return dLogger.StdLoggerWithLevelDetection(defaultLevel)
+}
+
+// Write logs the message written by the "log" package
+func (w *stdWriter) Write(p []byte) (int, error) {
+	message := string(bytes.TrimRight(p, "\r\n"))
+	level := w.level
+	if w.detectLevels {
+		level, message = detectLevel(message, level)
+	}
+
+	l := w.logger
+	if !l.Enabled(level) {
+		return len(p), nil
+	}
+
+	e := l.NewEntry(level, message)
+	if l.needsSource() {
+		e.PC = getStdCallerPC()
+	}
+	l.LogEntry(e)
+
+	return len(p), nil
+}
+
+// detectLevel returns the level by the prefix of the message and the message without the prefix.
+// If no prefix matches, the default level and the unchanged message are returned
+func detectLevel(message string, defaultLevel Level) (Level, string) {
+	trimmed := strings.TrimLeft(message, " [")
+	for _, p := range stdLevelPrefixes {
+		if len(trimmed) <= len(p.prefix) || !strings.EqualFold(trimmed[:len(p.prefix)], p.prefix) {
+			continue
+		}
+
+		switch trimmed[len(p.prefix)] {
+		case ':', ' ', ']':
+			return p.level, strings.TrimLeft(trimmed[len(p.prefix):], ":] ")
+		}
+	}
+
+	return defaultLevel, message
+}
+
+// getStdCallerPC returns the program counter of the first caller outside
+// of the "log" package and this package
+func getStdCallerPC() uintptr {
+	pcs := make([]uintptr, 16)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "log.") && !strings.HasPrefix(frame.Function, "git.rpjosh.de/RPJosh/go-logger.") {
+			return frame.PC
+		}
+		if !more {
+			return 0
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Logln logs the operands with the given level. Spaces are always added between
+// the operands like "fmt.Sprintln()" does
+func (l *Logger) Logln(level Level, args ...any) {
+	l.logln(level, args)
+}
+
+// logln formats the operands if the level is enabled and logs the result.
+// Like log(), it has to be called directly by the exported function
+func (l *Logger) logln(level Level, args []any) {
+	if level != LevelFatal && !l.Enabled(level) {
+		return
+	}
+
+	if e, ok := l.buildEntry(nil, 3, level, strings.TrimSuffix(fmt.Sprintln(args...), "\n"), nil); ok {
+		l.write(e, false)
+	}
+}
+
+// Logw logs the message with the given level and the alternating keys and values
+// as structured fields like the "SugaredLogger" of zap:
+//
+//	l.Infow("Request failed", "status", 500, "path", "/api")
+//
+// A value without a key is added with the key "!BADKEY"
+func (l *Logger) Logw(level Level, message string, keysAndValues ...any) {
+	l.logw(level, message, keysAndValues)
+}
+
+// logw logs the message with the key/value pairs as fields if the level is enabled.
+// Like log(), it has to be called directly by the exported function
+func (l *Logger) logw(level Level, message string, keysAndValues []any) {
+	if level != LevelFatal && !l.Enabled(level) {
+		return
+	}
+
+	target := l
+	if len(keysAndValues) > 0 {
+		target = l.WithFields(getKeyValueFields(keysAndValues))
+	}
+	if e, ok := target.buildEntry(nil, 3, level, message, nil); ok {
+		target.write(e, false)
+	}
+}
+
+// getKeyValueFields converts the alternating keys and values to fields
+func getKeyValueFields(keysAndValues []any) map[string]any {
+	fields := make(map[string]any, (len(keysAndValues)+1)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		if i+1 == len(keysAndValues) {
+			fields["!BADKEY"] = keysAndValues[i]
+			break
+		}
+
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		fields[key] = keysAndValues[i+1]
+	}
+
+	return fields
+}
+
+// Global available methods per logging level with the semantics of "fmt.Sprintln()"
+// and with key/value pairs
+
+func Traceln(args ...any) {
+	dLogger.logln(LevelTrace, args)
+}
+func Debugln(args ...any) {
+	dLogger.logln(LevelDebug, args)
+}
+func Infoln(args ...any) {
+	dLogger.logln(LevelInfo, args)
+}
+func Warningln(args ...any) {
+	dLogger.logln(LevelWarning, args)
+}
+func Errorln(args ...any) {
+	dLogger.logln(LevelError, args)
+}
+func Fatalln(args ...any) {
+	dLogger.logln(LevelFatal, args)
+}
+
+func Tracew(message string, keysAndValues ...any) {
+	dLogger.logw(LevelTrace, message, keysAndValues)
+}
+func Debugw(message string, keysAndValues ...any) {
+	dLogger.logw(LevelDebug, message, keysAndValues)
+}
+func Infow(message string, keysAndValues ...any) {
+	dLogger.logw(LevelInfo, message, keysAndValues)
+}
+func Warningw(message string, keysAndValues ...any) {
+	dLogger.logw(LevelWarning, message, keysAndValues)
+}
+func Errorw(message string, keysAndValues ...any) {
+	dLogger.logw(LevelError, message, keysAndValues)
+}
+func Fatalw(message string, keysAndValues ...any) {
+	dLogger.logw(LevelFatal, message, keysAndValues)
+}
+
+// Available methods for each logger per logging level with the semantics of
+// "fmt.Sprintln()" and with key/value pairs
+
+func (l *Logger) Traceln(args ...any) {
+	l.logln(LevelTrace, args)
+}
+func (l *Logger) Debugln(args ...any) {
+	l.logln(LevelDebug, args)
+}
+func (l *Logger) Infoln(args ...any) {
+	l.logln(LevelInfo, args)
+}
+func (l *Logger) Warningln(args ...any) {
+	l.logln(LevelWarning, args)
+}
+func (l *Logger) Errorln(args ...any) {
+	l.logln(LevelError, args)
+}
+func (l *Logger) Fatalln(args ...any) {
+	l.logln(LevelFatal, args)
+}
+
+func (l *Logger) Tracew(message string, keysAndValues ...any) {
+	l.logw(LevelTrace, message, keysAndValues)
+}
+func (l *Logger) Debugw(message string, keysAndValues ...any) {
+	l.logw(LevelDebug, message, keysAndValues)
+}
+func (l *Logger) Infow(message string, keysAndValues ...any) {
+	l.logw(LevelInfo, message, keysAndValues)
+}
+func (l *Logger) Warningw(message string, keysAndValues ...any) {
+	l.logw(LevelWarning, message, keysAndValues)
+}
+func (l *Logger) Errorw(message string, keysAndValues ...any) {
+	l.logw(LevelError, message, keysAndValues)
+}
+func (l *Logger) Fatalw(message string, keysAndValues ...any) {
+	l.logw(LevelFatal, message, keysAndValues)
+}
@@ -0,0 +1,223 @@
+package logger
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SyslogFacility is the facility code of a syslog message
+type SyslogFacility uint8
+
+const (
+	FacilityKern SyslogFacility = iota
+	FacilityUser
+	FacilityMail
+	FacilityDaemon
+	FacilityAuth
+	FacilitySyslog
+	FacilityLpr
+	FacilityNews
+	FacilityUucp
+	FacilityCron
+	FacilityAuthPriv
+	FacilityFtp
+)
+
+const (
+	FacilityLocal0 SyslogFacility = iota + 16
+	FacilityLocal1
+	FacilityLocal2
+	FacilityLocal3
+	FacilityLocal4
+	FacilityLocal5
+	FacilityLocal6
+	FacilityLocal7
+)
+
+// SyslogLogger contains configuration options specific to logging to a syslog daemon.
+// If no network is given, the messages are written to the local syslog socket.
+// Otherwise the messages are sent to a remote server in the format of RFC 5424
+type SyslogLogger struct {
+
+	// Minimum log level for logging to syslog
+	Level Level
+
+	// Network to use for connecting to a remote syslog server.
+	// Supported values are "udp", "tcp" and "tls". If empty, the local
+	// syslog socket is used
+	Network string
+
+	// Address of the remote syslog server like "localhost:514".
+	// For the local syslog daemon you can optionally specify the path to the
+	// unix socket. By default "/dev/log", "/var/run/syslog" and "/var/run/log" are tried
+	Address string
+
+	// TLS configuration used for the network "tls"
+	TLSConfig *tls.Config
+
+	// Facility of the messages. Defaults to FacilityUser
+	Facility SyslogFacility
+
+	// Tag (app name) of the messages. Defaults to the name of the executable
+	Tag string
+
+	conn     net.Conn
+	connSync *sync.Mutex
+	hostname string
+
+	// Upper logger struct
+	rootLogger *Logger
+}
+
+// getSyslogSeverity returns the syslog severity matching the level
+func (lvl Level) getSyslogSeverity() int {
+	switch lvl.predefined() {
+	case LevelTrace, LevelDebug:
+		return 7
+	case LevelInfo:
+		return 6
+	case LevelWarning:
+		return 4
+	case LevelError:
+		return 3
+	default:
+		return 2
+	}
+}
+
+// Enabled returns whether the level is enabled for syslog
+func (l *SyslogLogger) Enabled(level Level) bool {
+	return l.Level.load() <= level
+}
+
+// Write sends the entry to the syslog daemon. If the connection was lost,
+// one attempt to reconnect is made
+func (l *SyslogLogger) Write(e Entry) error {
+	return l.writeToSyslog(e)
+}
+
+// Close closes the connection to the syslog daemon
+func (l *SyslogLogger) Close() (err error) {
+	if l.connSync == nil {
+		return nil
+	}
+
+	l.connSync.Lock()
+	if l.conn != nil {
+		err = l.conn.Close()
+		l.conn = nil
+	}
+	l.connSync.Unlock()
+
+	return
+}
+
+// setup initializes the syslog logger and tries to connect to the daemon
+func (l *SyslogLogger) setup() {
+	if l.connSync != nil {
+		return
+	}
+	l.connSync = &sync.Mutex{}
+
+	if l.Tag == "" {
+		l.Tag = filepath.Base(os.Args[0])
+	}
+	l.hostname, _ = os.Hostname()
+	if l.hostname == "" {
+		l.hostname = "-"
+	}
+
+	l.connSync.Lock()
+	err := l.connect()
+	l.connSync.Unlock()
+
+	if err != nil {
+		l.rootLogger.Log(LevelError, "Cannot connect to syslog: %s", err)
+	}
+}
+
+// connect opens the connection to the syslog daemon.
+// The caller has to hold the lock "connSync"
+func (l *SyslogLogger) connect() (err error) {
+	switch l.Network {
+	case "":
+		l.conn, err = dialLocalSyslog(l.Address)
+	case "tls":
+		l.conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", l.Address, l.TLSConfig)
+	default:
+		l.conn, err = net.DialTimeout(l.Network, l.Address, 10*time.Second)
+	}
+
+	return
+}
+
+// dialLocalSyslog connects to the unix socket of the local syslog daemon
+func dialLocalSyslog(address string) (net.Conn, error) {
+	paths := []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+	if address != "" {
+		paths = []string{address}
+	}
+
+	for _, path := range paths {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				return conn, nil
+			}
+		}
+	}
+
+	return nil, errors.New("no local syslog socket found")
+}
+
+// writeToSyslog sends the entry to the syslog daemon
+func (l *SyslogLogger) writeToSyslog(e Entry) (err error) {
+	message := l.buildMessage(e)
+
+	l.connSync.Lock()
+	defer l.connSync.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if l.conn == nil {
+			if err = l.connect(); err != nil {
+				return
+			}
+		}
+
+		if _, err = l.conn.Write([]byte(message)); err == nil {
+			return
+		}
+
+		l.conn.Close()
+		l.conn = nil
+	}
+
+	return
+}
+
+// buildMessage returns the syslog message for the entry
+func (l *SyslogLogger) buildMessage(e Entry) string {
+	priority := int(l.Facility)*8 + e.Level.getSyslogSeverity()
+	message := strings.TrimSpace(e.prefix()+" "+e.Message) + e.getFieldsMessage()
+
+	// The local daemon expects the traditional BSD format
+	if l.Network == "" {
+		return fmt.Sprintf("<%d>%s %s[%d]: %s\n", priority, e.Time.Format(time.Stamp), l.Tag, os.Getpid(), message)
+	}
+
+	message = fmt.Sprintf("<%d>1 %s %s %s %d - - %s", priority, e.Time.Format(time.RFC3339Nano), l.hostname, l.Tag, os.Getpid(), message)
+
+	// Use octet counting for stream based protocols (RFC 6587)
+	if !strings.HasPrefix(l.Network, "udp") {
+		message = strconv.Itoa(len(message)) + " " + message
+	}
+
+	return message
+}
@@ -0,0 +1,235 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Target is a destination to which the log entries are written.
+// Besides the console and the file you can add your own targets to
+// a logger via the field "Targets".
+// Targets that render the entries as text should use a Formatter for it.
+type Target interface {
+
+	// Enabled returns whether entries with the given level should be
+	// written to this target
+	Enabled(level Level) bool
+
+	// Write writes a single entry to the target
+	Write(e Entry) error
+
+	// Close releases all resources used by the target.
+	// It's called when the logger is closed
+	Close() error
+}
+
+// SourceTarget can be implemented by targets that require the source (file, line
+// and program counter) of the entries. Determining the source is relatively expensive,
+// so it's only done if "PrintSource" is enabled or a target requires it
+type SourceTarget interface {
+	Target
+
+	// NeedsSource returns whether the source of the entries is required
+	NeedsSource() bool
+}
+
+// ConsoleRouting defines to which output (stdout or stderr) the console messages are written
+type ConsoleRouting uint8
+
+const (
+	// Errors and fatal messages are written to stderr, all other messages to stdout
+	RouteErrorsToStderr ConsoleRouting = iota
+
+	// Warnings and above are written to stderr, all other messages to stdout
+	RouteWarningsToStderr
+
+	// All messages are written to stderr like recommended by the twelve-factor app
+	RouteAllToStderr
+
+	// All messages are written to stdout
+	RouteAllToStdout
+)
+
+// isStderr returns whether messages with the given level are written to stderr
+func (r ConsoleRouting) isStderr(level Level) bool {
+	switch r {
+	case RouteWarningsToStderr:
+		return level >= LevelWarning
+	case RouteAllToStderr:
+		return true
+	case RouteAllToStdout:
+		return false
+	default:
+		return level >= LevelError
+	}
+}
+
+// consoleTarget writes the entries to stdout and stderr
+type consoleTarget struct {
+	logger *Logger
+}
+
+func (t consoleTarget) Enabled(level Level) bool {
+	l := t.logger
+
+	// Messages are already written by systemd to the journal
+	if l.Journald != nil && l.Journald.isActive() && l.Journald.ReplaceConsole {
+		return false
+	}
+
+	return l.GetLevel() <= level
+}
+
+func (t consoleTarget) Write(e Entry) error {
+	l := t.logger
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	stderr := l.ConsoleRouting.isStderr(e.Level)
+	b, err := e.appendFormat(*buf, l.Formatter, l.Format, l.colorConf.enabled(stderr))
+	*buf = append(b, '\n')
+	if err != nil {
+		return err
+	}
+
+	out := l.consoleOut
+	if stderr {
+		out = l.consoleErr
+	}
+	l.consoleSync.Lock()
+	_, err = out.Write(*buf)
+	l.consoleSync.Unlock()
+	return err
+}
+
+func (t consoleTarget) Close() error {
+	return nil
+}
+
+// forEachTarget calls the function for every target of the logger in the same order
+// as getTargets() until the function returns false. In contrast to getTargets()
+// no slice is allocated
+func (l *Logger) forEachTarget(fn func(target Target) bool) {
+	if l.File != nil && !fn(l.File) {
+		return
+	}
+	if l.Syslog != nil && !fn(l.Syslog) {
+		return
+	}
+	if l.Journald != nil && !fn(l.Journald) {
+		return
+	}
+	for _, target := range l.Targets {
+		if !fn(target) {
+			return
+		}
+	}
+	fn(consoleTarget{logger: l})
+}
+
+// getTargets returns all targets of the logger. The file, syslog and journald
+// targets are only included if they are configured
+func (l *Logger) getTargets() []Target {
+	targets := make([]Target, 0, len(l.Targets)+4)
+
+	if l.File != nil {
+		targets = append(targets, l.File)
+	}
+	if l.Syslog != nil {
+		targets = append(targets, l.Syslog)
+	}
+	if l.Journald != nil {
+		targets = append(targets, l.Journald)
+	}
+	targets = append(targets, l.Targets...)
+
+	return append(targets, consoleTarget{logger: l})
+}
+
+// Close closes all targets of the logger including the log file
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l = l.resolve()
+
+	if l.async != nil {
+		l.async.close()
+	}
+
+	var errs []error
+	for _, target := range l.getTargets() {
+		errs = append(errs, target.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// Maximum duration a fatal message waits for the shutdown of the logger before the program exits
+const fatalShutdownTimeout = 5 * time.Second
+
+// shutdownState makes sure that a logger and all copies of it are only shut down once
+type shutdownState struct {
+	once sync.Once
+	done chan struct{}
+	err  error
+}
+
+// Shutdown writes all queued messages, waits until the targets sent their buffered entries
+// and closes them including the log file. If the context expires before, the context error is
+// returned and the remaining entries may be lost. Calling it multiple times is safe: further
+// calls wait for the first shutdown and return its result.
+// Fatal messages call it automatically before the program exits
+func (l *Logger) Shutdown(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	state := l.shutdown
+	if state == nil {
+		// The logger was not set up
+		return l.Close()
+	}
+
+	state.once.Do(func() {
+		go func() {
+			state.err = l.Close()
+			close(state.done)
+		}()
+	})
+
+	select {
+	case <-state.done:
+		return state.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flush writes the buffered messages of the log file and of all targets that
+// implement a method "Flush()" or "Flush() error"
+func (l *Logger) Flush() error {
+	if l == nil {
+		return nil
+	}
+	l = l.resolve()
+
+	if l.async != nil {
+		l.async.flush()
+	}
+
+	var errs []error
+	l.forEachTarget(func(target Target) bool {
+		switch t := target.(type) {
+		case interface{ Flush() error }:
+			errs = append(errs, t.Flush())
+		case interface{ Flush() }:
+			t.Flush()
+		}
+		return true
+	})
+
+	return errors.Join(errs...)
+}
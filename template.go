@@ -0,0 +1,194 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// PatternFormatter renders the entries with a pattern string that contains placeholders
+// in the form "%{name}" like:
+//
+//	%{time} %{level:-5} [%{source}] %{message} %{fields}
+//
+// Supported placeholders are:
+//   - level: name of the level ("INFO")
+//   - time: timestamp formatted with the configured time format of the logger
+//   - source: file name and line number ("file.go:1")
+//   - file, line and func: file path, line number and function name of the invoking line
+//   - logger: name of the logger
+//   - prefix: configured prefix of the logger
+//   - message: the message
+//   - fields: all structured fields formatted as "key=value"
+//   - field:NAME: the value of a single structured field
+//
+// The width and alignment of a placeholder can be specified after a colon as with
+// "fmt.Printf()": "%{level:-5}" pads the level to five characters
+type PatternFormatter struct {
+	parts []patternPart
+}
+
+// patternPart is either a literal text or a placeholder of a pattern
+type patternPart struct {
+	literal string
+
+	placeholder string
+	argument    string
+	width       string
+}
+
+// TemplateFormatter renders the entries with a template of the "text/template" package.
+// The template is executed with a TemplateData struct:
+//
+//	{{.Time}} {{printf "%-5s" .Level}} {{.Message}} {{index .Fields "request_id"}}
+type TemplateFormatter struct {
+	template *template.Template
+}
+
+// TemplateData contains the values passed to the template of the TemplateFormatter
+type TemplateData struct {
+	Level   string
+	Time    string
+	Source  string
+	Logger  string
+	Prefix  string
+	Message string
+	Fields  map[string]any
+
+	// The original entry
+	Entry Entry
+}
+
+// NewPatternFormatter parses the pattern and returns a formatter for it
+func NewPatternFormatter(pattern string) (*PatternFormatter, error) {
+	f := &PatternFormatter{}
+
+	for pattern != "" {
+		start := strings.Index(pattern, "%{")
+		if start == -1 {
+			f.parts = append(f.parts, patternPart{literal: pattern})
+			break
+		}
+
+		end := strings.Index(pattern[start:], "}")
+		if end == -1 {
+			return nil, errors.New("unterminated placeholder in pattern at position " + strconv.Itoa(start))
+		}
+		end += start
+
+		if start > 0 {
+			f.parts = append(f.parts, patternPart{literal: pattern[:start]})
+		}
+
+		part := patternPart{placeholder: pattern[start+2 : end]}
+		if name, width, found := strings.Cut(part.placeholder, ":"); found && name != "field" {
+			part.placeholder, part.width = name, width
+		} else if found {
+			part.placeholder = name
+			part.argument, part.width, _ = strings.Cut(width, ":")
+		}
+
+		switch part.placeholder {
+		case "level", "time", "source", "file", "line", "func", "logger", "prefix", "message", "fields", "field":
+		default:
+			return nil, errors.New("unknown placeholder in pattern: " + part.placeholder)
+		}
+
+		f.parts = append(f.parts, part)
+		pattern = pattern[end+1:]
+	}
+
+	return f, nil
+}
+
+// MustPatternFormatter is like NewPatternFormatter() but panics if the pattern is invalid
+func MustPatternFormatter(pattern string) *PatternFormatter {
+	f, err := NewPatternFormatter(pattern)
+	if err != nil {
+		panic(err)
+	}
+
+	return f
+}
+
+// Format renders the entry with the pattern
+func (f *PatternFormatter) Format(e Entry) ([]byte, error) {
+	var b strings.Builder
+
+	for _, part := range f.parts {
+		if part.placeholder == "" {
+			b.WriteString(part.literal)
+			continue
+		}
+
+		value := ""
+		switch part.placeholder {
+		case "level":
+			value = e.Level.String()
+		case "time":
+			value = e.formatTime(defaultTimeFormat)
+		case "source":
+			value = e.sourceName()
+		case "file":
+			value = e.File
+		case "line":
+			value = strconv.Itoa(e.Line)
+		case "func":
+			if fn := runtime.FuncForPC(e.PC); fn != nil {
+				value = fn.Name()
+			}
+		case "logger":
+			value = e.LoggerName
+		case "prefix":
+			value = strings.TrimSpace(e.prefix())
+		case "message":
+			value = e.Message
+		case "fields":
+			value = strings.TrimPrefix(e.getFieldsMessage(), " ")
+		case "field":
+			if fieldValue, ok := e.Fields[part.argument]; ok {
+				value = fmt.Sprintf("%v", fieldValue)
+			}
+		}
+
+		if part.width != "" {
+			value = fmt.Sprintf("%"+part.width+"s", value)
+		}
+		b.WriteString(value)
+	}
+
+	return []byte(b.String()), nil
+}
+
+// NewTemplateFormatter parses the text as a template of the "text/template" package
+// and returns a formatter for it
+func NewTemplateFormatter(text string) (*TemplateFormatter, error) {
+	tmpl, err := template.New("logger").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TemplateFormatter{template: tmpl}, nil
+}
+
+// Format renders the entry with the template
+func (f *TemplateFormatter) Format(e Entry) ([]byte, error) {
+	var b bytes.Buffer
+
+	err := f.template.Execute(&b, TemplateData{
+		Level:   e.Level.String(),
+		Time:    e.formatTime(defaultTimeFormat),
+		Source:  e.sourceName(),
+		Logger:  e.LoggerName,
+		Prefix:  strings.TrimSpace(e.prefix()),
+		Message: e.Message,
+		Fields:  e.Fields,
+		Entry:   e,
+	})
+
+	return b.Bytes(), err
+}
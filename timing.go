@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+// TimeOperation starts measuring the duration of an operation. The returned function
+// logs the elapsed time as the field "duration" with the level "TimingLevel".
+// Use it with defer:
+//
+//	defer l.TimeOperation("load config")()
+func (l *Logger) TimeOperation(name string) func() {
+	return l.startTiming(nil, name)
+}
+
+// TraceSpan logs the start of an operation and returns a function that logs the end
+// with the elapsed time as the field "duration". The fields of the context extractors
+// are added to both messages (see AddContextExtractor()):
+//
+//	defer l.TraceSpan(ctx, "query users")()
+func (l *Logger) TraceSpan(ctx context.Context, name string) func() {
+	return l.startTiming(ctx, name)
+}
+
+// TimeOperation starts measuring the duration of an operation with the global logger
+// (see Logger.TimeOperation())
+func TimeOperation(name string) func() {
+	return dLogger.startTiming(nil, name)
+}
+
+// TraceSpan logs the start and the end of an operation with the logger stored inside
+// the context (see Logger.TraceSpan())
+func TraceSpan(ctx context.Context, name string) func() {
+	return FromContext(ctx).startTiming(ctx, name)
+}
+
+// startTiming logs the start of a span if a context is given and returns the function
+// that logs the end. The source of both messages is the caller of the exported function.
+// This function has to be called directly by them so that the depth of the caller is correct
+func (l *Logger) startTiming(ctx context.Context, name string) func() {
+	if !l.ensureSetup() || !l.Enabled(l.TimingLevel) {
+		return func() {}
+	}
+	level := l.TimingLevel
+
+	var pcs [1]uintptr
+	runtime.Callers(3+l.FuncCallIncrement, pcs[:])
+
+	start := time.Now()
+	fields := map[string]any{"operation": name}
+	if ctx != nil {
+		fields = addContextFields(ctx, fields)
+
+		e := l.NewEntry(level, "Started "+name)
+		e.Fields, e.PC = fields, pcs[0]
+		l.LogEntry(e)
+	}
+
+	return func() {
+		e := l.NewEntry(level, "Finished "+name)
+		e.Fields = make(map[string]any, len(fields)+1)
+		for key, value := range fields {
+			e.Fields[key] = value
+		}
+		e.Fields["duration"] = e.Time.Sub(start)
+		e.PC = pcs[0]
+		l.LogEntry(e)
+	}
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// TLSOptions is a common TLS configuration block for all network targets. Pass it to the
+// field "TLS" of the target, which builds the TLS configuration or the HTTP client from it:
+//
+//	opts := &logger.TLSOptions{CAFile: "ca.pem", CertFile: "client.pem", KeyFile: "client-key.pem"}
+//	target := netlog.New(netlog.Config{Network: "tls", Address: "logs:6514", TLS: opts})
+//
+// Use Config() or HTTPClient() to check the options before or for other libraries
+type TLSOptions struct {
+
+	// PEM encoded certificates of the authorities that are trusted to verify the server.
+	// Defaults to the certificate pool of the system
+	CAFile string `json:"caFile" yaml:"caFile" toml:"caFile"`
+
+	// PEM encoded client certificate and key for mutual TLS (mTLS)
+	CertFile string `json:"certFile" yaml:"certFile" toml:"certFile"`
+	KeyFile  string `json:"keyFile" yaml:"keyFile" toml:"keyFile"`
+
+	// Name of the server used for the verification. Defaults to the host of the address
+	ServerName string `json:"serverName" yaml:"serverName" toml:"serverName"`
+
+	// Minimum TLS version like "1.2" or "1.3". Defaults to "1.2"
+	MinVersion string `json:"minVersion" yaml:"minVersion" toml:"minVersion"`
+
+	// Don't verify the certificate of the server. Only use this for testing
+	InsecureSkipVerify bool `json:"insecureSkipVerify" yaml:"insecureSkipVerify" toml:"insecureSkipVerify"`
+}
+
+// Config builds the TLS configuration. The certificate files are read immediately
+func (o *TLSOptions) Config() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify,
+	}
+
+	switch o.MinVersion {
+	case "", "1.2":
+		config.MinVersion = tls.VersionTLS12
+	case "1.3":
+		config.MinVersion = tls.VersionTLS13
+	case "1.0":
+		config.MinVersion = tls.VersionTLS10
+	case "1.1":
+		config.MinVersion = tls.VersionTLS11
+	default:
+		return nil, fmt.Errorf("unknown TLS version %q", o.MinVersion)
+	}
+
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %q", o.CAFile)
+		}
+	}
+
+	if o.CertFile != "" || o.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// HTTPClient returns a HTTP client with the TLS configuration and the given timeout
+func (o *TLSOptions) HTTPClient(timeout time.Duration) (*http.Client, error) {
+	config, err := o.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = config
+
+	return &http.Client{Timeout: timeout, Transport: transport}, nil
+}
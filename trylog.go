@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+)
+
+// WriteError is the error of a target that failed to write a message
+type WriteError struct {
+
+	// Target that failed to write the message
+	Target Target
+
+	// Error returned by the target
+	Err error
+}
+
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("failed to write log message to %T: %s", e.Target, e.Err)
+}
+
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// handleError passes the error to the "ErrorHandler" of the logger
+func (l *Logger) handleError(err error) {
+	if l.ErrorHandler != nil {
+		l.ErrorHandler(err)
+	}
+}
+
+// TryLog logs a message with the given level like Log() and returns the errors of the
+// targets that failed to write it. Use it for messages that have to be persisted.
+// The message is written directly, even if "Async" is configured.
+// Messages that are not written because of the levels, filters, sampling or deduplication
+// don't return an error
+func (l *Logger) TryLog(level Level, message string, parameters ...any) error {
+	e, ok := l.buildEntry(nil, 2, level, message, parameters)
+	if !ok {
+		return nil
+	}
+
+	return ignoreDropped(l.write(e, true))
+}
+
+// TryLog logs a message with the global logger and returns the errors of the targets
+// (see Logger.TryLog())
+func TryLog(level Level, message string, parameters ...any) error {
+	e, ok := dLogger.buildEntry(nil, 2, level, message, parameters)
+	if !ok {
+		return nil
+	}
+
+	return ignoreDropped(dLogger.write(e, true))
+}
+
+// ignoreDropped returns nil if the error reports that the entry was dropped
+func ignoreDropped(err error) error {
+	if errors.Is(err, errDropped) {
+		return nil
+	}
+	return err
+}
+
+// MustLog logs a message like TryLog() and guarantees that it was written to the log file.
+// The file is synced to the storage (fsync) after the message was written, so use it only
+// for important messages like audit events that must not be lost.
+// An error is returned if the log file doesn't write messages with the level or if the
+// message was dropped, e.g. by a filter, a hook, the sampling or the deduplication
+func (l *Logger) MustLog(level Level, message string, parameters ...any) error {
+	e, ok := l.buildEntry(nil, 2, level, message, parameters)
+	return l.mustWrite(e, ok)
+}
+
+// MustLog logs a message with the global logger and syncs the log file (see Logger.MustLog())
+func MustLog(level Level, message string, parameters ...any) error {
+	e, ok := dLogger.buildEntry(nil, 2, level, message, parameters)
+	return dLogger.mustWrite(e, ok)
+}
+
+// errNotWrittenToFile is returned by MustLog() if the log file doesn't write the message
+var errNotWrittenToFile = errors.New("the message is not written to a log file")
+
+// mustWrite writes the entry synchronously and syncs the log file. An error is returned
+// if the entry was dropped (e.g. by the deduplication) or not written to the file
+func (l *Logger) mustWrite(e Entry, enabled bool) error {
+	l = l.resolve()
+	if !enabled || l.File == nil || !l.File.Enabled(e.Level) {
+		return errNotWrittenToFile
+	}
+	e.requireFile = true
+
+	if err := l.write(e, true); err != nil {
+		return err
+	}
+	return l.File.Sync()
+}
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newMustLogLogger creates a logger that only writes to a log file
+func newMustLogLogger(t *testing.T, config *Logger) (*Logger, string) {
+	path := filepath.Join(t.TempDir(), "test.log")
+	config.Level = LevelOff
+	config.File = &FileLogger{Level: LevelInfo, Path: path}
+
+	l := NewLogger(config)
+	t.Cleanup(func() { l.Close() })
+	return l, path
+}
+
+// countLines returns the number of lines of the file
+func countLines(t *testing.T, path string) int {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read the log file: %s", err)
+	}
+	return strings.Count(string(content), "\n")
+}
+
+func TestMustLogReportsDeduplicatedMessages(t *testing.T) {
+	l, path := newMustLogLogger(t, &Logger{DedupWindow: time.Minute})
+
+	for i := 0; i < 3; i++ {
+		err := l.MustLog(LevelInfo, "same message")
+		if i == 0 && err != nil {
+			t.Fatalf("the first message failed: %s", err)
+		}
+		if i > 0 && err == nil {
+			t.Fatalf("message %d was deduplicated but no error was returned", i+1)
+		}
+	}
+
+	if lines := countLines(t, path); lines != 1 {
+		t.Fatalf("expected 1 line in the log file, got %d", lines)
+	}
+}
+
+func TestMustLogReportsDroppedMessages(t *testing.T) {
+	l, _ := newMustLogLogger(t, &Logger{})
+	l.AddFilter(func(e Entry) bool { return !strings.Contains(e.Message, "filtered") })
+	l.AddHook(func(e Entry) Entry {
+		if strings.Contains(e.Message, "hooked") {
+			return e.Drop()
+		}
+		return e
+	})
+
+	if err := l.MustLog(LevelInfo, "filtered"); err == nil {
+		t.Error("no error for a filtered message")
+	}
+	if err := l.MustLog(LevelInfo, "hooked"); err == nil {
+		t.Error("no error for a message dropped by a hook")
+	}
+	if err := l.TryLog(LevelInfo, "filtered"); err != nil {
+		t.Errorf("TryLog returned an error for a filtered message: %s", err)
+	}
+}
+
+func TestMustLogReportsLoweredLevel(t *testing.T) {
+	l, _ := newMustLogLogger(t, &Logger{LevelRules: []LevelRule{{Pattern: "noisy", Level: LevelDebug}}})
+
+	if err := l.MustLog(LevelInfo, "noisy message"); err == nil {
+		t.Error("no error for a message that was lowered below the level of the file")
+	}
+	if err := l.MustLog(LevelInfo, "important message"); err != nil {
+		t.Errorf("the message failed: %s", err)
+	}
+}
+
+func TestLevelRuleRaisesDisabledLevel(t *testing.T) {
+	l, path := newMustLogLogger(t, &Logger{LevelRules: []LevelRule{{Pattern: "disk full", Level: LevelError}}})
+
+	l.Debug("disk full")
+	l.Debug("other")
+	l.LogEntry(l.NewEntry(LevelDebug, "disk full"))
+	l.Close()
+
+	if lines := countLines(t, path); lines != 2 {
+		t.Fatalf("expected the two raised messages in the file, got %d lines", lines)
+	}
+}
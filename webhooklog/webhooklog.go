@@ -0,0 +1,275 @@
+// webhooklog provides a target that sends the log entries in batches via
+// HTTP POST requests to an arbitrary URL.
+//
+// The body is either a JSON array of the entries or newline delimited JSON (NDJSON).
+// Every entry is rendered by the JSON formatter of the logger.
+// Configure "SpillDir" to keep batches that could not be sent on disk until the
+// endpoint is reachable again
+package webhooklog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/batch"
+	"git.rpjosh.de/RPJosh/go-logger/internal/spill"
+	"git.rpjosh.de/RPJosh/go-logger/retry"
+)
+
+// Encoding defines how a batch of entries is encoded within the request body
+type Encoding uint8
+
+const (
+	// EncodingJSONArray sends the entries as a single JSON array
+	EncodingJSONArray Encoding = iota
+
+	// EncodingNDJSON sends one JSON object per line
+	EncodingNDJSON
+)
+
+// Config contains the configuration options of the webhook target
+type Config struct {
+
+	// Minimum log level of the entries to send
+	Level logger.Level
+
+	// URL the entries are posted to
+	URL string
+
+	// Encoding of the request body
+	Encoding Encoding
+
+	// Additional HTTP headers sent with every request (e.g. "Authorization")
+	Headers map[string]string
+
+	// Timeout of a single request. Defaults to 10 seconds
+	Timeout time.Duration
+
+	// Number of attempts to send a batch. Defaults to 3
+	MaxAttempts int
+
+	// Delay before the first retry. It's doubled for every further attempt. Defaults to 1 second
+	RetryDelay time.Duration
+
+	// Policy for retrying failed requests like the delays between the attempts.
+	// It replaces "MaxAttempts" and "RetryDelay" if set
+	RetryPolicy *retry.Policy
+
+	// The entries of a batch that could not be sent are written to this target
+	// instead. Usually this is the file logger of the logger ("logger.File").
+	// All entries are written regardless of the level of the fallback
+	Fallback logger.Target
+
+	// Directory of a persistent queue on disk. Batches that could not be sent are stored
+	// in it and sent again before the next batch or every 30 seconds. Stored batches also survive a restart
+	// of the program. The directory must not be shared with other targets.
+	// The fallback is only used if the batch can't be stored. The queue is disabled if this is empty
+	SpillDir string
+
+	// Maximum size of the queue on disk in bytes. If it's exceeded, the oldest
+	// batches are dropped. Defaults to 100 MB
+	SpillMaxSize int64
+
+	// Maximum number of entries per request. Defaults to 100
+	BatchSize int
+
+	// Maximum size of the encoded entries per request in bytes. No limit is applied if this is <= 0
+	MaxBatchBytes int
+
+	// Maximum duration an entry is kept before it's sent. Defaults to 5 seconds
+	FlushInterval time.Duration
+
+	// Maximum number of entries that are queued. If the queue is full, entries are dropped.
+	// Defaults to 1000
+	QueueSize int
+
+	// HTTP client used for the requests
+	HTTPClient *http.Client
+
+	// TLS options used to build the HTTP client if "HTTPClient" is nil (see logger.TLSOptions)
+	TLS *logger.TLSOptions
+}
+
+// Target posts the entries to a webhook
+type Target struct {
+	config  Config
+	batcher *batch.Batcher[entry]
+	health  logger.HealthStatus
+	sender  spill.Sender
+}
+
+// entry is a log entry with its JSON representation
+type entry struct {
+	entry logger.Entry
+	json  []byte
+}
+
+// New creates a new webhook target with the given configuration.
+// Add it to the field "Targets" of a logger
+func New(config Config) *Target {
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = 3
+	}
+	if config.RetryDelay <= 0 {
+		config.RetryDelay = time.Second
+	}
+	if config.RetryPolicy == nil {
+		config.RetryPolicy = &retry.Policy{MaxAttempts: config.MaxAttempts, InitialDelay: config.RetryDelay}
+	}
+	if config.HTTPClient == nil && config.TLS != nil {
+		client, err := config.TLS.HTTPClient(config.Timeout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "webhooklog: invalid TLS options: %s\n", err)
+		}
+		config.HTTPClient = client
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = &http.Client{Timeout: config.Timeout}
+	}
+
+	t := &Target{config: config}
+	t.sender = spill.Sender{Name: "webhooklog", Policy: config.RetryPolicy, Send: t.post}
+	if config.SpillDir != "" {
+		queue, err := spill.Open(spill.Options{Dir: config.SpillDir, MaxSize: config.SpillMaxSize})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "webhooklog: failed to open the spill queue: %s\n", err)
+		}
+		t.sender.Queue = queue
+		t.sender.Start()
+	}
+	t.batcher = batch.New(batch.Options[entry]{
+		MaxItems:   config.BatchSize,
+		MaxBytes:   config.MaxBatchBytes,
+		Size:       func(e entry) int { return len(e.json) + 1 },
+		MaxLatency: config.FlushInterval,
+		QueueSize:  config.QueueSize,
+	}, t.send)
+
+	return t
+}
+
+// Enabled returns whether entries with the level are sent
+func (t *Target) Enabled(level logger.Level) bool {
+	return t.config.Level <= level
+}
+
+// Write formats the entry and queues it for sending
+func (t *Target) Write(e logger.Entry) error {
+	json, err := logger.JSONFormatter{}.Format(e)
+	if err != nil {
+		return err
+	}
+
+	if !t.batcher.Add(entry{entry: e, json: json}) {
+		return errors.New("webhooklog: queue is full, entry dropped")
+	}
+
+	return nil
+}
+
+// Flush sends all queued entries
+func (t *Target) Flush() {
+	t.batcher.Flush()
+}
+
+// Stats returns the metrics of the sent batches like their size and the flush latency
+func (t *Target) Stats() batch.Stats {
+	return t.batcher.Stats()
+}
+
+// Health returns the state of the target with the last error of sending a batch
+func (t *Target) Health() logger.SinkHealth {
+	health := t.health.Status()
+	health.QueueDepth = t.batcher.Stats().Queued + t.sender.Len()
+	return health
+}
+
+// Close sends all queued entries and stops the background worker
+func (t *Target) Close() error {
+	t.batcher.Close()
+	return t.sender.Close()
+}
+
+// send posts a batch of entries to the URL. If that fails, the batch is stored in the
+// spill queue or the entries are written to the fallback
+func (t *Target) send(entries []entry) {
+	stored, err := t.sender.Deliver(t.encode(entries))
+	t.health.Report(err)
+	if err == nil || stored {
+		return
+	}
+
+	if t.config.Fallback == nil {
+		fmt.Fprintf(os.Stderr, "webhooklog: failed to send %d entries: %s\n", len(entries), err)
+		return
+	}
+	for _, e := range entries {
+		t.config.Fallback.Write(e.entry)
+	}
+}
+
+// encode returns the request body for the entries
+func (t *Target) encode(entries []entry) []byte {
+	var b bytes.Buffer
+	if t.config.Encoding == EncodingJSONArray {
+		b.WriteByte('[')
+	}
+
+	for i, e := range entries {
+		if i > 0 && t.config.Encoding == EncodingJSONArray {
+			b.WriteByte(',')
+		}
+		b.Write(e.json)
+		if t.config.Encoding == EncodingNDJSON {
+			b.WriteByte('\n')
+		}
+	}
+
+	if t.config.Encoding == EncodingJSONArray {
+		b.WriteByte(']')
+	}
+
+	return b.Bytes()
+}
+
+// post executes a single request. Client errors (except "429 Too Many Requests") are not retried
+func (t *Target) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, t.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	if t.config.Encoding == EncodingNDJSON {
+		req.Header.Set("Content-Type", "application/x-ndjson")
+	} else {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range t.config.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := t.config.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return nil
+	}
+
+	err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	if resp.StatusCode >= 400 && resp.StatusCode <= 499 && resp.StatusCode != http.StatusTooManyRequests {
+		return retry.Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// WriterTarget writes the formatted entries line by line to an io.Writer.
+// Add multiple writer targets to the field "Targets" of a logger to write
+// to several outputs with an own minimum level and formatter each:
+//
+//	logger.NewLogger(&logger.Logger{
+//		Level: logger.LevelInfo,
+//		ColoredOutput: true,
+//		Targets: []logger.Target{
+//			logger.NewWriterTarget(jsonFile, logger.LevelDebug, logger.JSONFormatter{}),
+//			netlog.New(netlog.Config{
+//				Level: logger.LevelWarning, Address: "graylog:12201",
+//				Formatter: logger.GELFFormatter{}, Delimiter: "\x00",
+//			}),
+//		},
+//	})
+type WriterTarget struct {
+
+	// Minimum log level of the entries to write
+	Level Level
+
+	// Writer to write the entries to
+	Writer io.Writer
+
+	// Formatter used for the entries. Defaults to the text format without colors
+	Formatter Formatter
+
+	// Delimiter appended after every entry. Defaults to a line break
+	Delimiter string
+
+	writeSync sync.Mutex
+}
+
+// NewWriterTarget returns a target that writes the entries with at least the given
+// level formatted by the formatter to the writer
+func NewWriterTarget(w io.Writer, level Level, formatter Formatter) *WriterTarget {
+	return &WriterTarget{Level: level, Writer: w, Formatter: formatter}
+}
+
+// SetLevel changes the minimum log level of the target.
+// This method is safe to call while other goroutines are logging
+func (t *WriterTarget) SetLevel(level Level) {
+	t.Level.store(level)
+}
+
+// Enabled returns whether entries with the level are written
+func (t *WriterTarget) Enabled(level Level) bool {
+	return t.Level.load() <= level
+}
+
+// Write formats the entry and writes it to the writer.
+// The writes are serialized, so the writer doesn't have to be safe for concurrent use
+func (t *WriterTarget) Write(e Entry) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	b, err := e.appendFormat(*buf, t.Formatter, FormatText, false)
+	if t.Delimiter == "" {
+		*buf = append(b, '\n')
+	} else {
+		*buf = append(b, t.Delimiter...)
+	}
+	if err != nil {
+		return err
+	}
+
+	t.writeSync.Lock()
+	_, err = t.Writer.Write(*buf)
+	t.writeSync.Unlock()
+
+	return err
+}
+
+// Close closes the writer if it implements io.Closer. Stdout and stderr are
+// never closed
+func (t *WriterTarget) Close() error {
+	if closer, ok := t.Writer.(io.Closer); ok && t.Writer != os.Stdout && t.Writer != os.Stderr {
+		return closer.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,12 @@
+module git.rpjosh.de/RPJosh/go-logger/zaplog
+
+go 1.21
+
+require (
+	git.rpjosh.de/RPJosh/go-logger v0.0.0-20261016032005-162d53f8b99c
+	go.uber.org/zap v1.27.0
+)
+
+require go.uber.org/multierr v1.10.0 // indirect
+
+replace git.rpjosh.de/RPJosh/go-logger => ../
@@ -0,0 +1,94 @@
+// zaplog provides a "zapcore.Core" that writes the entries of zap to a logger, so zap based
+// libraries use the same targets, file rotation and formatting as the rest of the application.
+// It's a separate module, so that only programs that use it depend on "go.uber.org/zap":
+//
+//	zapLogger := zaplog.New(logger.Named("zap"))
+//	library.SetLogger(zapLogger)
+package zaplog
+
+import (
+	"git.rpjosh.de/RPJosh/go-logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Core is a zapcore.Core that writes the entries to a logger
+type Core struct {
+	logger *logger.Logger
+}
+
+// NewCore returns a core that writes the entries to the given logger.
+// The levels of the logger decide which entries are enabled
+func NewCore(l *logger.Logger) *Core {
+	return &Core{logger: l}
+}
+
+// New returns a zap logger that writes to the given logger. The caller is added
+// to the entries, so the source of the messages is printed correctly
+func New(l *logger.Logger, options ...zap.Option) *zap.Logger {
+	return zap.New(NewCore(l), append([]zap.Option{zap.AddCaller()}, options...)...)
+}
+
+func (c *Core) Enabled(level zapcore.Level) bool {
+	return c.logger.Enabled(getLevel(level))
+}
+
+func (c *Core) With(fields []zapcore.Field) zapcore.Core {
+	return &Core{logger: c.logger.WithFields(encodeFields(fields))}
+}
+
+func (c *Core) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+func (c *Core) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	entry := c.logger.NewEntry(getLevel(e.Level), e.Message)
+	entry.Time = e.Time
+	entry.Fields = encodeFields(fields)
+	entry.Stack = e.Stack
+	if e.LoggerName != "" {
+		entry.LoggerName = e.LoggerName
+	}
+	if e.Caller.Defined {
+		entry.File, entry.Line, entry.PC = e.Caller.File, e.Caller.Line, e.Caller.PC
+	}
+
+	c.logger.LogEntry(entry)
+	return nil
+}
+
+// Sync writes the buffered messages of the logger (see Logger.Flush())
+func (c *Core) Sync() error {
+	return c.logger.Flush()
+}
+
+// encodeFields converts the fields of zap to a map
+func encodeFields(fields []zapcore.Field) map[string]any {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+// getLevel converts the level of zap. Panic and fatal entries are handled by zap itself,
+// so they are logged as error
+func getLevel(level zapcore.Level) logger.Level {
+	switch {
+	case level < zapcore.InfoLevel:
+		return logger.LevelDebug
+	case level < zapcore.WarnLevel:
+		return logger.LevelInfo
+	case level < zapcore.ErrorLevel:
+		return logger.LevelWarning
+	default:
+		return logger.LevelError
+	}
+}
@@ -0,0 +1,56 @@
+package zaplog
+
+import (
+	"path/filepath"
+	"testing"
+
+	"git.rpjosh.de/RPJosh/go-logger"
+	"git.rpjosh.de/RPJosh/go-logger/loggertest"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCore(t *testing.T) {
+	l := loggertest.NewTestLogger(t)
+	zapLogger := New(l.Logger).Named("library").With(zap.String("component", "cache"))
+
+	zapLogger.Debug("debug message")
+	zapLogger.Warn("cache miss", zap.Int("size", 3))
+
+	entries := l.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	e := entries[1]
+	if e.Level != logger.LevelWarning || e.Message != "cache miss" || e.LoggerName != "library" {
+		t.Errorf("unexpected entry: %s %q %q", e.Level, e.Message, e.LoggerName)
+	}
+	if e.Fields["component"] != "cache" || e.Fields["size"] != int64(3) {
+		t.Errorf("unexpected fields: %v", e.Fields)
+	}
+	if filepath.Base(e.File) != "zaplog_test.go" {
+		t.Errorf("the caller was not set: %s:%d", e.File, e.Line)
+	}
+}
+
+func TestCoreLevels(t *testing.T) {
+	core := NewCore(loggertest.NewTestLogger(t).Logger)
+
+	tests := map[zapcore.Level]logger.Level{
+		zapcore.DebugLevel:  logger.LevelDebug,
+		zapcore.InfoLevel:   logger.LevelInfo,
+		zapcore.WarnLevel:   logger.LevelWarning,
+		zapcore.ErrorLevel:  logger.LevelError,
+		zapcore.DPanicLevel: logger.LevelError,
+		zapcore.FatalLevel:  logger.LevelError,
+	}
+	for zapLevel, level := range tests {
+		if got := getLevel(zapLevel); got != level {
+			t.Errorf("%s was converted to %s instead of %s", zapLevel, got, level)
+		}
+		if !core.Enabled(zapLevel) {
+			t.Errorf("%s is not enabled", zapLevel)
+		}
+	}
+}